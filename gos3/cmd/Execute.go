@@ -1,7 +1,10 @@
 package cmd
 
 import (
-	"log"
+	"gos3/internal/config"
+	"gos3/internal/notify"
+	"log/slog"
+	"os"
 
 	"github.com/spf13/cobra"
 )
@@ -9,13 +12,16 @@ import (
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("Command failed", "error", err)
+		os.Exit(1)
 	}
 }
 
 func addListFlags(cmd *cobra.Command) {
 	cmd.Flags().String("prefix", "", "Prefix of bucket")
 	cmd.Flags().String("delimiter", "", "List delimiter. '' for recursive '/' for local items only")
+	cmd.Flags().Bool("recursive", false, "List every item below the prefix, ignoring --delimiter")
+	cmd.Flags().Int("max-keys", 0, "Maximum number of items to list (0 lists all)")
 }
 
 func addKeyFlags(cmd *cobra.Command) {
@@ -24,6 +30,12 @@ func addKeyFlags(cmd *cobra.Command) {
 }
 
 func init() {
+	rootCmd.PersistentFlags().String("log-format", "text", "Log format: text or json")
+	rootCmd.PersistentFlags().String("log-level", "info", "Log level: debug, info, warn or error")
+	rootCmd.PersistentFlags().String("log-file", "", "Also append the log to this file")
+	rootCmd.PersistentFlags().String("log-max-size", "10M", "Rotate the log file when it reaches this size (0 never rotates)")
+	rootCmd.PersistentFlags().Int("log-max-files", 5, "Number of rotated log files to keep")
+
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(derivekeyCmd)
 	rootCmd.AddCommand(volumebackupCmd)
@@ -40,12 +52,121 @@ func init() {
 	rootCmd.AddCommand(manualBackupCmd)
 	rootCmd.AddCommand(downloadCmd)
 	rootCmd.AddCommand(folderdecryptCmd)
+	rootCmd.AddCommand(pruneCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(restoreTestCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(hubCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(extractCmd)
+	rootCmd.AddCommand(lsCmd)
+	rootCmd.AddCommand(catalogCmd)
+	catalogCmd.AddCommand(catalogRebuildCmd)
+	catalogCmd.AddCommand(catalogQueryCmd)
+	rootCmd.AddCommand(notifyCmd)
+	notifyCmd.AddCommand(notifyTestCmd)
 
 	volumebackupCmd.Flags().BoolP("no-compression", "n", false, "Create backup without compression")
+	volumebackupCmd.Flags().String("codec", "gzip", "Compression codec: none, gzip, zstd, xz or lz4")
+	volumebackupCmd.Flags().Int("level", 0, "Compression level (0 uses the codec default)")
+	volumebackupCmd.Flags().Int("threads", 0, "Compression threads (0 uses the codec default)")
 
 	s3UploadCmd.Flags().String("local", "", "Override the local folder path from config")
 	s3UploadCmd.Flags().String("s3folder", "", "Override the S3 folder path from config")
 
+	pruneCmd.Flags().Bool("dry-run", false, "Only print the backups that would be removed")
+	pruneCmd.Flags().String("target", "", "Only prune this target (default all targets)")
+
+	syncCmd.Flags().String("from", config.DefaultTargetName, "Target to copy backups from")
+	syncCmd.Flags().String("to", "", "Target to copy backups to")
+	syncCmd.Flags().Bool("dry-run", false, "Only print the backups that would be copied")
+	syncCmd.MarkFlagRequired("to")
+
+	verifyCmd.Flags().Bool("quick", false, "Compare object sizes and ETags with the manifest (default)")
+	verifyCmd.Flags().Bool("full", false, "Also decrypt and decompress every item as a stream")
+	verifyCmd.Flags().String("date", "latest", "Date folder to verify, 'latest' or 'all'")
+	verifyCmd.Flags().String("target", config.DefaultTargetName, "Target to verify")
+	verifyCmd.Flags().String("definition", "", "Only verify items of this backup definition")
+	verifyCmd.MarkFlagsMutuallyExclusive("quick", "full")
+
+	restoreTestCmd.Flags().String("name", "", "Only run the configured restore test with this name")
+	restoreTestCmd.Flags().String("definition", "", "Run an ad-hoc restore test of this backup definition")
+	restoreTestCmd.Flags().StringSlice("volume", nil, "Volume to restore with --definition (default all)")
+	restoreTestCmd.Flags().String("pick", "latest", "Backup to restore with --definition: latest or random")
+	restoreTestCmd.Flags().String("target", config.DefaultTargetName, "Target to restore from with --definition")
+	restoreTestCmd.MarkFlagsMutuallyExclusive("name", "definition")
+
+	restoreCmd.Flags().String("definition", "", "Backup definition to restore")
+	restoreCmd.Flags().String("date", "latest", "Date folder to restore or 'latest'")
+	restoreCmd.Flags().StringSlice("volume", nil, "Volume to restore (default all volumes of the definition)")
+	restoreCmd.Flags().String("target-volume", "", "Restore the single selected volume into this volume instead")
+	restoreCmd.Flags().String("target", config.DefaultTargetName, "Target to restore from")
+	restoreCmd.Flags().Bool("dry-run", false, "Only print the restore plan")
+	restoreCmd.MarkFlagRequired("definition")
+
+	extractCmd.Flags().String("date", "latest", "Date folder to extract from or 'latest'")
+	extractCmd.Flags().String("volume", "", "Volume to extract from")
+	extractCmd.Flags().String("definition", "", "Backup definition of the volume (needed when several back it up)")
+	extractCmd.Flags().String("target", config.DefaultTargetName, "Target to extract from")
+	extractCmd.Flags().StringArray("path", nil, "Path pattern to extract, may be repeated")
+	extractCmd.Flags().String("out", "", "Folder to write the extracted files to")
+	extractCmd.MarkFlagRequired("volume")
+	extractCmd.MarkFlagRequired("path")
+	extractCmd.MarkFlagRequired("out")
+
+	lsCmd.Flags().String("date", "latest", "Date folder to list or 'latest'")
+	lsCmd.Flags().String("volume", "", "Volume to list")
+	lsCmd.Flags().String("definition", "", "Backup definition of the volume (needed when several back it up)")
+	lsCmd.Flags().String("target", config.DefaultTargetName, "Target to list from")
+	lsCmd.Flags().StringArray("path", nil, "Only list paths matching this pattern, may be repeated")
+	lsCmd.Flags().Bool("json", false, "Print the entries as JSON")
+	lsCmd.MarkFlagRequired("volume")
+
+	catalogRebuildCmd.Flags().String("target", "", "Only rebuild this target (default all targets)")
+	catalogQueryCmd.Flags().String("target", "", "Only list runs against this target")
+	catalogQueryCmd.Flags().String("definition", "", "Only list runs of this backup definition")
+	catalogQueryCmd.Flags().String("volume", "", "Only list runs containing this volume and report its sizes")
+	catalogQueryCmd.Flags().String("status", "", "Only list runs with this status: success or failed")
+	catalogQueryCmd.Flags().String("since", "", "Only list runs started within this duration, e.g. 30d")
+	catalogQueryCmd.Flags().String("format", "table", "Output format: table or json")
+
+	statusCmd.Flags().String("stale-after", "", "Flag definitions without a success for this long (default twice the schedule interval or 26h)")
+	statusCmd.Flags().Bool("offline", false, "Do not read the targets for the latest backup date")
+	statusCmd.Flags().String("format", "table", "Output format: table or json")
+
+	historyCmd.Flags().String("definition", "", "Only list runs of this backup definition")
+	historyCmd.Flags().String("status", "", "Only list runs with this status: success, degraded or failed")
+	historyCmd.Flags().String("since", "", "Only list runs started within this duration, e.g. 7d")
+	historyCmd.Flags().Int("limit", 20, "Maximum number of runs to list (0 lists all)")
+	historyCmd.Flags().String("format", "table", "Output format: table or json")
+
+	reportCmd.Flags().String("from", "", "First day of the report, YYYY-MM-DD")
+	reportCmd.Flags().String("to", "", "Last day of the report, YYYY-MM-DD (default today)")
+	reportCmd.Flags().String("period", "month", "Period before today when --from is not set: month, week or a duration such as 30d")
+	reportCmd.Flags().String("format", "html", "Output format: html, md or json")
+	reportCmd.Flags().String("out", "", "Write the report to this file")
+	reportCmd.Flags().String("email", "", "Mail the report through the smtp notification channel with this name")
+	reportCmd.Flags().Bool("upload", false, "Upload the report below compliance.prefix (default reports) in the bucket")
+	reportCmd.Flags().Bool("offline", false, "Do not read the targets for the storage consumed")
+
+	doctorCmd.Flags().String("format", "table", "Output format: table or json")
+
+	notifyTestCmd.Flags().String("channel", "", "Only send to the channel with this name (default all channels)")
+	notifyTestCmd.Flags().String("event", notify.EventRunFailed, "Event to send: run_started, run_succeeded, run_partial, run_failed, verification_failed or retention_pruned")
+
+	for _, command := range []*cobra.Command{listCmd, downloadCmd, pruneCmd} {
+		command.Flags().Bool("no-catalog", false, "Read the bucket instead of the local catalog")
+	}
+
+	for _, command := range []*cobra.Command{manualBackupCmd, pruneCmd, verifyCmd, restoreTestCmd} {
+		command.RunE = withMetricsTextfile(command.RunE)
+	}
+
 	addListFlags(listCmd)
 	addKeyFlags(derivekeyCmd)
 }
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gos3/internal/catalog"
+	"gos3/internal/config"
+	"gos3/internal/s3"
+
+	"github.com/spf13/cobra"
+)
+
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "Query or rebuild the local catalog of backup runs",
+	Long: `The catalog in the state folder records every backup run per target, date
+and definition: status, timings, sizes per volume and the stored objects. It
+is updated on every backup, prune and sync. Once rebuilt from a target, list,
+download and prune read dates and items from it instead of scanning the
+bucket.`,
+}
+
+var catalogRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Rebuild the catalog from the run manifests in the bucket",
+	Long: `Read the run manifest of every date folder of the targets and replace their
+successful runs in the catalog. Failed runs are kept, the bucket does not
+know about them. Run it once after upgrading, and whenever another host or
+a manual copy changed a target.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		targets := cfg.AllTargetNames()
+		if target, _ := cmd.Flags().GetString("target"); target != "" {
+			targets = []string{target}
+		}
+
+		for _, target := range targets {
+			targetCfg, err := cfg.ForTarget(target)
+			if err != nil {
+				return err
+			}
+
+			runs, err := s3.RebuildCatalog(targetCfg)
+			if err != nil {
+				return fmt.Errorf("failed to rebuild catalog of target %s: %w", target, err)
+			}
+			fmt.Printf("Catalog of %s rebuilt: %d runs\n", target, runs)
+		}
+		return nil
+	},
+}
+
+var catalogQueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "List recorded backup runs",
+	Example: `  gos3 catalog query --volume pgdata --since 30d
+  gos3 catalog query --status failed --format json`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		filter := catalog.Filter{}
+		filter.Target, _ = cmd.Flags().GetString("target")
+		filter.Definition, _ = cmd.Flags().GetString("definition")
+		filter.Volume, _ = cmd.Flags().GetString("volume")
+		filter.Status, _ = cmd.Flags().GetString("status")
+		format, _ := cmd.Flags().GetString("format")
+		if since, _ := cmd.Flags().GetString("since"); since != "" {
+			age, err := config.ParseDuration(since)
+			if err != nil {
+				return err
+			}
+			filter.Since = time.Now().Add(-age)
+		}
+
+		cat, err := catalog.Open(cfg)
+		if err != nil {
+			return err
+		}
+		defer cat.Close()
+
+		runs, err := cat.Runs(filter)
+		if err != nil {
+			return err
+		}
+
+		summaries := make([]catalog.RunSummary, 0, len(runs))
+		for _, run := range runs {
+			summaries = append(summaries, catalog.Summarize(run, filter.Volume))
+		}
+
+		switch format {
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(summaries)
+		case "table":
+			printRunSummaries(summaries, filter.Volume != "")
+			return nil
+		default:
+			return fmt.Errorf("unknown format %q, expected table or json", format)
+		}
+	},
+}
+
+// With perVolume the sizes are those of the selected volume's archive.
+func printRunSummaries(summaries []catalog.RunSummary, perVolume bool) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "DATE\tDEFINITION\tTARGET\tSTATUS\tSTARTED\tDURATION\tSIZE\tORIGINAL\tERROR")
+	for _, summary := range summaries {
+		size, original := summary.Size, summary.OriginalSize
+		if perVolume && len(summary.Volumes) > 0 {
+			size, original = summary.Volumes[0].Size, summary.Volumes[0].OriginalSize
+		}
+
+		definition, started, duration := summary.Definition, "-", "-"
+		if summary.Legacy {
+			definition = "(no manifest)"
+		}
+		if !summary.StartedAt.IsZero() {
+			started = summary.StartedAt.Local().Format("2006-01-02 15:04")
+		}
+		if summary.Seconds > 0 {
+			duration = time.Duration(summary.Seconds * float64(time.Second)).Round(time.Second).String()
+		}
+
+		firstLine, _, _ := strings.Cut(summary.Error, "\n")
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", summary.Date, definition, summary.Target,
+			summary.Status, started, duration, s3.FormatSize(size), s3.FormatSize(original), firstLine)
+	}
+	writer.Flush()
+}
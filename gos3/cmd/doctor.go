@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gos3/internal/config"
+	"gos3/internal/doctor"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the host, configuration, targets and keys for problems",
+	Long: `Check everything a backup or restore depends on and print a fix for every
+problem: the Docker daemon and its version, the containers and volumes of
+every backup definition, the scripts folder, the host tools and openssl,
+the local folders, list, put and delete of a probe object on every target,
+the clock against every S3 endpoint, the public key and whether the private
+key decrypts what is encrypted for it.
+
+The key pair check needs the private key password from
+app.privateKeyPassword or, on a terminal, a prompt. Exits with an error when
+a check failed.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		if format != "table" && format != "json" {
+			return fmt.Errorf("unknown format %q, expected table or json", format)
+		}
+
+		var password string
+		if !cfg.App.PrivateKeyPassword.IsSet() && cfg.App.PrivateKeyFile != "" && term.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Print("Enter private key password for the key pair check (empty to skip): ")
+			passwordBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
+			fmt.Println()
+			if err != nil {
+				return fmt.Errorf("failed to read password: %w", err)
+			}
+			password = string(passwordBytes)
+		}
+
+		var checks []doctor.Check
+		doctor.Run(password, cfg, func(check doctor.Check) {
+			checks = append(checks, check)
+			if format == "table" {
+				printCheck(check)
+			}
+		})
+
+		counts := make(map[string]int)
+		for _, check := range checks {
+			counts[check.Status]++
+		}
+		if format == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(checks)
+			if err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("\n%d ok, %d warnings, %d failed, %d skipped\n",
+				counts[doctor.StatusOK], counts[doctor.StatusWarn], counts[doctor.StatusFail], counts[doctor.StatusSkip])
+		}
+
+		if counts[doctor.StatusFail] > 0 {
+			return fmt.Errorf("%d checks failed", counts[doctor.StatusFail])
+		}
+		return nil
+	},
+}
+
+func printCheck(check doctor.Check) {
+	fmt.Printf("%-5s %s: %s\n", strings.ToUpper(check.Status), check.Name, check.Detail)
+	if check.Fix != "" && check.Status != doctor.StatusOK {
+		fmt.Printf("      fix: %s\n", check.Fix)
+	}
+}
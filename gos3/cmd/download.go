@@ -20,6 +20,9 @@ func runDownload(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
+	if noCatalog, _ := cmd.Flags().GetBool("no-catalog"); noCatalog {
+		cfg.App.DisableCatalog = true
+	}
 
 	return s3.DownloadBackup(cfg)
 }
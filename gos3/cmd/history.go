@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gos3/internal/config"
+	"gos3/internal/history"
+	"gos3/internal/s3"
+
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List the backup runs of this host",
+	Long: `List the runs recorded in history.jsonl in the state folder, newest first:
+start and end, status, stored bytes, the duration of each step and the
+error of failed runs.`,
+	Example: `  gos3 history --definition app --since 7d
+  gos3 history --status failed --format json`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		filter := history.Filter{}
+		filter.Definition, _ = cmd.Flags().GetString("definition")
+		filter.Status, _ = cmd.Flags().GetString("status")
+		filter.Limit, _ = cmd.Flags().GetInt("limit")
+		format, _ := cmd.Flags().GetString("format")
+		if since, _ := cmd.Flags().GetString("since"); since != "" {
+			age, err := config.ParseDuration(since)
+			if err != nil {
+				return err
+			}
+			filter.Since = time.Now().Add(-age)
+		}
+
+		records, err := history.Read(filter, cfg)
+		if err != nil {
+			return err
+		}
+
+		switch format {
+		case "json":
+			if records == nil {
+				records = []history.Record{}
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(records)
+		case "table":
+			printHistory(records)
+			return nil
+		default:
+			return fmt.Errorf("unknown format %q, expected table or json", format)
+		}
+	},
+}
+
+func printHistory(records []history.Record) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STARTED\tDEFINITION\tSTATUS\tDURATION\tDOWNTIME\tARCHIVE\tENCRYPT\tUPLOAD\tSIZE\tDATE\tERROR")
+	for _, record := range records {
+		firstLine, _, _ := strings.Cut(record.Error, "\n")
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			record.StartedAt.Local().Format("2006-01-02 15:04"), record.Definition, record.Status,
+			record.Duration().Round(time.Second), seconds(record.Steps.DowntimeSeconds), seconds(record.Steps.ArchiveSeconds),
+			seconds(record.Steps.EncryptSeconds), seconds(record.Steps.UploadSeconds), s3.FormatSize(record.Bytes),
+			record.Date, firstLine)
+	}
+	writer.Flush()
+}
+
+// seconds formats a step duration, "-" for a step that did not run.
+func seconds(value float64) string {
+	if value == 0 {
+		return "-"
+	}
+	duration := time.Duration(value * float64(time.Second))
+	if duration < time.Second {
+		return duration.Round(time.Millisecond).String()
+	}
+	return duration.Round(100 * time.Millisecond).String()
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+
+	"gos3/internal/config"
+	"gos3/internal/hub"
+
+	"github.com/spf13/cobra"
+)
+
+var hubCmd = &cobra.Command{
+	Use:   "hub",
+	Short: "Collect run reports from many hosts and serve a dashboard",
+	Long: `Run in the foreground as the central hub: agents with a report block send a
+report of every backup run over HTTPS, authenticated with a token from
+hub.agents. The hub stores them and serves a dashboard on / and a JSON API
+on /api/v1/reports and /api/v1/summary to the logins in hub.viewers.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		return hub.Run(cfg)
+	},
+}
@@ -9,7 +9,11 @@ import (
 
 var listCmd = &cobra.Command{
 	Use:   "list",
-	Short: "list",
+	Short: "List the items of the configured storage",
+	Long: `List the items of the configured storage. Listings of the backup folder
+and of a date folder come from the local catalog once it was rebuilt from the
+bucket (see gos3 catalog); folder sizes are then the size of their content.
+--no-catalog always lists the bucket.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s3config, err := config.LoadConfiguration("")
 		if err != nil {
@@ -17,11 +21,30 @@ var listCmd = &cobra.Command{
 		}
 		prefix, _ := cmd.Flags().GetString("prefix")
 		delimiter, _ := cmd.Flags().GetString("delimiter")
-		items, err := s3.ListS3Bucket(s3config, prefix, delimiter)
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		maxKeys, _ := cmd.Flags().GetInt("max-keys")
+		if recursive {
+			delimiter = ""
+		}
+		if noCatalog, _ := cmd.Flags().GetBool("no-catalog"); noCatalog {
+			s3config.App.DisableCatalog = true
+		}
+
+		if items, ok := s3.CatalogListing(s3config, prefix, delimiter); ok {
+			if maxKeys > 0 && len(items) > maxKeys {
+				items = items[:maxKeys]
+			}
+			s3.PrintS3ItemList(items)
+			s3.PrintS3ItemSummary(items)
+			return nil
+		}
+
+		items, err := s3.ListS3Bucket(s3config, prefix, delimiter, maxKeys)
 		if err != nil {
 			return err
 		}
 		s3.PrintS3ItemList(items)
+		s3.PrintS3ItemSummary(items)
 		return nil
 	},
 }
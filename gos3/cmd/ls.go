@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gos3/internal/archive"
+	"gos3/internal/backupops"
+	"gos3/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var lsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List the files in a volume backup",
+	Long: `List the content of a volume as it was at a backup date, including the
+changes of every incremental backup up to it. Listings come from the content
+index written at backup time: the local copy in the state folder needs no
+download at all, otherwise the small encrypted index is fetched. Backups made
+before indexes existed are streamed once and cached. The private key password
+is only needed when something has to be decrypted.`,
+	Example: `  gos3 ls --volume app_data
+  gos3 ls --date 2024-01-02 --volume app_data --path 'uploads/**'`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		date, _ := cmd.Flags().GetString("date")
+		volume, _ := cmd.Flags().GetString("volume")
+		definition, _ := cmd.Flags().GetString("definition")
+		target, _ := cmd.Flags().GetString("target")
+		paths, _ := cmd.Flags().GetStringArray("path")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		chain, err := backupops.PlanBrowse(definition, volume, date, target, cfg)
+		if err != nil {
+			return err
+		}
+
+		var password string
+		askPassword := func() (string, error) {
+			if password == "" {
+				password, err = privateKeyPassword(cfg)
+			}
+			return password, err
+		}
+
+		entries, err := backupops.ListBackup(chain, askPassword, cfg)
+		if err != nil {
+			return err
+		}
+
+		var selected []archive.Entry
+		for _, entry := range entries {
+			if archive.MatchAny(paths, entry.Path) {
+				selected = append(selected, entry)
+			}
+		}
+
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(selected)
+		}
+		backupops.PrintArchiveEntries(selected)
+		return nil
+	},
+}
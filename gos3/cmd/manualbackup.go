@@ -1,10 +1,11 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"gos3/internal/config"
 	"gos3/internal/backupops"
-	"log"
+	"log/slog"
 
 	"github.com/spf13/cobra"
 )
@@ -23,12 +24,16 @@ func runManualBackup(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	log.Println("Starting manual backup process for all defined backups")
+	slog.Info("Starting manual backup process for all defined backups")
 	err = backupops.PerformBackups(cfg)
+	if errors.Is(err, backupops.ErrDegraded) {
+		slog.Warn("Manual backup process completed with missing targets", "error", err)
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("failed to perform backups: %w", err)
 	}
-	log.Println("Manual backup process completed successfully")
+	slog.Info("Manual backup process completed successfully")
 
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"log/slog"
+
+	"gos3/internal/config"
+	"gos3/internal/metrics"
+
+	"github.com/spf13/cobra"
+)
+
+// withMetricsTextfile wraps the RunE of a one-shot command so the metrics it
+// set are written to metrics.textfileFolder, also when the command failed.
+func withMetricsTextfile(run func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		runErr := run(cmd, args)
+
+		cfg, err := config.LoadConfiguration("")
+		if err != nil || cfg.Metrics.TextfileFolder == "" {
+			return runErr
+		}
+		err = metrics.WriteTextfile(cfg.Metrics.TextfileFolder, cmd.Name())
+		if err != nil {
+			slog.Warn("Failed to write metrics", "error", err)
+		}
+		return runErr
+	}
+}
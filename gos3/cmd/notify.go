@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gos3/internal/config"
+	"gos3/internal/notify"
+
+	"github.com/spf13/cobra"
+)
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Work with the configured notification channels",
+}
+
+var notifyTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Send a sample event to the notification channels",
+	Long: `Render a sample event with each channel's template and send it, regardless
+of the channel's events and minSeverity filters. Use it to check URLs,
+credentials and templates, e.g. against a local stub server.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		name, _ := cmd.Flags().GetString("channel")
+		eventType, _ := cmd.Flags().GetString("event")
+		if !slices.Contains(notify.EventTypes, eventType) {
+			return fmt.Errorf("unknown event %q: use one of %v", eventType, notify.EventTypes)
+		}
+
+		var channels []config.NotificationChannel
+		for _, channel := range cfg.Notifications.Channels {
+			if name == "" || channel.Name == name {
+				channels = append(channels, channel)
+			}
+		}
+		if len(channels) == 0 {
+			return errors.New("no matching notification channel configured")
+		}
+
+		event := sampleEvent(eventType, cfg)
+		failed := 0
+		for _, channel := range channels {
+			err := notify.Deliver(channel, event)
+			if err != nil {
+				failed++
+				fmt.Printf("FAIL  %s (%s): %v\n", channel.Name, channel.Type, err)
+				continue
+			}
+			fmt.Printf("OK    %s (%s)\n", channel.Name, channel.Type)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d channels failed", failed, len(channels))
+		}
+		return nil
+	},
+}
+
+func sampleEvent(eventType string, cfg config.Config) notify.Event {
+	event := notify.Event{
+		Type:       eventType,
+		Definition: "example",
+		Target:     config.DefaultTargetName,
+		Targets:    cfg.AllTargetNames(),
+		Date:       time.Now().Format("2006-01-02"),
+		Duration:   95 * time.Second,
+		Bytes:      52428800,
+	}
+	if len(cfg.BackupDefinitions) > 0 {
+		event.Definition = cfg.BackupDefinitions[0].Name
+	}
+
+	switch eventType {
+	case notify.EventRunPartial:
+		event.FailedTargets = event.Targets[len(event.Targets)-1:]
+		event.Targets = event.Targets[:len(event.Targets)-1]
+		event.Error = "sample upload error"
+	case notify.EventRunFailed:
+		event.Error = "sample backup error"
+	case notify.EventVerificationFailed:
+		event.Details = []string{event.Date + "/example.tar.gz.enc: size differs from manifest"}
+	case notify.EventRetentionPruned:
+		event.Details = []string{"2024-01-01", "2024-01-02"}
+	}
+	return event
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"gos3/internal/config"
+
+	"golang.org/x/term"
+)
+
+func privateKeyPassword(cfg config.Config) (string, error) {
+	if cfg.App.PrivateKeyPassword.IsSet() {
+		password, err := cfg.App.PrivateKeyPassword.Resolve()
+		if err != nil {
+			return "", fmt.Errorf("failed to read private key password: %w", err)
+		}
+		return password, nil
+	}
+	return readPrivateKeyPassword()
+}
+
+// Without a terminal a single line is read from stdin, so scripts can pipe
+// the password in.
+func readPrivateKeyPassword() (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Print("Enter private key decryption password: ")
+	passwordBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return string(passwordBytes), nil
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+
+	"gos3/internal/backupops"
+	"gos3/internal/config"
+	"gos3/internal/s3"
+
+	"github.com/spf13/cobra"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove backups outside the retention policy",
+	Long: `Remove the backup date folders that fall outside app.retention from the
+configured targets. Folders needed by a kept incremental backup are kept.
+Dates and items come from the local catalog when it covers the target.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if noCatalog, _ := cmd.Flags().GetBool("no-catalog"); noCatalog {
+			cfg.App.DisableCatalog = true
+		}
+		if cfg.App.Retention.KeepLast <= 0 && cfg.App.Retention.MaxAge == "" {
+			fmt.Println("No retention policy configured, nothing to prune")
+			return nil
+		}
+
+		targets := cfg.AllTargetNames()
+		if target, _ := cmd.Flags().GetString("target"); target != "" {
+			targets = []string{target}
+		}
+
+		for _, target := range targets {
+			targetCfg, err := cfg.ForTarget(target)
+			if err != nil {
+				return err
+			}
+
+			expired, err := s3.PruneBackups(targetCfg, dryRun)
+			if err != nil {
+				return fmt.Errorf("failed to prune target %s: %w", target, err)
+			}
+			if cfg.Metrics.Enabled() && !dryRun {
+				backupops.RecordBucketSizes(targetCfg)
+			}
+
+			if len(expired) == 0 {
+				fmt.Printf("No backups to prune on %s\n", target)
+				continue
+			}
+			for _, date := range expired {
+				if dryRun {
+					fmt.Printf("Would remove from %s: %s\n", target, date.FolderName)
+				} else {
+					fmt.Printf("Removed from %s: %s\n", target, date.FolderName)
+				}
+			}
+		}
+		return nil
+	},
+}
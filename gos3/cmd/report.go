@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gos3/internal/compliance"
+	"gos3/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generate a compliance report of backups, verifications and restore drills",
+	Long: `Generate a report of a period for auditors: per backup definition the
+success rate, the RPO achieved (the longest time without a successful
+backup), the bytes uploaded and the unsuccessful runs, the storage every
+target holds, the verification and restore drill results and the public
+key fingerprints backups were encrypted for. The data comes from the run,
+verification and restore test history of this host.
+
+The period is --from to --to (both dates inclusive, --to defaults to today)
+or, without --from, the --period before today: "month" (the previous
+calendar month), "week" (the previous Monday to Sunday) or a duration such
+as 30d. The report is written to --out, or to stdout unless it is mailed
+with --email or uploaded with --upload.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		fromFlag, _ := cmd.Flags().GetString("from")
+		toFlag, _ := cmd.Flags().GetString("to")
+		period, _ := cmd.Flags().GetString("period")
+		format, _ := cmd.Flags().GetString("format")
+		out, _ := cmd.Flags().GetString("out")
+		email, _ := cmd.Flags().GetString("email")
+		upload, _ := cmd.Flags().GetBool("upload")
+		offline, _ := cmd.Flags().GetBool("offline")
+
+		from, to, err := reportPeriod(fromFlag, toFlag, period)
+		if err != nil {
+			return err
+		}
+
+		report, err := compliance.Build(from, to, !offline, cfg)
+		if err != nil {
+			return err
+		}
+		content, err := compliance.Render(report, format)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case out != "":
+			err = os.WriteFile(out, content, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+			cfg.Logger().Info("Wrote compliance report", "file", out)
+		case email == "" && !upload:
+			_, err = os.Stdout.Write(content)
+			if err != nil {
+				return err
+			}
+		}
+
+		if email != "" {
+			err = compliance.Email(report, content, format, email, cfg)
+			if err != nil {
+				return err
+			}
+			cfg.Logger().Info("Mailed compliance report", "channel", email)
+		}
+		if upload {
+			key, err := compliance.Upload(report, content, format, cfg.Compliance.Prefix, cfg)
+			if err != nil {
+				return err
+			}
+			cfg.Logger().Info("Uploaded compliance report", "target", cfg.CurrentTarget(), "key", key)
+		}
+		return nil
+	},
+}
+
+// --to is a whole day, so the period ends at the following midnight.
+func reportPeriod(fromFlag, toFlag, period string) (time.Time, time.Time, error) {
+	if fromFlag == "" {
+		if toFlag != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("--to needs --from")
+		}
+		return compliance.Period(period, time.Now())
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", fromFlag, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid --from date %q, expected YYYY-MM-DD", fromFlag)
+	}
+	to := time.Now()
+	if toFlag != "" {
+		to, err = time.ParseInLocation("2006-01-02", toFlag, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --to date %q, expected YYYY-MM-DD", toFlag)
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--from must be before --to")
+	}
+	return from, to, nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+
+	"gos3/internal/backupops"
+	"gos3/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore the volumes of a backup definition without prompts",
+	Long: `Download, join and decrypt the backup of a definition, stop its containers,
+restore its volumes and start the containers again. Incremental backups are
+restored together with the full backup and incrementals they build on.
+--target-volume restores a single volume under a new name and leaves the
+containers running. The private key password comes from
+app.privateKeyPassword, or is prompted for (read from stdin when it is not a
+terminal).`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		definition, _ := cmd.Flags().GetString("definition")
+		date, _ := cmd.Flags().GetString("date")
+		volumes, _ := cmd.Flags().GetStringSlice("volume")
+		targetVolume, _ := cmd.Flags().GetString("target-volume")
+		target, _ := cmd.Flags().GetString("target")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		def, err := cfg.BackupDefinitionByName(definition)
+		if err != nil {
+			return err
+		}
+
+		plan, err := backupops.PlanRestore(def, volumes, targetVolume, date, target, cfg)
+		if err != nil {
+			return err
+		}
+		backupops.PrintRestorePlan(plan)
+		if dryRun {
+			return nil
+		}
+
+		password, err := privateKeyPassword(cfg)
+		if err != nil {
+			return err
+		}
+
+		err = backupops.RestoreBackup(plan, password, cfg)
+		if err != nil {
+			return fmt.Errorf("restore failed: %w", err)
+		}
+
+		fmt.Printf("Restore of %s from %s completed\n", def.Name, plan.Date)
+		return nil
+	},
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+
+	"gos3/internal/backupops"
+	"gos3/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var restoreTestCmd = &cobra.Command{
+	Use:   "restore-test",
+	Short: "Restore a backup into throwaway volumes and check it",
+	Long: `Run restore drills: a backup is downloaded, restored into temporary Docker
+volumes, checked with the drill's check container and removed again. Without
+flags every drill in restoreTests runs; --name selects one and --definition
+runs an ad-hoc drill without check container. Results are appended to
+restore-tests.jsonl in the state folder. Exits non-zero when a drill fails.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		name, _ := cmd.Flags().GetString("name")
+		definition, _ := cmd.Flags().GetString("definition")
+
+		var tests []config.RestoreTestConfig
+		switch {
+		case definition != "":
+			test := config.RestoreTestConfig{Name: definition, Definition: definition}
+			test.Volumes, _ = cmd.Flags().GetStringSlice("volume")
+			test.Pick, _ = cmd.Flags().GetString("pick")
+			test.Target, _ = cmd.Flags().GetString("target")
+			tests = append(tests, test)
+		case name != "":
+			for _, test := range cfg.RestoreTests {
+				if test.Name == name {
+					tests = append(tests, test)
+				}
+			}
+			if len(tests) == 0 {
+				return fmt.Errorf("unknown restore test: %s", name)
+			}
+		default:
+			tests = cfg.RestoreTests
+		}
+		if len(tests) == 0 {
+			return fmt.Errorf("no restore tests configured")
+		}
+
+		password, err := privateKeyPassword(cfg)
+		if err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, test := range tests {
+			result := backupops.RunRestoreTest(test, password, cfg)
+			if result.Passed {
+				fmt.Printf("PASS  %s  %s  %s  %.0fs\n", result.Name, result.Definition, result.Date, result.Seconds)
+			} else {
+				fmt.Printf("FAIL  %s  %s  %s  %s\n", result.Name, result.Definition, result.Date, result.Error)
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d restore tests failed", failed, len(tests))
+		}
+		return nil
+	},
+}
@@ -1,10 +1,39 @@
 package cmd
 
 import (
+	"gos3/internal/config"
+	"gos3/internal/logging"
+	"io"
+
 	"github.com/spf13/cobra"
 )
 
+var logFile io.Closer
+
 var rootCmd = &cobra.Command{
-	Use:   "gos3",
-	Short: "gos3",
+	Use:     "gos3",
+	Short:   "gos3",
+	Version: config.Version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("log-format")
+		level, _ := cmd.Flags().GetString("log-level")
+		file, _ := cmd.Flags().GetString("log-file")
+		maxSize, _ := cmd.Flags().GetString("log-max-size")
+		maxFiles, _ := cmd.Flags().GetInt("log-max-files")
+
+		var err error
+		logFile, err = logging.Setup(logging.Options{
+			Format:   format,
+			Level:    level,
+			File:     file,
+			MaxSize:  config.ParseSize(maxSize),
+			MaxFiles: maxFiles,
+		})
+		return err
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if logFile != nil {
+			logFile.Close()
+		}
+	},
 }
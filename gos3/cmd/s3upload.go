@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"log/slog"
 
 	"gos3/internal/config"
 	"gos3/internal/s3"
@@ -31,7 +32,7 @@ var s3UploadCmd = &cobra.Command{
 			s3Folder = s3FolderOverride
 		}
 
-		fmt.Printf("Uploading from %s to S3 folder %s\n", localFolder, s3Folder)
+		slog.Info("Uploading folder", "folder", localFolder, "key", s3Folder)
 
 		err = s3.UploadFolderToS3(localFolder, s3Folder, configuration)
 		if err != nil {
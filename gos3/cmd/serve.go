@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"fmt"
+
+	"gos3/internal/config"
+	"gos3/internal/serve"
+
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run scheduled backups and restore tests",
+	Long: `Run in the foreground and start backups on serve.backupSchedule, every
+restore test on its own schedule and the compliance report on
+compliance.schedule until interrupted.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		return serve.Run(cfg)
+	},
+}
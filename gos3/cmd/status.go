@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gos3/internal/backupops"
+	"gos3/internal/config"
+	"gos3/internal/history"
+
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show when each backup definition last succeeded",
+	Long: `Show per backup definition the last success and how long it took, the last
+failure, the next run on serve.backupSchedule and the newest backup date in
+each target's bucket. A definition whose last success is older than
+--stale-after (default twice the schedule interval, or 26h) is flagged as
+stale.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		offline, _ := cmd.Flags().GetBool("offline")
+		var staleAfter time.Duration
+		if value, _ := cmd.Flags().GetString("stale-after"); value != "" {
+			staleAfter, err = config.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+		}
+
+		statuses, err := backupops.DefinitionStatuses(staleAfter, !offline, cfg)
+		if err != nil {
+			return err
+		}
+
+		switch format {
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(statuses)
+		case "table":
+			printStatuses(statuses, !offline)
+			return nil
+		default:
+			return fmt.Errorf("unknown format %q, expected table or json", format)
+		}
+	},
+}
+
+func printStatuses(statuses []backupops.DefinitionStatus, checkBucket bool) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "DEFINITION\tLAST SUCCESS\tDURATION\tLAST FAILURE\tNEXT RUN"
+	if checkBucket {
+		header += "\tLATEST IN BUCKET"
+	}
+	fmt.Fprintln(writer, header)
+
+	for _, status := range statuses {
+		lastSuccess, duration, lastFailure, nextRun := "never", "-", "-", "-"
+		if status.LastSuccess != nil {
+			lastSuccess = formatRunTime(status.LastSuccess)
+			duration = status.LastSuccess.Duration().Round(time.Second).String()
+		}
+		if status.LastFailure != nil {
+			lastFailure = formatRunTime(status.LastFailure) + " " + status.LastFailure.Status
+		}
+		if status.NextRun != nil {
+			nextRun = status.NextRun.Local().Format("2006-01-02 15:04")
+		}
+
+		line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s", status.Definition, lastSuccess, duration, lastFailure, nextRun)
+		if checkBucket {
+			line += "\t" + formatLatestDates(status)
+		}
+		fmt.Fprintln(writer, line)
+	}
+	writer.Flush()
+
+	for _, status := range statuses {
+		switch {
+		case status.LastSuccess == nil:
+			fmt.Printf("\nWARNING: %s has never succeeded on this host\n", status.Definition)
+		case status.Stale:
+			fmt.Printf("\nWARNING: %s last succeeded %s ago, more than %s\n", status.Definition,
+				time.Since(status.LastSuccess.FinishedAt).Round(time.Second), status.StaleAfter)
+		}
+		for target, message := range status.LatestErrors {
+			fmt.Printf("\nWARNING: failed to read target %s: %s\n", target, message)
+		}
+		if status.LastFailure != nil && status.LastFailure.Error != "" &&
+			(status.LastSuccess == nil || status.LastFailure.FinishedAt.After(status.LastSuccess.FinishedAt)) {
+			firstLine, _, _ := strings.Cut(status.LastFailure.Error, "\n")
+			fmt.Printf("%s failed last: %s\n", status.Definition, firstLine)
+		}
+	}
+}
+
+func formatRunTime(record *history.Record) string {
+	return record.FinishedAt.Local().Format("2006-01-02 15:04")
+}
+
+func formatLatestDates(status backupops.DefinitionStatus) string {
+	targets := make([]string, 0, len(status.LatestDates))
+	for target := range status.LatestDates {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	parts := make([]string, 0, len(targets))
+	for _, target := range targets {
+		date := status.LatestDates[target]
+		switch {
+		case status.LatestErrors[target] != "":
+			date = "error"
+		case date == "":
+			date = "none"
+		}
+		if len(targets) == 1 && target == config.DefaultTargetName {
+			parts = append(parts, date)
+		} else {
+			parts = append(parts, target+": "+date)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
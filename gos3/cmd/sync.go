@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+
+	"gos3/internal/config"
+	"gos3/internal/s3"
+
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Copy missing backup dates from one target to another",
+	Long: `Copy every backup date folder that exists on the --from target but not on
+the --to target. Targets are the names from the targets list; "default" is the
+top-level storage.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if from == to {
+			return fmt.Errorf("source and destination targets must differ")
+		}
+
+		source, err := cfg.ForTarget(from)
+		if err != nil {
+			return err
+		}
+		destination, err := cfg.ForTarget(to)
+		if err != nil {
+			return err
+		}
+
+		dates, err := s3.SyncBackups(source, destination, dryRun)
+		for _, date := range dates {
+			if dryRun {
+				fmt.Printf("Would copy: %s\n", date.FolderName)
+			} else {
+				fmt.Printf("Copied: %s\n", date.FolderName)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(dates) == 0 {
+			fmt.Printf("Target %s already has every backup of %s\n", to, from)
+		}
+		return nil
+	},
+}
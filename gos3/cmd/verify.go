@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"gos3/internal/config"
+	"gos3/internal/s3"
+
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the integrity of stored backups",
+	Long: `Check stored backups against their run manifest and print a pass/fail line
+per item. --quick (the default) compares object sizes and ETags; --full also
+streams every item through decryption and decompression and checks the tar
+structure and checksums without writing plaintext to disk. The private key
+password for --full comes from app.privateKeyPassword, or is prompted for (read
+from stdin when it is not a terminal). Exits non-zero when any item fails.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfiguration("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		full, _ := cmd.Flags().GetBool("full")
+		date, _ := cmd.Flags().GetString("date")
+		target, _ := cmd.Flags().GetString("target")
+		definition, _ := cmd.Flags().GetString("definition")
+
+		targetCfg, err := cfg.ForTarget(target)
+		if err != nil {
+			return err
+		}
+
+		dates, err := s3.SelectBackupDates(targetCfg, date)
+		if err != nil {
+			return err
+		}
+
+		options := s3.VerifyOptions{Full: full, Target: target, Definition: definition}
+		if full {
+			options.PrivateKeyPassword, err = privateKeyPassword(cfg)
+			if err != nil {
+				return err
+			}
+		}
+
+		results, err := s3.VerifyBackups(targetCfg, dates, options)
+		if err != nil {
+			return err
+		}
+		s3.PrintVerifyResults(results)
+
+		for _, result := range results {
+			if !result.Passed() {
+				return fmt.Errorf("verification failed")
+			}
+		}
+		return nil
+	},
+}
@@ -22,9 +22,15 @@ var volumebackupCmd = &cobra.Command{
 		}
 
 		noCompression, _ := cmd.Flags().GetBool("no-compression")
-		compress := !noCompression
+		compression := config.CompressionConfig{}
+		compression.Codec, _ = cmd.Flags().GetString("codec")
+		compression.Level, _ = cmd.Flags().GetInt("level")
+		compression.Threads, _ = cmd.Flags().GetInt("threads")
+		if noCompression {
+			compression.Codec = "none"
+		}
 
-		result, err := script.VolumeBackup(volumeName, backupFileName, compress, configuration)
+		result, err := script.VolumeBackup(volumeName, backupFileName, compression, configuration)
 		if err != nil {
 			return fmt.Errorf("volume backup failed: %w", err)
 		}
@@ -2,8 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"gos3/internal/backupops"
 	"gos3/internal/config"
-	"gos3/internal/script"
 
 	"github.com/spf13/cobra"
 )
@@ -11,7 +11,11 @@ import (
 var volumerestoreCmd = &cobra.Command{
 	Use:   "volumerestore <volume_name> <backup_file_name>",
 	Short: "Restore a Docker volume from a backup",
-	Args:  cobra.ExactArgs(2),
+	Long: `Restore a Docker volume from a backup.
+When the backup file is an incremental archive (*.incr.tar.gz) downloaded with
+the download command, the full backup and all incrementals up to it are
+applied in order.`,
+	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		volumeName := args[0]
 		backupFileName := args[1]
@@ -21,7 +25,7 @@ var volumerestoreCmd = &cobra.Command{
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
 
-		err = script.VolumeRestore(volumeName, backupFileName, configuration)
+		err = backupops.RestoreVolume(volumeName, backupFileName, configuration)
 		if err != nil {
 			return fmt.Errorf("volume restore failed: %w", err)
 		}
@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"gos3/internal/script"
+)
+
+// Decompress starts the decompressor of codec reading input. Closing the
+// returned reader waits for it and reports its failure.
+func Decompress(codec script.Codec, input io.Reader) (io.ReadCloser, error) {
+	if len(codec.Decompressor) == 0 {
+		return io.NopCloser(input), nil
+	}
+
+	cmd := exec.Command(codec.Decompressor[0], codec.Decompressor[1:]...)
+	cmd.Stdin = input
+	reader := &decompressReader{cmd: cmd, codec: codec.Name}
+	cmd.Stderr = &reader.stderr
+	output, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", codec.Decompressor[0], err)
+	}
+	reader.output = output
+	return reader, nil
+}
+
+type decompressReader struct {
+	cmd    *exec.Cmd
+	codec  string
+	output io.Reader
+	stderr bytes.Buffer
+}
+
+func (d *decompressReader) Read(p []byte) (int, error) {
+	return d.output.Read(p)
+}
+
+func (d *decompressReader) Close() error {
+	_, _ = io.Copy(io.Discard, d.output)
+	err := d.cmd.Wait()
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(d.stderr.String()), "\n")
+		return fmt.Errorf("%s failed: %w: %s", d.codec, err, lines[len(lines)-1])
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExtractResult counts what Extract and Remove did across the archives of a
+// chain.
+type ExtractResult struct {
+	Files   int
+	Dirs    int
+	Links   int
+	Removed int
+	Skipped int
+	Bytes   int64
+}
+
+// Extract writes the entries of a tar stream that match any of patterns below
+// outFolder. Nothing is written through a symlink.
+func Extract(input io.Reader, patterns []string, outFolder string, result *ExtractResult) error {
+	reader := tar.NewReader(input)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar: %w", err)
+		}
+
+		entry, ok := headerEntry(header)
+		if !ok || !MatchAny(patterns, entry.Path) {
+			continue
+		}
+
+		err = extractEntry(reader, entry, outFolder, result)
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %w", entry.Path, err)
+		}
+	}
+}
+
+// Remove deletes the extracted paths an incremental archive removes.
+func Remove(entries []Entry, patterns []string, outFolder string, result *ExtractResult) error {
+	for _, entry := range entries {
+		if entry.Type != TypeDeleted || !MatchAny(patterns, entry.Path) {
+			continue
+		}
+		target, err := safeTarget(outFolder, entry.Path)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(target); err != nil {
+			continue
+		}
+		err = os.RemoveAll(target)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s: %w", entry.Path, err)
+		}
+		result.Removed++
+	}
+	return nil
+}
+
+func extractEntry(reader io.Reader, entry Entry, outFolder string, result *ExtractResult) error {
+	target, err := safeTarget(outFolder, entry.Path)
+	if err != nil {
+		return err
+	}
+
+	if entry.Type == TypeDir {
+		err = os.MkdirAll(target, os.FileMode(entry.Mode)|0700)
+		if err != nil {
+			return err
+		}
+		result.Dirs++
+		return nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+	// Replace whatever an earlier archive of the chain left, never writing
+	// through it.
+	if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+		os.Remove(target)
+	}
+
+	switch entry.Type {
+	case TypeFile:
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(entry.Mode)&os.ModePerm)
+		if err != nil {
+			return err
+		}
+		written, err := io.Copy(file, reader)
+		closeErr := file.Close()
+		if err != nil {
+			return err
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+		modTime := time.Unix(entry.ModTime, 0)
+		_ = os.Chtimes(target, modTime, modTime)
+		result.Files++
+		result.Bytes += written
+	case TypeSymlink:
+		err = os.Symlink(entry.Link, target)
+		if err != nil {
+			return err
+		}
+		result.Links++
+	case TypeHardlink:
+		source, err := safeTarget(outFolder, entry.Link)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(source); err != nil {
+			slog.Info("Skipping hard link to a path that was not extracted", "path", entry.Path, "link", entry.Link)
+			result.Skipped++
+			return nil
+		}
+		err = os.Link(source, target)
+		if err != nil {
+			return err
+		}
+		result.Links++
+	default:
+		slog.Info("Skipping special file", "path", entry.Path)
+		result.Skipped++
+	}
+	return nil
+}
+
+// safeTarget refuses a path whose existing parent is a symlink.
+func safeTarget(outFolder, path string) (string, error) {
+	parts := strings.Split(CleanPath(path), "/")
+	current := outFolder
+	for _, part := range parts[:len(parts)-1] {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("%s is below the symlink %s", path, part)
+		}
+	}
+	return filepath.Join(outFolder, filepath.FromSlash(CleanPath(path))), nil
+}
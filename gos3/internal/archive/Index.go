@@ -0,0 +1,154 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// IndexSuffix names the gzip compressed JSON lines index written next to every
+// archive, one Entry per tar entry plus one per deleted path.
+const IndexSuffix = ".index"
+
+const (
+	TypeFile     = "file"
+	TypeDir      = "dir"
+	TypeSymlink  = "symlink"
+	TypeHardlink = "hardlink"
+	TypeOther    = "other"
+	TypeDeleted  = "deleted"
+)
+
+// Entry describes one path of an archive. Paths are relative to the volume
+// root without the "./" prefix used by tar.
+type Entry struct {
+	Path    string `json:"path"`
+	Type    string `json:"type"`
+	Size    int64  `json:"size,omitempty"`
+	Mode    int64  `json:"mode,omitempty"`
+	ModTime int64  `json:"mtime,omitempty"`
+	Link    string `json:"link,omitempty"`
+}
+
+// CachedIndexPath is where the backup keeps a plaintext copy of an archive
+// index below the state folder, so listing needs no download.
+func CachedIndexPath(stateFolder, date, archiveName string) string {
+	return filepath.Join(CachedIndexFolder(stateFolder, date), archiveName+IndexSuffix)
+}
+
+func CachedIndexFolder(stateFolder, date string) string {
+	return filepath.Join(stateFolder, "archive-index", date)
+}
+
+func WriteIndex(path string, entries []Entry) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create archive index: %w", err)
+	}
+	defer file.Close()
+
+	compressed := gzip.NewWriter(file)
+	encoder := json.NewEncoder(compressed)
+	for _, entry := range entries {
+		err = encoder.Encode(entry)
+		if err != nil {
+			return fmt.Errorf("failed to write archive index: %w", err)
+		}
+	}
+	err = compressed.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write archive index: %w", err)
+	}
+	return file.Close()
+}
+
+func ReadIndex(input io.Reader) ([]Entry, error) {
+	compressed, err := gzip.NewReader(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive index: %w", err)
+	}
+	defer compressed.Close()
+
+	var entries []Entry
+	decoder := json.NewDecoder(compressed)
+	for {
+		var entry Entry
+		err = decoder.Decode(&entry)
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse archive index: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func ReadIndexFile(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadIndex(file)
+}
+
+// ReadDeletedList turns a deleted list as written by incremental backups
+// into index entries.
+func ReadDeletedList(input io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if path := CleanPath(scanner.Text()); path != "" {
+			entries = append(entries, Entry{Path: path, Type: TypeDeleted})
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// Apply lays the entries of the next archive of an incremental chain over
+// tree. Deleted paths are removed with everything below them.
+func Apply(tree map[string]Entry, entries []Entry) {
+	for _, entry := range entries {
+		if entry.Type != TypeDeleted {
+			tree[entry.Path] = entry
+			continue
+		}
+		previous, found := tree[entry.Path]
+		delete(tree, entry.Path)
+		if !found || previous.Type != TypeDir {
+			continue
+		}
+		for path := range tree {
+			if strings.HasPrefix(path, entry.Path+"/") {
+				delete(tree, path)
+			}
+		}
+	}
+}
+
+func Sorted(tree map[string]Entry) []Entry {
+	entries := make([]Entry, 0, len(tree))
+	for _, entry := range tree {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+// CleanPath normalizes a tar entry name to a path relative to the volume
+// root. Leading "../" elements are dropped so no name escapes the root; the
+// root itself yields "".
+func CleanPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+}
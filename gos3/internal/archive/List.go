@@ -0,0 +1,110 @@
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gos3/internal/script"
+)
+
+// List reads the entries of an uncompressed tar stream.
+func List(input io.Reader) ([]Entry, error) {
+	reader := tar.NewReader(input)
+	var entries []Entry
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, fmt.Errorf("invalid tar after %d entries: %w", len(entries), err)
+		}
+		if entry, ok := headerEntry(header); ok {
+			entries = append(entries, entry)
+		}
+	}
+}
+
+// BuildIndex lists a local archive and writes its index next to it.
+// deletedListPath may be empty.
+func BuildIndex(archivePath, deletedListPath string) ([]Entry, error) {
+	_, _, codec, ok := script.ParseArchiveName(filepath.Base(archivePath))
+	if !ok {
+		return nil, fmt.Errorf("cannot determine compression codec of %s", archivePath)
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	plain, err := Decompress(codec, file)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := List(plain)
+	closeErr := plain.Close()
+	if err != nil {
+		return nil, err
+	}
+	if closeErr != nil {
+		return nil, closeErr
+	}
+
+	if deletedListPath != "" {
+		deleted, err := readDeletedListFile(deletedListPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read deleted list: %w", err)
+		}
+		entries = append(entries, deleted...)
+	}
+
+	return entries, WriteIndex(archivePath+IndexSuffix, entries)
+}
+
+func headerEntry(header *tar.Header) (Entry, bool) {
+	path := CleanPath(header.Name)
+	if path == "" {
+		return Entry{}, false
+	}
+
+	entry := Entry{
+		Path:    path,
+		Mode:    header.Mode & 07777,
+		ModTime: header.ModTime.Unix(),
+	}
+	switch header.Typeflag {
+	case tar.TypeReg:
+		entry.Type = TypeFile
+		entry.Size = header.Size
+	case tar.TypeDir:
+		entry.Type = TypeDir
+	case tar.TypeSymlink:
+		entry.Type = TypeSymlink
+		entry.Link = header.Linkname
+	case tar.TypeLink:
+		entry.Type = TypeHardlink
+		entry.Link = CleanPath(header.Linkname)
+	default:
+		entry.Type = TypeOther
+	}
+	return entry, true
+}
+
+// readDeletedListFile yields no entries when the list does not exist.
+func readDeletedListFile(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadDeletedList(file)
+}
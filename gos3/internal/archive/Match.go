@@ -0,0 +1,56 @@
+package archive
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether the archive path, or one of its parent directories,
+// matches pattern. Patterns use path.Match syntax per element and "**"
+// matches any number of elements, so "srv/www" and "srv/www/**" both select
+// the whole tree and "**/*.conf" selects every .conf file.
+func Match(pattern, name string) bool {
+	patternParts := strings.Split(CleanPath(pattern), "/")
+	nameParts := strings.Split(name, "/")
+	for end := 1; end <= len(nameParts); end++ {
+		if matchParts(patternParts, nameParts[:end]) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAny is true for every path when there are no patterns.
+func MatchAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if Match(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchParts(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for skip := 0; skip <= len(name); skip++ {
+				if matchParts(pattern[1:], name[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		matched, err := path.Match(pattern[0], name[0])
+		if err != nil || !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,68 @@
+package backupops
+
+import (
+	"errors"
+	"gos3/internal/manifest"
+	"time"
+)
+
+const (
+	StatusSuccess  = "success"
+	StatusDegraded = "degraded"
+	StatusFailed   = "failed"
+)
+
+// ErrDegraded is returned by PerformBackups when some backups reached only
+// part of their targets.
+var ErrDegraded = errors.New("backup degraded")
+
+type TargetResult struct {
+	Target   string
+	Err      error
+	Duration time.Duration
+}
+
+type BackupResult struct {
+	Definition string
+	DateFolder string
+	Bytes      int64
+	Targets    []TargetResult
+	// Manifest is the run manifest entry before it was published to each
+	// target, nil when the run failed before it was built.
+	Manifest *manifest.Definition
+	// Timings holds the durations of the steps that completed, also when a
+	// later step failed.
+	Timings  *manifest.Timings
+	Downtime time.Duration
+}
+
+// Status is success when every target received the backup, degraded when
+// only some did and failed when none did.
+func (r BackupResult) Status() string {
+	succeeded := 0
+	for _, target := range r.Targets {
+		if target.Err == nil {
+			succeeded++
+		}
+	}
+
+	switch {
+	case len(r.Targets) > 0 && succeeded == len(r.Targets):
+		return StatusSuccess
+	case succeeded > 0:
+		return StatusDegraded
+	default:
+		return StatusFailed
+	}
+}
+
+// FailedTargets returns the names of the targets the upload failed for.
+func (r BackupResult) FailedTargets() []string {
+	var failed []string
+	for _, target := range r.Targets {
+		if target.Err != nil {
+			failed = append(failed, target.Target)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,373 @@
+package backupops
+
+import (
+	"fmt"
+	"gos3/internal/archive"
+	"gos3/internal/config"
+	"gos3/internal/s3"
+	"gos3/internal/script"
+	"gos3/internal/storage"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveChain is the archive of one volume in a date folder together with
+// the older archives its incremental chain needs, oldest first. A full
+// backup is a chain of one.
+type ArchiveChain struct {
+	Definition string
+	Volume     string
+	Target     string
+	Date       string
+	Links      []ChainLink
+}
+
+type ChainLink struct {
+	Date        string
+	Archive     s3.BackupItem
+	Index       *s3.BackupItem
+	DeletedList *s3.BackupItem
+}
+
+// PlanBrowse resolves the archive chain of volume in date ("latest" when
+// empty). definition may be empty when only one definition backs up the
+// volume.
+func PlanBrowse(definition, volume, date, target string, cfg config.Config) (ArchiveChain, error) {
+	def, err := volumeDefinition(definition, volume, cfg)
+	if err != nil {
+		return ArchiveChain{}, err
+	}
+
+	targetCfg, err := cfg.ForTarget(target)
+	if err != nil {
+		return ArchiveChain{}, err
+	}
+
+	bases, err := volumeArchiveBases(def, []string{volume})
+	if err != nil {
+		return ArchiveChain{}, err
+	}
+
+	dates, err := s3.GetBackupDates(targetCfg)
+	if err != nil {
+		return ArchiveChain{}, fmt.Errorf("failed to get backup dates: %w", err)
+	}
+
+	selected, err := selectBackupDate(date, dates, bases, targetCfg)
+	if err != nil {
+		return ArchiveChain{}, err
+	}
+
+	sets, err := planChains(def, []string{volume}, selected, dates, targetCfg)
+	if err != nil {
+		return ArchiveChain{}, err
+	}
+
+	chain := ArchiveChain{Definition: def.Name, Volume: volume, Target: target, Date: selected.FolderName}
+	for i := len(sets) - 1; i >= 0; i-- {
+		link := ChainLink{Date: sets[i].Date.FolderName}
+		found := false
+		for _, item := range sets[i].Items {
+			switch {
+			case strings.HasSuffix(item.Name, archive.IndexSuffix):
+				link.Index = &item
+			case strings.HasSuffix(item.Name, script.IncrementalDeletedSuffix):
+				link.DeletedList = &item
+			default:
+				if _, _, _, ok := script.ParseArchiveName(item.Name); ok {
+					link.Archive = item
+					found = true
+				}
+			}
+		}
+		if !found {
+			return ArchiveChain{}, fmt.Errorf("no archive of volume %s found in %s", volume, link.Date)
+		}
+		chain.Links = append(chain.Links, link)
+	}
+	return chain, nil
+}
+
+// ListBackup returns the content of the volume at the chain's date, read from
+// the index cache, the stored index or the archive itself.
+func ListBackup(chain ArchiveChain, password func() (string, error), cfg config.Config) ([]archive.Entry, error) {
+	browser, err := openBrowser(chain, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer browser.close()
+
+	tree := make(map[string]archive.Entry)
+	for _, link := range chain.Links {
+		entries, indexed, err := browser.index(link, password)
+		if err != nil {
+			return nil, err
+		}
+		if !indexed {
+			entries, err = browser.listArchive(link, password)
+			if err != nil {
+				return nil, err
+			}
+		}
+		archive.Apply(tree, entries)
+	}
+	return archive.Sorted(tree), nil
+}
+
+// ExtractBackup writes the paths of the volume matching patterns, as they were
+// at the chain's date, below outFolder. Archives whose index shows no match are
+// not downloaded.
+func ExtractBackup(chain ArchiveChain, patterns []string, outFolder, privateKeyPassword string, cfg config.Config) (archive.ExtractResult, error) {
+	var result archive.ExtractResult
+	password := func() (string, error) { return privateKeyPassword, nil }
+
+	err := os.MkdirAll(outFolder, 0755)
+	if err != nil {
+		return result, fmt.Errorf("failed to create output folder: %w", err)
+	}
+
+	browser, err := openBrowser(chain, cfg)
+	if err != nil {
+		return result, err
+	}
+	defer browser.close()
+
+	for _, link := range chain.Links {
+		entries, indexed, err := browser.index(link, password)
+		if err != nil {
+			return result, err
+		}
+
+		if indexed && !anyMatch(entries, patterns) {
+			cfg.Logger().Info("Skipping archive without matching entries", "date", link.Date, "file", link.Archive.Name)
+		} else {
+			cfg.Logger().Info("Extracting from archive", "step", "extract", "date", link.Date, "file", link.Archive.Name)
+			err = browser.streamArchive(link, privateKeyPassword, func(tarStream io.Reader) error {
+				return archive.Extract(tarStream, patterns, outFolder, &result)
+			})
+			if err != nil {
+				return result, err
+			}
+		}
+
+		if !indexed {
+			entries, err = browser.deletedList(link, privateKeyPassword)
+			if err != nil {
+				return result, err
+			}
+		}
+		err = archive.Remove(entries, patterns, outFolder, &result)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func PrintArchiveEntries(entries []archive.Entry) {
+	for _, entry := range entries {
+		name := entry.Path
+		if entry.Type == archive.TypeSymlink || entry.Type == archive.TypeHardlink {
+			name += " -> " + entry.Link
+		}
+		modTime := time.Unix(entry.ModTime, 0).Format("2006-01-02 15:04")
+		fmt.Printf("%s %12d %s %s\n", entryMode(entry), entry.Size, modTime, name)
+	}
+}
+
+func entryMode(entry archive.Entry) string {
+	mode := os.FileMode(entry.Mode) & os.ModePerm
+	switch entry.Type {
+	case archive.TypeDir:
+		mode |= os.ModeDir
+	case archive.TypeSymlink:
+		mode |= os.ModeSymlink
+	case archive.TypeOther:
+		mode |= os.ModeIrregular
+	}
+	return mode.String()
+}
+
+func anyMatch(entries []archive.Entry, patterns []string) bool {
+	for _, entry := range entries {
+		if entry.Type != archive.TypeDeleted && archive.MatchAny(patterns, entry.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+func volumeDefinition(definition, volume string, cfg config.Config) (config.BackupDefinition, error) {
+	if definition != "" {
+		return cfg.BackupDefinitionByName(definition)
+	}
+
+	var matches []config.BackupDefinition
+	for _, def := range cfg.BackupDefinitions {
+		for _, defVolume := range def.Volumes {
+			if defVolume == volume {
+				matches = append(matches, def)
+				break
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return config.BackupDefinition{}, fmt.Errorf("no backup definition contains volume %s", volume)
+	case 1:
+		return matches[0], nil
+	default:
+		return config.BackupDefinition{}, fmt.Errorf("volume %s is part of several backup definitions, choose one with --definition", volume)
+	}
+}
+
+type backupBrowser struct {
+	st         storage.Storage
+	workFolder string
+	cfg        config.Config
+}
+
+func openBrowser(chain ArchiveChain, cfg config.Config) (*backupBrowser, error) {
+	targetCfg, err := cfg.ForTarget(chain.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := storage.Open(targetCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	workFolder, err := makeRestoreFolder("gos3-browse", cfg)
+	if err != nil {
+		st.Close()
+		return nil, err
+	}
+
+	return &backupBrowser{st: st, workFolder: workFolder, cfg: targetCfg}, nil
+}
+
+func (b *backupBrowser) close() {
+	b.st.Close()
+	os.RemoveAll(b.workFolder)
+}
+
+// indexed is false for archives stored without an index.
+func (b *backupBrowser) index(link ChainLink, password func() (string, error)) (entries []archive.Entry, indexed bool, err error) {
+	cached := archive.CachedIndexPath(b.cfg.App.StateFolder, link.Date, link.Archive.Name)
+	entries, err = archive.ReadIndexFile(cached)
+	if err == nil {
+		return entries, true, nil
+	}
+	if !os.IsNotExist(err) {
+		b.cfg.Logger().Warn("Ignoring cached archive index", "file", cached, "error", err)
+	}
+
+	if link.Index == nil {
+		return nil, false, nil
+	}
+
+	privateKeyPassword, err := password()
+	if err != nil {
+		return nil, false, err
+	}
+	err = b.stream(*link.Index, privateKeyPassword, func(input io.Reader) error {
+		entries, err = archive.ReadIndex(input)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	b.cache(cached, entries)
+	return entries, true, nil
+}
+
+// listArchive caches its result, so an archive is streamed only once.
+func (b *backupBrowser) listArchive(link ChainLink, password func() (string, error)) ([]archive.Entry, error) {
+	privateKeyPassword, err := password()
+	if err != nil {
+		return nil, err
+	}
+
+	b.cfg.Logger().Info("No archive index, streaming the archive", "date", link.Date, "file", link.Archive.Name)
+	var entries []archive.Entry
+	err = b.streamArchive(link, privateKeyPassword, func(tarStream io.Reader) error {
+		entries, err = archive.List(tarStream)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := b.deletedList(link, privateKeyPassword)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, deleted...)
+
+	b.cache(archive.CachedIndexPath(b.cfg.App.StateFolder, link.Date, link.Archive.Name), entries)
+	return entries, nil
+}
+
+func (b *backupBrowser) deletedList(link ChainLink, privateKeyPassword string) ([]archive.Entry, error) {
+	if link.DeletedList == nil {
+		return nil, nil
+	}
+	var entries []archive.Entry
+	err := b.stream(*link.DeletedList, privateKeyPassword, func(input io.Reader) error {
+		var err error
+		entries, err = archive.ReadDeletedList(input)
+		return err
+	})
+	return entries, err
+}
+
+func (b *backupBrowser) streamArchive(link ChainLink, privateKeyPassword string, consume func(io.Reader) error) error {
+	_, _, codec, ok := script.ParseArchiveName(link.Archive.Name)
+	if !ok {
+		return fmt.Errorf("cannot determine compression codec of %s", link.Archive.Name)
+	}
+
+	return b.stream(link.Archive, privateKeyPassword, func(input io.Reader) error {
+		tarStream, err := archive.Decompress(codec, input)
+		if err != nil {
+			return err
+		}
+		err = consume(tarStream)
+		closeErr := tarStream.Close()
+		if err != nil {
+			return err
+		}
+		return closeErr
+	})
+}
+
+// A decryption failure is reported instead of the errors it causes downstream.
+func (b *backupBrowser) stream(item s3.BackupItem, privateKeyPassword string, consume func(io.Reader) error) error {
+	reader, err := s3.OpenItem(b.st, item, b.workFolder, privateKeyPassword, b.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", item.Name, err)
+	}
+	consumeErr := consume(reader)
+	err = reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", item.Name, err)
+	}
+	if consumeErr != nil {
+		return fmt.Errorf("failed to read %s: %w", item.Name, consumeErr)
+	}
+	return nil
+}
+
+func (b *backupBrowser) cache(path string, entries []archive.Entry) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		err = archive.WriteIndex(path, entries)
+	}
+	if err != nil {
+		b.cfg.Logger().Warn("Failed to cache archive index", "file", path, "error", err)
+	}
+}
@@ -0,0 +1,114 @@
+package backupops
+
+import (
+	"gos3/internal/config"
+	"gos3/internal/history"
+	"gos3/internal/s3"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// defaultStaleAfter applies when serve.backupSchedule does not tell how
+// often a definition should succeed.
+const defaultStaleAfter = 26 * time.Hour
+
+// DefinitionStatus answers when a definition last succeeded and failed, when
+// it runs next and what the newest backup in each of its targets is.
+type DefinitionStatus struct {
+	Definition  string          `json:"definition"`
+	LastSuccess *history.Record `json:"lastSuccess,omitempty"`
+	LastFailure *history.Record `json:"lastFailure,omitempty"`
+	NextRun     *time.Time      `json:"nextRun,omitempty"`
+	// LatestDates maps each target to the newest date folder holding every
+	// volume of the definition; empty when there is none or, with
+	// LatestErrors set, when the target could not be read.
+	LatestDates  map[string]string `json:"latestDates,omitempty"`
+	LatestErrors map[string]string `json:"latestErrors,omitempty"`
+	// Stale is set when the last success is older than StaleAfter.
+	Stale      bool   `json:"stale"`
+	StaleAfter string `json:"staleAfter"`
+}
+
+// DefinitionStatuses reports every backup definition from the run history. A
+// zero staleAfter is twice the backup schedule interval, or 26h without one.
+func DefinitionStatuses(staleAfter time.Duration, checkBucket bool, cfg config.Config) ([]DefinitionStatus, error) {
+	records, err := history.Read(history.Filter{}, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var nextRun *time.Time
+	if cfg.Serve.BackupSchedule != "" {
+		schedule, err := cron.ParseStandard(cfg.Serve.BackupSchedule)
+		if err == nil {
+			next := schedule.Next(time.Now())
+			nextRun = &next
+			if staleAfter == 0 {
+				staleAfter = 2 * schedule.Next(next).Sub(next)
+			}
+		}
+	}
+	if staleAfter == 0 {
+		staleAfter = defaultStaleAfter
+	}
+
+	statuses := make([]DefinitionStatus, 0, len(cfg.BackupDefinitions))
+	for _, def := range cfg.BackupDefinitions {
+		status := DefinitionStatus{Definition: def.Name, NextRun: nextRun, StaleAfter: staleAfter.String()}
+		for i := range records {
+			record := &records[i]
+			if record.Definition != def.Name {
+				continue
+			}
+			if record.Status == StatusSuccess && status.LastSuccess == nil {
+				status.LastSuccess = record
+			}
+			if record.Status != StatusSuccess && status.LastFailure == nil {
+				status.LastFailure = record
+			}
+		}
+		status.Stale = status.LastSuccess == nil || time.Since(status.LastSuccess.FinishedAt) > staleAfter
+
+		if checkBucket {
+			status.LatestDates, status.LatestErrors = latestBackupDates(def, cfg)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func latestBackupDates(def config.BackupDefinition, cfg config.Config) (map[string]string, map[string]string) {
+	dates := make(map[string]string)
+	failures := make(map[string]string)
+	bases, err := volumeArchiveBases(def, nil)
+	if err != nil || len(bases) == 0 {
+		return nil, nil
+	}
+
+	for _, target := range cfg.TargetNames(def) {
+		dates[target] = ""
+		targetCfg, err := cfg.ForTarget(target)
+		if err != nil {
+			failures[target] = err.Error()
+			continue
+		}
+		folders, err := s3.CatalogBackupDates(targetCfg)
+		if err != nil {
+			failures[target] = err.Error()
+			continue
+		}
+		if len(folders) == 0 {
+			continue
+		}
+		date, err := pickBackupDate("latest", folders, bases, targetCfg)
+		if err == nil {
+			dates[target] = date.FolderName
+		}
+	}
+
+	if len(failures) == 0 {
+		failures = nil
+	}
+	return dates, failures
+}
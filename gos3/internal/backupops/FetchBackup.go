@@ -0,0 +1,164 @@
+package backupops
+
+import (
+	"fmt"
+	"gos3/internal/archive"
+	"gos3/internal/config"
+	"gos3/internal/s3"
+	"gos3/internal/script"
+	"math/rand/v2"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FetchedBackup is a backup of one definition decrypted into Folder/<date>.
+// Archives maps each volume to its archive of Date.
+type FetchedBackup struct {
+	Folder   string
+	Date     string
+	Archives map[string]string
+}
+
+// PlanFetch selects the items of the given volumes in date and resolves the
+// incremental chains they need. Content indexes are left out.
+func PlanFetch(def config.BackupDefinition, volumes []string, date s3.BackupDate, dates []s3.BackupDate, cfg config.Config) ([]s3.DownloadSet, error) {
+	sets, err := planChains(def, volumes, date, dates, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sets {
+		sets[i].Items = slices.DeleteFunc(sets[i].Items, func(item s3.BackupItem) bool {
+			return strings.HasSuffix(item.Name, archive.IndexSuffix)
+		})
+	}
+	return sets, nil
+}
+
+// planChains is PlanFetch including the content indexes.
+func planChains(def config.BackupDefinition, volumes []string, date s3.BackupDate, dates []s3.BackupDate, cfg config.Config) ([]s3.DownloadSet, error) {
+	bases, err := volumeArchiveBases(def, volumes)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s3.GetBackupItems(cfg, date)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []s3.BackupItem
+	found := make(map[string]bool)
+	for _, item := range items {
+		if base, _ := s3.ChainLinkBase(item.Name); bases[base] != "" {
+			selected = append(selected, item)
+			found[base] = true
+		}
+	}
+	for base, volume := range bases {
+		if !found[base] {
+			return nil, fmt.Errorf("no backup of volume %s of %s found in %s", volume, def.Name, date.FolderName)
+		}
+	}
+
+	return s3.PlanBackupDownload(cfg, dates, date, selected)
+}
+
+// FetchBackup downloads the sets planned by PlanFetch into localRoot, joins
+// and decrypts them without any prompts, ready for RestoreVolume.
+func FetchBackup(def config.BackupDefinition, volumes []string, sets []s3.DownloadSet, localRoot, privateKeyPassword string, cfg config.Config) (FetchedBackup, error) {
+	bases, err := volumeArchiveBases(def, volumes)
+	if err != nil {
+		return FetchedBackup{}, err
+	}
+
+	err = s3.DownloadSets(cfg, sets, localRoot)
+	if err != nil {
+		return FetchedBackup{}, err
+	}
+
+	err = script.Join(localRoot, cfg)
+	if err != nil {
+		return FetchedBackup{}, fmt.Errorf("failed to join split files: %w", err)
+	}
+
+	err = DecryptFolder(localRoot, cfg.App.PrivateKeyFile, privateKeyPassword, cfg)
+	if err != nil {
+		return FetchedBackup{}, err
+	}
+
+	date := sets[0].Date.FolderName
+	fetched := FetchedBackup{Folder: localRoot, Date: date, Archives: make(map[string]string)}
+	dateFolder := filepath.Join(localRoot, date)
+	for base, volume := range bases {
+		archive := findArchive(dateFolder, base)
+		if archive == "" {
+			archive = findArchive(dateFolder, base+script.IncrementalMarker)
+		}
+		if archive == "" {
+			return FetchedBackup{}, fmt.Errorf("no archive of volume %s found in %s", volume, date)
+		}
+		fetched.Archives[volume] = archive
+	}
+
+	return fetched, nil
+}
+
+func pickBackupDate(pick string, dates []s3.BackupDate, bases map[string]string, cfg config.Config) (s3.BackupDate, error) {
+	order := make([]int, len(dates))
+	for i := range order {
+		order[i] = i
+	}
+	switch pick {
+	case "", "latest":
+	case "random":
+		order = rand.Perm(len(dates))
+	default:
+		return s3.BackupDate{}, fmt.Errorf("unknown backup pick %q, expected latest or random", pick)
+	}
+
+	for _, i := range order {
+		items, err := s3.GetBackupItems(cfg, dates[i])
+		if err != nil {
+			return s3.BackupDate{}, err
+		}
+		found := make(map[string]bool)
+		for _, item := range items {
+			if base, _ := s3.ChainLinkBase(item.Name); bases[base] != "" {
+				found[base] = true
+			}
+		}
+		if len(found) == len(bases) {
+			return dates[i], nil
+		}
+	}
+	return s3.BackupDate{}, fmt.Errorf("no backup found to restore")
+}
+
+func selectBackupDate(date string, dates []s3.BackupDate, bases map[string]string, cfg config.Config) (s3.BackupDate, error) {
+	if date == "" || date == "latest" {
+		return pickBackupDate("latest", dates, bases, cfg)
+	}
+	index := slices.IndexFunc(dates, func(d s3.BackupDate) bool { return d.FolderName == date })
+	if index < 0 {
+		return s3.BackupDate{}, fmt.Errorf("backup date folder %s not found", date)
+	}
+	return dates[index], nil
+}
+
+func volumeArchiveBases(def config.BackupDefinition, volumes []string) (map[string]string, error) {
+	for _, volume := range volumes {
+		if !slices.Contains(def.Volumes, volume) {
+			return nil, fmt.Errorf("volume %s is not part of backup definition %s", volume, def.Name)
+		}
+	}
+
+	bases := make(map[string]string)
+	for index, volume := range def.Volumes {
+		if len(volumes) == 0 || slices.Contains(volumes, volume) {
+			bases[generateBackupBaseName(def.Name, volume, index)] = volume
+		}
+	}
+	return bases, nil
+}
@@ -21,6 +21,18 @@ func KeyDecrypt2Folder(workingFolder, encryptedPrivateKeyFile string, configurat
 	privateKeyPassword := string(passwordBytes)
 	fmt.Println() // Print a newline after password input
 
+	err = DecryptFolder(workingFolder, encryptedPrivateKeyFile, privateKeyPassword, configuration)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Folder decryption completed successfully.")
+	return nil
+}
+
+// DecryptFolder decrypts every .cpt file below workingFolder next to itself
+// and deletes the encrypted file and its .pass file.
+func DecryptFolder(workingFolder, encryptedPrivateKeyFile, privateKeyPassword string, configuration config.Config) error {
 	// Get list of encrypted files
 	encryptedFiles, err := listEncryptedFiles(workingFolder)
 	if err != nil {
@@ -29,9 +41,9 @@ func KeyDecrypt2Folder(workingFolder, encryptedPrivateKeyFile string, configurat
 
 	// Decrypt each file
 	for i, encryptedFile := range encryptedFiles {
-		fmt.Printf("Decrypting file %d of %d: %s\n", i+1, len(encryptedFiles), encryptedFile)
+		configuration.Logger().Info("Decrypting file", "step", "decrypt", "file", encryptedFile, "number", i+1, "of", len(encryptedFiles))
 
-		outputFile := filepath.Join(workingFolder, strings.TrimSuffix(filepath.Base(encryptedFile), ".cpt"))
+		outputFile := filepath.Join(filepath.Dir(encryptedFile), strings.TrimSuffix(filepath.Base(encryptedFile), ".cpt"))
 		err := script.KeyDecrypt2WithPass(encryptedFile, outputFile, encryptedPrivateKeyFile, privateKeyPassword, configuration)
 		if err != nil {
 			return fmt.Errorf("failed to decrypt %s: %w", encryptedFile, err)
@@ -39,11 +51,10 @@ func KeyDecrypt2Folder(workingFolder, encryptedPrivateKeyFile string, configurat
 
 		// Delete original encrypted file and its .pass file
 		if err := deleteEncryptedFiles(encryptedFile); err != nil {
-			fmt.Printf("Warning: Failed to delete %s: %v\n", encryptedFile, err)
+			configuration.Logger().Warn("Failed to delete encrypted file", "file", encryptedFile, "error", err)
 		}
 	}
 
-	fmt.Println("Folder decryption completed successfully.")
 	return nil
 }
 
@@ -1,30 +1,108 @@
 package backupops
 
 import (
+	"fmt"
 	"gos3/internal/config"
-	"log"
+	"gos3/internal/logging"
+	"gos3/internal/manifest"
+	"gos3/internal/metrics"
+	"gos3/internal/notify"
+	"gos3/internal/s3"
+	"strings"
+	"time"
 )
 
 func PerformBackups(cfg config.Config) error {
-	for _, backupDef := range cfg.BackupDefinitions {
-		log.Printf("Starting backup process for: %s", backupDef.Name)
+	runID := logging.NewRunID()
+	cfg = cfg.WithLog("run_id", runID)
+	if cfg.Metrics.Enabled() {
+		SeedMetrics(cfg)
+	}
+	var degraded []string
+	for i, backupDef := range cfg.BackupDefinitions {
+		defCfg, tail := startHeartbeat(backupDef, cfg.WithLog("definition", backupDef.Name))
+		defCfg.Logger().Info("Starting backup process", "type", backupDef.Type)
+		notify.Send(defCfg, notify.Event{Type: notify.EventRunStarted, Definition: backupDef.Name})
 
+		startedAt := time.Now()
+		var result BackupResult
 		var err error
 		switch backupDef.Type {
 		case "standard":
-			err = PerformStandardBackup(backupDef, cfg)
+			result, err = PerformStandardBackup(backupDef, defCfg)
 		default:
-			log.Printf("Unknown backup type: %s for backup: %s", backupDef.Type, backupDef.Name)
+			defCfg.Logger().Error("Unknown backup type", "type", backupDef.Type)
+			finishHeartbeat(backupDef, tail, false, defCfg)
 			continue
 		}
 
 		if err != nil {
-			log.Printf("Error performing backup %s: %v", backupDef.Name, err)
+			defCfg.Logger().Error("Backup failed", "error", err, "duration", time.Since(startedAt))
+			metrics.RecordRun(backupDef.Name, StatusFailed, time.Since(startedAt))
+			result.Definition = backupDef.Name
+			notifyRun(result, err, time.Since(startedAt), defCfg)
+			recordHistory(runID, result, err, startedAt, defCfg)
+			reportRun(runID, result, err, startedAt, defCfg)
+			finishHeartbeat(backupDef, tail, false, defCfg)
+			FailHeartbeats(cfg.BackupDefinitions[i+1:], fmt.Sprintf("Skipped: backup of %s failed: %v", backupDef.Name, err), cfg)
+			if len(result.Targets) == 0 {
+				run := manifest.Definition{Name: backupDef.Name, Type: backupDef.Type, StartedAt: startedAt.UTC()}
+				for _, target := range cfg.TargetNames(backupDef) {
+					recordRun(run, target, result.DateFolder, err, defCfg)
+				}
+			}
 			return err
 		}
 
-		log.Printf("Completed backup process for: %s", backupDef.Name)
+		if result.Status() == StatusDegraded {
+			degraded = append(degraded, backupDef.Name)
+		}
+
+		defCfg.Logger().Info("Completed backup process", "status", result.Status(), "duration", time.Since(startedAt))
+		metrics.RecordRun(backupDef.Name, result.Status(), time.Since(startedAt))
+		notifyRun(result, nil, time.Since(startedAt), defCfg)
+		recordHistory(runID, result, nil, startedAt, defCfg)
+		reportRun(runID, result, nil, startedAt, defCfg)
+		finishHeartbeat(backupDef, tail, result.Status() == StatusSuccess, defCfg)
+	}
+
+	for _, target := range cfg.AllTargetNames() {
+		pruneTarget(target, cfg)
 	}
 
+	if len(degraded) > 0 {
+		return fmt.Errorf("%w: %s", ErrDegraded, strings.Join(degraded, ", "))
+	}
 	return nil
 }
+
+func pruneTarget(target string, cfg config.Config) {
+	logger := cfg.Logger().With("step", "prune", "target", target)
+	targetCfg, err := cfg.ForTarget(target)
+	if err != nil {
+		logger.Warn("Failed to prune old backups", "error", err)
+		return
+	}
+
+	expired, err := s3.PruneBackups(targetCfg, false)
+	if err != nil {
+		logger.Warn("Failed to prune old backups", "error", err)
+	} else if len(expired) > 0 {
+		logger.Info("Pruned backup folders outside the retention policy", "folders", len(expired))
+	}
+
+	if cfg.Metrics.Enabled() {
+		RecordBucketSizes(targetCfg)
+	}
+}
+
+// RecordBucketSizes updates the folder size metrics of cfg's target. They
+// only feed dashboards, so failing to list the target is logged.
+func RecordBucketSizes(cfg config.Config) {
+	sizes, err := s3.BackupFolderSizes(cfg)
+	if err != nil {
+		cfg.Logger().Warn("Failed to measure backup folder sizes", "target", cfg.CurrentTarget(), "error", err)
+		return
+	}
+	metrics.RecordBucketSizes(cfg.CurrentTarget(), sizes)
+}
@@ -3,86 +3,161 @@ package backupops
 import (
 	"fmt"
 	"gos3/internal/config"
+	"gos3/internal/manifest"
+	"gos3/internal/metrics"
 	"gos3/internal/s3"
 	"gos3/internal/script"
-	"log"
 	"os"
 	"path/filepath"
+	"time"
 )
 
-func PerformStandardBackup(def config.BackupDefinition, cfg config.Config) error {
-	log.Printf("Starting standard backup process for: %s", def.Name)
+func PerformStandardBackup(def config.BackupDefinition, cfg config.Config) (BackupResult, error) {
+	logger := cfg.Logger()
+	startedAt := time.Now()
+	dateSubfolder := s3.GenerateSubfolderName(cfg.App.BackupFrequency)
+	result := BackupResult{Definition: def.Name, DateFolder: dateSubfolder}
 
-	err := cleanLocalBackupFolder(cfg.App.LocalBackupFolder)
+	prepared, err := loadPreparedRun(def, dateSubfolder, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to clean local backup folder: %w", err)
+		logger.Warn("Failed to read backup prepared by a failed run", "error", err)
+	}
+	if prepared != nil {
+		logger.Info("Resuming upload of backup prepared by a failed run", "step", "upload", "started_at", prepared.Run.StartedAt)
+		result.Timings = &prepared.Run.Timings
+		return uploadRun(def, preparedRunFolder(def, cfg), dateSubfolder, prepared.Run, prepared.pendingIndexes(), result, cfg)
 	}
 
-	log.Printf("Stopping containers: %v", def.Containers)
+	run := manifest.Definition{
+		Name:       def.Name,
+		Type:       def.Type,
+		StartedAt:  startedAt.UTC(),
+		Containers: inspectContainers(def.Containers, cfg),
+	}
+	result.Timings = &run.Timings
 
-	err = stopContainers(def.Containers)
+	codec, err := script.GetCodec(def.Compression.Codec)
 	if err != nil {
-		return err
+		return result, err
 	}
-	volumeCreationErrors := ""
-	log.Printf("Containers stopped successfully: %v", def.Containers)
 
-	for i, volumeName := range def.Volumes {
-		backupFileName := generateBackupFileName(def.Name, volumeName, i)
-		backupFilePath := filepath.Join(cfg.App.LocalBackupFolder, backupFileName)
-		log.Printf("Creating backup for volume: %s", volumeName)
+	err = cleanLocalBackupFolder(cfg.App.LocalBackupFolder)
+	if err != nil {
+		return result, fmt.Errorf("failed to clean local backup folder: %w", err)
+	}
 
-		result, err := script.VolumeBackup(volumeName, backupFilePath, true, cfg)
+	if !cfg.App.Preflight.Disabled {
+		err = preflight(def, cfg)
 		if err != nil {
-			volumeCreationErrors = err.Error()
-			log.Printf("Backup failed for volume: %s, %s", volumeName, err.Error())
-			break
-		}
-		log.Printf("Backup created successfully for volume: %s", volumeName)
-		log.Printf("Backup details for %s:", volumeName)
-		log.Printf("  Original size: %d bytes", result.OriginalSize)
-		log.Printf("  Final size: %d bytes", result.FinalSize)
-		log.Printf("  Compression ratio: %.2f", result.CompressionRatio)
-		log.Printf("  Time elapsed: %.6f seconds", result.TimeElapsed)
-
-		err = changeBackupPermissions(backupFilePath)
-		if err != nil {
-			log.Printf("Warning: failed to change permissions for %s: %v", backupFilePath, err)
+			return result, err
 		}
 	}
 
-	log.Printf("Starting containers: %v", def.Containers)
+	logger.Info("Stopping containers", "step", "stop", "containers", def.Containers)
+	stoppedAt := time.Now()
+	err = stopContainers(def.Containers)
+	if err != nil {
+		return result, err
+	}
+
+	archiveStart := time.Now()
+	archives := archiveVolumes(def, dateSubfolder, codec, cfg)
+	run.Timings.ArchiveSeconds = time.Since(archiveStart).Seconds()
+
+	logger.Info("Starting containers", "step", "start", "containers", def.Containers)
 	err = startContainers(def.Containers)
 	if err != nil {
-		return err
+		return result, err
+	}
+	result.Downtime = time.Since(stoppedAt)
+	logger.Info("Containers started", "step", "start", "downtime", result.Downtime)
+	metrics.RecordDowntime(def.Name, result.Downtime)
+
+	var volumeCreationErrors []error
+	var pendingIndexes []pendingIndex
+	for _, archive := range archives {
+		logVolumeArchive(archive, cfg)
+		if archive.Err != nil {
+			volumeCreationErrors = append(volumeCreationErrors, fmt.Errorf("%s: %w", archive.Volume, archive.Err))
+			continue
+		}
+		metrics.RecordVolume(def.Name, archive.Volume, archive.Result.OriginalSize, archive.Result.FinalSize)
+		if archive.Pending != nil {
+			pendingIndexes = append(pendingIndexes, *archive.Pending)
+		}
 	}
-	log.Printf("Containers started successfully: %v", def.Containers)
 
 	if len(volumeCreationErrors) != 0 {
-		return fmt.Errorf("error creating volumes: %s", volumeCreationErrors)
+		return result, fmt.Errorf("error creating volumes: %v", volumeCreationErrors)
 	}
 
-	err = encryptBackupFiles(cfg)
+	err = changeBackupPermissions(cfg.App.LocalBackupFolder)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt backup files: %w", err)
+		logger.Warn("Failed to change permissions of the local backup folder", "folder", cfg.App.LocalBackupFolder, "error", err)
 	}
 
+	writeArchiveIndexes(archives, dateSubfolder, cfg)
+
+	encryptStart := time.Now()
+	plaintextSizes, err := encryptBackupFiles(cfg)
+	if err != nil {
+		return result, fmt.Errorf("failed to encrypt backup files: %w", err)
+	}
+	run.Timings.EncryptSeconds = time.Since(encryptStart).Seconds()
+	logger.Info("Encrypted backup files", "step", "encrypt", "files", len(plaintextSizes), "duration", time.Since(encryptStart))
+
+	splitStart := time.Now()
 	err = script.Split(cfg.App.LocalBackupFolder, cfg.S3.MaxFileSize, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to split backup files: %w", err)
+		return result, fmt.Errorf("failed to split backup files: %w", err)
 	}
+	run.Timings.SplitSeconds = time.Since(splitStart).Seconds()
+	logger.Debug("Split large backup files", "step", "split", "duration", time.Since(splitStart))
 
-	err = s3.UploadFolderToS3(cfg.App.LocalBackupFolder, cfg.S3.BackupFolder, cfg)
+	err = describeRun(&run, def, archives, plaintextSizes, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to upload backup to S3: %w", err)
+		return result, fmt.Errorf("failed to build run manifest: %w", err)
 	}
 
+	return uploadRun(def, cfg.App.LocalBackupFolder, dateSubfolder, run, pendingIndexes, result, cfg)
+}
+
+func uploadRun(def config.BackupDefinition, localFolder, dateSubfolder string, run manifest.Definition, pendingIndexes []pendingIndex, result BackupResult, cfg config.Config) (BackupResult, error) {
+	logger := cfg.Logger()
+	result.Bytes = s3.StoredSize(run)
+	result.Manifest = &run
+
+	result.Targets = uploadToTargets(localFolder, dateSubfolder, cfg.TargetNames(def), run, cfg)
+
+	switch result.Status() {
+	case StatusFailed:
+		err := keepPreparedRun(def, localFolder, dateSubfolder, run, pendingIndexes, cfg)
+		if err != nil {
+			logger.Warn("Failed to keep backup for a retry", "error", err)
+		}
+		return result, fmt.Errorf("failed to upload backup to any target: %v", result.Targets[0].Err)
+	case StatusDegraded:
+		// Keep the previous indexes so the next incremental backup also
+		// covers the changes the failed targets are missing. It records its
+		// base folder, so restores skip this run where it was uploaded.
+		logger.Warn("Backup is degraded", "failed_targets", result.FailedTargets())
+	default:
+		err := commitVolumeIndexes(pendingIndexes, cfg)
+		if err != nil {
+			return result, fmt.Errorf("failed to update incremental indexes: %w", err)
+		}
+	}
+
+	err := discardPreparedRun(def, cfg)
+	if err != nil {
+		logger.Warn("Failed to remove backup prepared by a failed run", "error", err)
+	}
 	err = cleanLocalBackupFolder(cfg.App.LocalBackupFolder)
 	if err != nil {
-		log.Printf("Warning: failed to clean local backup folder after upload: %v", err)
+		logger.Warn("Failed to clean local backup folder after upload", "error", err)
 	}
 
-	return nil
+	return result, nil
 }
 
 func cleanLocalBackupFolder(folderPath string) error {
@@ -94,12 +169,14 @@ func cleanLocalBackupFolder(folderPath string) error {
 	return os.MkdirAll(folderPath, 0755)
 }
 
-func encryptBackupFiles(cfg config.Config) error {
+func encryptBackupFiles(cfg config.Config) (map[string]int64, error) {
 	files, err := os.ReadDir(cfg.App.LocalBackupFolder)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	sizes := make(map[string]int64)
+
 	for _, file := range files {
 		if file.IsDir() {
 			continue
@@ -108,16 +185,20 @@ func encryptBackupFiles(cfg config.Config) error {
 		filePath := filepath.Join(cfg.App.LocalBackupFolder, file.Name())
 		encryptedFilePath := filePath + ".cpt"
 
+		if info, err := file.Info(); err == nil {
+			sizes[file.Name()] = info.Size()
+		}
+
 		err = script.KeyEncrypt(filePath, encryptedFilePath, cfg.App.PublicKeyFile, cfg)
 		if err != nil {
-			return fmt.Errorf("failed to encrypt file %s: %w", filePath, err)
+			return nil, fmt.Errorf("failed to encrypt file %s: %w", filePath, err)
 		}
 
 		err = os.Remove(filePath)
 		if err != nil {
-			log.Printf("Warning: failed to remove original file %s after encryption: %v", filePath, err)
+			cfg.Logger().Warn("Failed to remove original file after encryption", "step", "encrypt", "file", filePath, "error", err)
 		}
 	}
 
-	return nil
+	return sizes, nil
 }
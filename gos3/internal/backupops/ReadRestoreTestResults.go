@@ -0,0 +1,37 @@
+package backupops
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"gos3/internal/config"
+	"os"
+	"path/filepath"
+)
+
+// ReadRestoreTestResults returns the restore test history, oldest first.
+// Lines that cannot be decoded are skipped.
+func ReadRestoreTestResults(cfg config.Config) ([]RestoreTestResult, error) {
+	file, err := os.Open(filepath.Join(cfg.App.StateFolder, RestoreTestHistoryFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open restore test history: %w", err)
+	}
+	defer file.Close()
+
+	var results []RestoreTestResult
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var result RestoreTestResult
+		if json.Unmarshal(scanner.Bytes(), &result) == nil {
+			results = append(results, result)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read restore test history: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,158 @@
+package backupops
+
+import (
+	"fmt"
+	"gos3/internal/config"
+	"gos3/internal/s3"
+	"os"
+	"slices"
+	"strings"
+)
+
+// RestorePlan is everything RestoreBackup does for one definition, so it can
+// be reviewed with --dry-run before anything is touched.
+type RestorePlan struct {
+	Definition config.BackupDefinition
+	Target     string
+	Date       string
+	Downloads  []s3.DownloadSet
+	Volumes    []VolumeRestorePlan
+	// StopContainers is empty when only new volumes are written.
+	StopContainers []string
+}
+
+type VolumeRestorePlan struct {
+	Volume       string
+	TargetVolume string
+}
+
+// PlanRestore resolves date and the downloads needed to restore the given
+// volumes. targetVolume restores a single volume under a new name.
+func PlanRestore(def config.BackupDefinition, volumes []string, targetVolume, date, target string, cfg config.Config) (RestorePlan, error) {
+	targetCfg, err := cfg.ForTarget(target)
+	if err != nil {
+		return RestorePlan{}, err
+	}
+
+	bases, err := volumeArchiveBases(def, volumes)
+	if err != nil {
+		return RestorePlan{}, err
+	}
+	if targetVolume != "" && len(bases) != 1 {
+		return RestorePlan{}, fmt.Errorf("a target volume needs exactly one volume to restore, %s has %d", def.Name, len(bases))
+	}
+
+	dates, err := s3.GetBackupDates(targetCfg)
+	if err != nil {
+		return RestorePlan{}, fmt.Errorf("failed to get backup dates: %w", err)
+	}
+
+	selected, err := selectBackupDate(date, dates, bases, targetCfg)
+	if err != nil {
+		return RestorePlan{}, err
+	}
+
+	plan := RestorePlan{Definition: def, Target: target, Date: selected.FolderName}
+	for _, volume := range def.Volumes {
+		if !slices.Contains(volumes, volume) && len(volumes) > 0 {
+			continue
+		}
+		step := VolumeRestorePlan{Volume: volume, TargetVolume: volume}
+		if targetVolume != "" {
+			step.TargetVolume = targetVolume
+		}
+		if step.TargetVolume == volume {
+			plan.StopContainers = def.Containers
+		}
+		plan.Volumes = append(plan.Volumes, step)
+	}
+
+	plan.Downloads, err = PlanFetch(def, volumes, selected, dates, targetCfg)
+	if err != nil {
+		return RestorePlan{}, err
+	}
+
+	return plan, nil
+}
+
+// RestoreBackup stops the containers of the definition, restores every volume
+// and starts the containers again, even when a restore failed.
+func RestoreBackup(plan RestorePlan, privateKeyPassword string, cfg config.Config) error {
+	cfg = cfg.WithLog("definition", plan.Definition.Name, "target", plan.Target, "date", plan.Date)
+	targetCfg, err := cfg.ForTarget(plan.Target)
+	if err != nil {
+		return err
+	}
+
+	localRoot, err := makeRestoreFolder("gos3-restore", cfg)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(localRoot)
+
+	volumes := make([]string, 0, len(plan.Volumes))
+	for _, step := range plan.Volumes {
+		volumes = append(volumes, step.Volume)
+	}
+
+	fetched, err := FetchBackup(plan.Definition, volumes, plan.Downloads, localRoot, privateKeyPassword, targetCfg)
+	if err != nil {
+		return err
+	}
+
+	if len(plan.StopContainers) > 0 {
+		cfg.Logger().Info("Stopping containers", "step", "stop", "containers", plan.StopContainers)
+		err = stopContainers(plan.StopContainers)
+		if err != nil {
+			// Containers that did stop must not stay down.
+			startErr := startContainers(plan.StopContainers)
+			if startErr != nil {
+				cfg.Logger().Warn("Failed to start containers", "step", "start", "error", startErr)
+			}
+			return err
+		}
+	}
+
+	var restoreErr error
+	for _, step := range plan.Volumes {
+		cfg.Logger().Info("Restoring volume", "step", "restore", "volume", step.Volume, "target_volume", step.TargetVolume)
+		restoreErr = RestoreVolume(step.TargetVolume, fetched.Archives[step.Volume], cfg)
+		if restoreErr != nil {
+			restoreErr = fmt.Errorf("failed to restore %s: %w", step.Volume, restoreErr)
+			break
+		}
+	}
+
+	if len(plan.StopContainers) > 0 {
+		cfg.Logger().Info("Starting containers", "step", "start", "containers", plan.StopContainers)
+		err = startContainers(plan.StopContainers)
+		if err != nil && restoreErr == nil {
+			return err
+		}
+		if err != nil {
+			cfg.Logger().Warn("Failed to start containers", "step", "start", "error", err)
+		}
+	}
+
+	return restoreErr
+}
+
+func PrintRestorePlan(plan RestorePlan) {
+	fmt.Printf("Restore plan for %s from target %s, date %s:\n", plan.Definition.Name, plan.Target, plan.Date)
+	for _, set := range plan.Downloads {
+		names := make([]string, 0, len(set.Items))
+		for _, item := range set.Items {
+			names = append(names, item.Name)
+		}
+		fmt.Printf("  download %s: %s\n", set.Date.FolderName, strings.Join(names, ", "))
+	}
+	if len(plan.StopContainers) > 0 {
+		fmt.Printf("  stop containers: %s\n", strings.Join(plan.StopContainers, ", "))
+	}
+	for _, step := range plan.Volumes {
+		fmt.Printf("  restore %s -> volume %s\n", step.Volume, step.TargetVolume)
+	}
+	if len(plan.StopContainers) > 0 {
+		fmt.Printf("  start containers: %s\n", strings.Join(plan.StopContainers, ", "))
+	}
+}
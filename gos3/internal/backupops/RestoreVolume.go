@@ -0,0 +1,114 @@
+package backupops
+
+import (
+	"fmt"
+	"gos3/internal/config"
+	"gos3/internal/script"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// RestoreVolume restores a volume from a decrypted archive. An incremental
+// archive is applied on top of its full backup and the incrementals before it.
+func RestoreVolume(volumeName, backupFile string, cfg config.Config) error {
+	backupFile = config.MustGetAbsPathRelativeToAppFolder(backupFile, cfg)
+	base, incremental, _, _ := script.ParseArchiveName(filepath.Base(backupFile))
+	if !incremental {
+		return script.VolumeRestore(volumeName, backupFile, cfg)
+	}
+
+	full, incrementals, err := resolveRestoreChain(backupFile, base)
+	if err != nil {
+		return err
+	}
+
+	cfg.Logger().Info("Restoring full backup", "step", "restore", "volume", volumeName, "file", full)
+	err = script.VolumeRestore(volumeName, full, cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, incremental := range incrementals {
+		deletedList := filepath.Join(filepath.Dir(incremental), base+script.IncrementalDeletedSuffix)
+		if _, err := os.Stat(deletedList); err != nil {
+			deletedList = ""
+		}
+
+		cfg.Logger().Info("Applying incremental backup", "step", "restore", "volume", volumeName, "file", incremental)
+		err = script.VolumeRestoreIncremental(volumeName, incremental, deletedList, cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resolveRestoreChain(backupFile, base string) (string, []string, error) {
+	dateFolder := filepath.Dir(backupFile)
+	rootFolder := filepath.Dir(dateFolder)
+	selected := filepath.Base(dateFolder)
+
+	entries, err := os.ReadDir(rootFolder)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read backup folder %s: %w", rootFolder, err)
+	}
+
+	var folders []string
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() <= selected {
+			folders = append(folders, entry.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(folders)))
+
+	// Incrementals record the folder they were diffed against, so archives of
+	// runs they supersede are skipped. Older ones without it link to the newest
+	// earlier folder holding the base: a run in which this definition failed,
+	// or a stale download, leaves folders without it.
+	var incrementals []string
+	next := ""
+	for _, folder := range folders {
+		if next != "" && folder != next {
+			continue
+		}
+		folderPath := filepath.Join(rootFolder, folder)
+		if fullPath := findArchive(folderPath, base); fullPath != "" {
+			return fullPath, incrementals, nil
+		}
+
+		incrementalPath := findArchive(folderPath, base+script.IncrementalMarker)
+		if incrementalPath == "" {
+			if next != "" {
+				return "", nil, fmt.Errorf("incremental chain for %s is broken: nothing found in %s", base, folder)
+			}
+			continue
+		}
+		incrementals = append([]string{incrementalPath}, incrementals...)
+
+		metadata, err := script.ReadArchiveMetadata(incrementalPath)
+		if err != nil {
+			return "", nil, err
+		}
+		next = ""
+		if metadata != nil {
+			next = metadata.BaseFolder
+		}
+	}
+
+	if next != "" {
+		return "", nil, fmt.Errorf("incremental chain for %s is broken: %s not found in %s", base, next, rootFolder)
+	}
+	return "", nil, fmt.Errorf("no full backup found for %s in %s", base, rootFolder)
+}
+
+func findArchive(folder, prefix string) string {
+	for _, codec := range script.Codecs {
+		path := filepath.Join(folder, prefix+codec.Extension)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
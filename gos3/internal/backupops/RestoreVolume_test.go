@@ -0,0 +1,111 @@
+package backupops
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"gos3/internal/script"
+)
+
+func TestResolveRestoreChain(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        []string
+		baseFolders  map[string]string
+		selected     string
+		full         string
+		incrementals []string
+		err          string
+	}{
+		{
+			name:         "chain",
+			files:        []string{"01/def-vol.tar.gz", "02/def-vol.incr.tar.gz", "03/def-vol.incr.tar.gz", "04/def-vol.incr.tar.gz"},
+			selected:     "03/def-vol.incr.tar.gz",
+			full:         "01/def-vol.tar.gz",
+			incrementals: []string{"02/def-vol.incr.tar.gz", "03/def-vol.incr.tar.gz"},
+		},
+		{
+			name:         "gap",
+			files:        []string{"01/def-vol.tar.gz", "02/def-vol.incr.tar.gz", "03/other-vol.tar.gz", "04/def-vol.incr.tar.gz"},
+			selected:     "04/def-vol.incr.tar.gz",
+			full:         "01/def-vol.tar.gz",
+			incrementals: []string{"02/def-vol.incr.tar.gz", "04/def-vol.incr.tar.gz"},
+		},
+		{
+			name:         "full period rollover",
+			files:        []string{"01/def-vol.tar.gz", "02/def-vol.incr.tar.gz", "03/def-vol.tar.zst", "04/def-vol.incr.tar.zst"},
+			selected:     "04/def-vol.incr.tar.zst",
+			full:         "03/def-vol.tar.zst",
+			incrementals: []string{"04/def-vol.incr.tar.zst"},
+		},
+		{
+			// 03 was degraded, so 04 was diffed against 02 again.
+			name:         "superseded run",
+			files:        []string{"01/def-vol.tar.gz", "02/def-vol.incr.tar.gz", "03/def-vol.incr.tar.gz", "04/def-vol.incr.tar.gz"},
+			baseFolders:  map[string]string{"02/def-vol.incr.tar.gz": "01", "03/def-vol.incr.tar.gz": "02", "04/def-vol.incr.tar.gz": "02"},
+			selected:     "04/def-vol.incr.tar.gz",
+			full:         "01/def-vol.tar.gz",
+			incrementals: []string{"02/def-vol.incr.tar.gz", "04/def-vol.incr.tar.gz"},
+		},
+		{
+			name:        "missing base folder",
+			files:       []string{"01/def-vol.tar.gz", "03/def-vol.incr.tar.gz"},
+			baseFolders: map[string]string{"03/def-vol.incr.tar.gz": "02"},
+			selected:    "03/def-vol.incr.tar.gz",
+			err:         "02 not found",
+		},
+		{
+			name:     "no full backup",
+			files:    []string{"02/def-vol.incr.tar.gz", "03/def-vol.incr.tar.gz"},
+			selected: "03/def-vol.incr.tar.gz",
+			err:      "no full backup found for def-vol",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, file := range test.files {
+				path := filepath.Join(root, file)
+				err := os.MkdirAll(filepath.Dir(path), 0755)
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = os.WriteFile(path, nil, 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if baseFolder := test.baseFolders[file]; baseFolder != "" {
+					err = script.WriteArchiveMetadata(path, script.ArchiveMetadata{Incremental: true, BaseFolder: baseFolder})
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			full, incrementals, err := resolveRestoreChain(filepath.Join(root, test.selected), "def-vol")
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if full != filepath.Join(root, test.full) {
+				t.Errorf("full = %s, want %s", full, test.full)
+			}
+			var want []string
+			for _, incremental := range test.incrementals {
+				want = append(want, filepath.Join(root, incremental))
+			}
+			if !slices.Equal(incrementals, want) {
+				t.Errorf("incrementals = %v, want %v", incrementals, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package backupops
+
+import (
+	"encoding/json"
+	"fmt"
+	"gos3/internal/config"
+	"gos3/internal/logging"
+	"gos3/internal/metrics"
+	"gos3/internal/notify"
+	"gos3/internal/s3"
+	"gos3/internal/script"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RestoreTestHistoryFile is the JSON lines file in the state folder every
+// restore drill result is appended to.
+const RestoreTestHistoryFile = "restore-tests.jsonl"
+
+type RestoreTestResult struct {
+	Name        string    `json:"name"`
+	Definition  string    `json:"definition"`
+	Target      string    `json:"target"`
+	Date        string    `json:"date"`
+	Volumes     []string  `json:"volumes"`
+	StartedAt   time.Time `json:"startedAt"`
+	Seconds     float64   `json:"seconds"`
+	Passed      bool      `json:"passed"`
+	Error       string    `json:"error,omitempty"`
+	CheckOutput string    `json:"checkOutput,omitempty"`
+}
+
+// RunRestoreTest restores the backup picked by test into throwaway Docker
+// volumes, checks them and appends the result to the restore test history.
+func RunRestoreTest(test config.RestoreTestConfig, privateKeyPassword string, cfg config.Config) RestoreTestResult {
+	cfg = cfg.WithLog("run_id", logging.NewRunID(), "restore_test", test.Name, "definition", test.Definition)
+	startedAt := time.Now()
+	result := RestoreTestResult{
+		Name:       test.Name,
+		Definition: test.Definition,
+		Target:     test.Target,
+		StartedAt:  startedAt.UTC(),
+	}
+	if result.Target == "" {
+		result.Target = config.DefaultTargetName
+	}
+
+	cfg.Logger().Info("Starting restore test", "step", "drill")
+	err := runRestoreTest(test, privateKeyPassword, cfg, &result)
+	result.Seconds = time.Since(startedAt).Seconds()
+	result.Passed = err == nil
+	if err != nil {
+		result.Error = err.Error()
+		cfg.Logger().Error("Restore test failed", "step", "drill", "date", result.Date, "duration", time.Since(startedAt), "error", err)
+		notify.Send(cfg, notify.Event{
+			Type:       notify.EventVerificationFailed,
+			Definition: test.Definition,
+			Target:     result.Target,
+			Date:       result.Date,
+			Error:      err.Error(),
+			Duration:   time.Since(startedAt),
+			Details:    []string{"restore test " + test.Name + ": " + err.Error()},
+		})
+	} else {
+		cfg.Logger().Info("Restore test passed", "step", "drill", "date", result.Date, "duration", time.Since(startedAt))
+	}
+	metrics.RecordRestoreTest(test.Name, test.Definition, result.Passed, startedAt, time.Since(startedAt))
+
+	err = appendRestoreTestResult(result, cfg)
+	if err != nil {
+		cfg.Logger().Warn("Failed to record restore test result", "error", err)
+	}
+	return result
+}
+
+func runRestoreTest(test config.RestoreTestConfig, privateKeyPassword string, cfg config.Config, result *RestoreTestResult) error {
+	def, err := cfg.BackupDefinitionByName(test.Definition)
+	if err != nil {
+		return err
+	}
+
+	targetCfg, err := cfg.ForTarget(test.Target)
+	if err != nil {
+		return err
+	}
+
+	bases, err := volumeArchiveBases(def, test.Volumes)
+	if err != nil {
+		return err
+	}
+
+	dates, err := s3.GetBackupDates(targetCfg)
+	if err != nil {
+		return fmt.Errorf("failed to get backup dates: %w", err)
+	}
+
+	date, err := pickBackupDate(test.Pick, dates, bases, targetCfg)
+	if err != nil {
+		return err
+	}
+	result.Date = date.FolderName
+
+	localRoot, err := makeRestoreFolder("gos3-restore-test", cfg)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(localRoot)
+
+	sets, err := PlanFetch(def, test.Volumes, date, dates, targetCfg)
+	if err != nil {
+		return err
+	}
+
+	fetched, err := FetchBackup(def, test.Volumes, sets, localRoot, privateKeyPassword, targetCfg)
+	if err != nil {
+		return err
+	}
+
+	runID := time.Now().Format("20060102-150405")
+	restored := make(map[string]string)
+	defer func() {
+		for _, volume := range restored {
+			removeVolume(volume, cfg)
+		}
+	}()
+
+	volumes := slices.Sorted(maps.Keys(fetched.Archives))
+	for _, volume := range volumes {
+		archive := fetched.Archives[volume]
+		base, _, _, _ := script.ParseArchiveName(filepath.Base(archive))
+		tempVolume := dockerName("gos3-drill-" + base + "-" + runID)
+		restored[volume] = tempVolume
+		result.Volumes = append(result.Volumes, volume)
+
+		cfg.Logger().Info("Restoring into throwaway volume", "step", "restore", "volume", volume, "target_volume", tempVolume)
+		err = RestoreVolume(tempVolume, archive, cfg)
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", volume, err)
+		}
+	}
+
+	if test.Check == nil {
+		return nil
+	}
+
+	containerName := dockerName("gos3-drill-check-" + runID)
+	cfg.Logger().Info("Running restore check", "step", "check", "container", containerName, "image", test.Check.Image)
+	output, err := runRestoreCheck(*test.Check, restored, containerName)
+	result.CheckOutput = output
+	return err
+}
+
+// A non-zero exit status or a timeout fails the check.
+func runRestoreCheck(check config.RestoreCheckConfig, volumes map[string]string, containerName string) (string, error) {
+	timeout := 10 * time.Minute
+	if check.Timeout != "" {
+		var err error
+		timeout, err = config.ParseDuration(check.Timeout)
+		if err != nil {
+			return "", fmt.Errorf("invalid check timeout: %w", err)
+		}
+	}
+
+	args := []string{"run", "--rm", "--name", containerName}
+	for volume, tempVolume := range volumes {
+		mountPath := check.Mounts[volume]
+		if mountPath == "" {
+			mountPath = "/restore/" + filepath.Base(volume)
+		}
+		args = append(args, "-v", tempVolume+":"+mountPath)
+	}
+	for _, env := range check.Env {
+		args = append(args, "-e", env)
+	}
+	args = append(args, check.Image)
+	args = append(args, check.Command...)
+
+	cmd := exec.Command("docker", args...)
+	timer := time.AfterFunc(timeout, func() {
+		exec.Command("docker", "rm", "-f", containerName).Run()
+	})
+	output, err := cmd.CombinedOutput()
+	timedOut := !timer.Stop()
+
+	text := tailOutput(string(output), 4096)
+	if timedOut {
+		return text, fmt.Errorf("check container timed out after %s", timeout)
+	}
+	if err != nil {
+		return text, fmt.Errorf("check container failed: %w", err)
+	}
+	return text, nil
+}
+
+func tailOutput(output string, limit int) string {
+	if len(output) <= limit {
+		return output
+	}
+	return "..." + output[len(output)-limit:]
+}
+
+var invalidDockerName = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+func dockerName(name string) string {
+	return invalidDockerName.ReplaceAllString(name, "-")
+}
+
+func removeVolume(volume string, cfg config.Config) {
+	output, err := exec.Command("docker", "volume", "rm", "-f", volume).CombinedOutput()
+	if err != nil {
+		cfg.Logger().Warn("Failed to remove volume", "volume", volume, "error", err, "output", strings.TrimSpace(string(output)))
+	}
+}
+
+func makeRestoreFolder(pattern string, cfg config.Config) (string, error) {
+	if cfg.App.RestoreFolder != "" {
+		err := os.MkdirAll(cfg.App.RestoreFolder, 0700)
+		if err != nil {
+			return "", fmt.Errorf("failed to create restore folder: %w", err)
+		}
+	}
+	folder, err := os.MkdirTemp(cfg.App.RestoreFolder, pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create restore folder: %w", err)
+	}
+	return folder, nil
+}
+
+func appendRestoreTestResult(result RestoreTestResult, cfg config.Config) error {
+	err := os.MkdirAll(cfg.App.StateFolder, 0755)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filepath.Join(cfg.App.StateFolder, RestoreTestHistoryFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
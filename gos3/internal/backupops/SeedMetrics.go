@@ -0,0 +1,39 @@
+package backupops
+
+import (
+	"gos3/internal/catalog"
+	"gos3/internal/config"
+	"gos3/internal/metrics"
+)
+
+// SeedMetrics sets the last success time of every definition and target from
+// the catalog, so a fresh process does not report a target as never backed up.
+func SeedMetrics(cfg config.Config) {
+	if cfg.App.DisableCatalog {
+		return
+	}
+
+	cat, err := catalog.Open(cfg)
+	if err != nil {
+		cfg.Logger().Warn("Failed to read catalog for metrics", "error", err)
+		return
+	}
+	defer cat.Close()
+
+	runs, err := cat.Runs(catalog.Filter{Status: catalog.StatusSuccess})
+	if err != nil {
+		cfg.Logger().Warn("Failed to read catalog for metrics", "error", err)
+		return
+	}
+
+	// Runs come newest first.
+	seen := make(map[string]bool)
+	for _, run := range runs {
+		key := run.Definition.Name + "\x00" + run.Target
+		if run.Legacy || seen[key] {
+			continue
+		}
+		seen[key] = true
+		metrics.RecordSuccess(run.Definition.Name, run.Target, run.Definition.FinishedAt)
+	}
+}
@@ -0,0 +1,137 @@
+package backupops
+
+import (
+	"fmt"
+	"gos3/internal/config"
+	"gos3/internal/script"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type volumeArchive struct {
+	Volume     string
+	FileName   string
+	BaseFolder string
+	Result     *script.VolumeBackupResult
+	Pending    *pendingIndex
+	Duration   time.Duration
+	Err        error
+}
+
+// ioSlots counts the volume archives running across all backup definitions.
+var ioSlots struct {
+	mu    sync.Mutex
+	inUse int
+}
+
+var ioSlotFreed = sync.NewCond(&ioSlots.mu)
+
+// acquireIOSlot reads the limit from cfg on every call; unset means unbounded.
+func acquireIOSlot(cfg config.Config) func() {
+	limit := cfg.App.MaxConcurrentIO
+	ioSlots.mu.Lock()
+	for limit > 0 && ioSlots.inUse >= limit {
+		ioSlotFreed.Wait()
+	}
+	ioSlots.inUse++
+	ioSlots.mu.Unlock()
+
+	return func() {
+		ioSlots.mu.Lock()
+		ioSlots.inUse--
+		ioSlots.mu.Unlock()
+		ioSlotFreed.Broadcast()
+	}
+}
+
+// archiveVolumes skips the volumes not started yet once a volume failed.
+func archiveVolumes(def config.BackupDefinition, dateSubfolder string, codec script.Codec, cfg config.Config) []volumeArchive {
+	workers := def.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	archives := make([]volumeArchive, len(def.Volumes))
+	jobs := make(chan int)
+
+	var failed sync.Once
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				select {
+				case <-stop:
+					archives[i] = volumeArchive{Volume: def.Volumes[i], Err: fmt.Errorf("skipped after a previous volume failed")}
+					continue
+				default:
+				}
+
+				release := acquireIOSlot(cfg)
+				archives[i] = archiveVolume(def, def.Volumes[i], i, dateSubfolder, codec, cfg)
+				release()
+
+				if archives[i].Err != nil {
+					failed.Do(func() { close(stop) })
+				}
+			}
+		}()
+	}
+
+	for i := range def.Volumes {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return archives
+}
+
+func archiveVolume(def config.BackupDefinition, volumeName string, index int, dateSubfolder string, codec script.Codec, cfg config.Config) volumeArchive {
+	archive := volumeArchive{
+		Volume:   volumeName,
+		FileName: generateBackupBaseName(def.Name, volumeName, index) + codec.Extension,
+	}
+	logger := cfg.Logger().With("volume", volumeName, "step", "archive")
+	logger.Info("Creating backup of volume")
+	start := time.Now()
+
+	if def.Incremental.Enabled {
+		incremental, err := createIncrementalVolumeBackup(def, volumeName, index, dateSubfolder, cfg)
+		if err == nil {
+			archive.FileName = incremental.FileName
+			archive.BaseFolder = incremental.BaseFolder
+			archive.Result = incremental.Result
+			archive.Pending = &incremental.Pending
+			if incremental.Full {
+				logger.Info("Full backup of volume", "files", incremental.Changed)
+			} else {
+				logger.Info("Incremental backup of volume", "changed", incremental.Changed, "deleted", incremental.Deleted)
+			}
+		}
+		archive.Err = err
+	} else {
+		archive.Result, archive.Err = script.VolumeBackup(volumeName, filepath.Join(cfg.App.LocalBackupFolder, archive.FileName), def.Compression, cfg)
+	}
+
+	archive.Duration = time.Since(start)
+	return archive
+}
+
+// Logged once the containers run again, so concurrent volumes do not interleave.
+func logVolumeArchive(archive volumeArchive, cfg config.Config) {
+	logger := cfg.Logger().With("volume", archive.Volume, "step", "archive", "duration", archive.Duration)
+	if archive.Err != nil {
+		logger.Error("Backup of volume failed", "error", archive.Err)
+		return
+	}
+	logger.Info("Backup of volume created",
+		"file", archive.FileName,
+		"original_bytes", archive.Result.OriginalSize,
+		"bytes", archive.Result.FinalSize,
+		"compression_ratio", archive.Result.CompressionRatio)
+}
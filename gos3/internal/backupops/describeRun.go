@@ -0,0 +1,55 @@
+package backupops
+
+import (
+	"gos3/internal/config"
+	"gos3/internal/manifest"
+	"gos3/internal/script"
+	"strings"
+)
+
+func describeRun(run *manifest.Definition, def config.BackupDefinition, archives []volumeArchive, plaintextSizes map[string]int64, cfg config.Config) error {
+	fingerprint, err := manifest.KeyFingerprint(cfg.App.PublicKeyFile)
+	if err != nil {
+		cfg.Logger().Warn("Failed to compute public key fingerprint", "error", err)
+	}
+	run.KeyFingerprint = fingerprint
+
+	items, err := manifest.BuildItems(cfg.App.LocalBackupFolder, plaintextSizes)
+	if err != nil {
+		return err
+	}
+
+	for index, archive := range archives {
+		volume := manifest.Volume{
+			Volume:  archive.Volume,
+			Archive: archive.FileName,
+			Seconds: archive.Duration.Seconds(),
+		}
+		if _, incremental, codec, ok := script.ParseArchiveName(archive.FileName); ok {
+			volume.Codec = codec.Name
+			volume.Incremental = incremental
+			if codec.Name != "none" {
+				volume.Level = def.Compression.Level
+				volume.Threads = def.Compression.Threads
+			}
+		}
+		if archive.Result != nil {
+			volume.OriginalSize = archive.Result.OriginalSize
+			volume.CompressedSize = archive.Result.FinalSize
+		}
+		run.Volumes = append(run.Volumes, volume)
+
+		base := generateBackupBaseName(def.Name, archive.Volume, index) + "."
+		for i := range items {
+			if strings.HasPrefix(items[i].Name, base) {
+				items[i].Volume = archive.Volume
+			}
+			if items[i].Name == archive.FileName {
+				items[i].BaseFolder = archive.BaseFolder
+			}
+		}
+	}
+
+	run.Items = items
+	return nil
+}
@@ -4,10 +4,10 @@ import (
 	"fmt"
 	"gos3/internal/config"
 	"gos3/internal/script"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
 )
 
@@ -70,11 +70,11 @@ func startContainers(containers []string) error {
 	return nil
 }
 
-func generateBackupFileName(backupName string, volumeName string, index int) string {
+func generateBackupBaseName(backupName string, volumeName string, index int) string {
 	if isVolumePath(volumeName) {
-		return fmt.Sprintf("%s-%d.tar.gz", backupName, index)
+		return fmt.Sprintf("%s-%d", backupName, index)
 	}
-	return fmt.Sprintf("%s-%s.tar.gz", backupName, volumeName)
+	return fmt.Sprintf("%s-%s", backupName, volumeName)
 }
 
 func encryptBackup(inputFile, outputFile string, cfg config.Config) error {
@@ -83,7 +83,7 @@ func encryptBackup(inputFile, outputFile string, cfg config.Config) error {
 		return fmt.Errorf("failed to encrypt backup: %w", err)
 	}
 
-	log.Printf("Encrypted file: %s to %s", inputFile, outputFile)
+	cfg.Logger().Debug("Encrypted file", "file", inputFile, "output", outputFile)
 	return nil
 }
 
@@ -92,8 +92,16 @@ func isVolumePath(volumeName string) bool {
 	return filepath.IsAbs(volumeName)
 }
 
-func changeBackupPermissions(backupFilePath string) error {
-	cmd := exec.Command("docker", "run", "--rm", "-v", fmt.Sprintf("%s:/backup", filepath.Dir(backupFilePath)),
+func changeBackupPermissions(backupFolder string) error {
+	cmd := exec.Command("docker", "run", "--rm", "-v", fmt.Sprintf("%s:/backup", backupFolder),
 		"alpine", "chown", "-R", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()), "/backup")
 	return cmd.Run()
 }
+
+func writeLines(path string, lines []string) error {
+	content := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		content += "\n"
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
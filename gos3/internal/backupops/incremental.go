@@ -0,0 +1,275 @@
+package backupops
+
+import (
+	"encoding/json"
+	"fmt"
+	"gos3/internal/config"
+	"gos3/internal/script"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+type volumeIndex struct {
+	Folder     string                             `json:"folder"`
+	Full       bool                               `json:"full"`
+	FullPeriod string                             `json:"fullPeriod"`
+	CreatedAt  time.Time                          `json:"createdAt"`
+	Entries    map[string]script.VolumeIndexEntry `json:"entries"`
+}
+
+// pendingIndex is only committed once its backup is uploaded, so a failed run
+// never advances the chain.
+type pendingIndex struct {
+	key   string
+	index volumeIndex
+}
+
+type incrementalBackupResult struct {
+	FileName   string
+	Full       bool
+	BaseFolder string
+	Changed    int
+	Deleted    int
+	Result     *script.VolumeBackupResult
+	Pending    pendingIndex
+}
+
+func createIncrementalVolumeBackup(def config.BackupDefinition, volumeName string, index int, folder string, cfg config.Config) (*incrementalBackupResult, error) {
+	key := generateBackupBaseName(def.Name, volumeName, index)
+	codec, err := script.GetCodec(def.Compression.Codec)
+	if err != nil {
+		return nil, err
+	}
+	period := fullBackupPeriod(def.Incremental.FullBackupFrequency, time.Now())
+
+	base, err := selectBaseIndex(key, folder, period, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := script.VolumeIndex(volumeName, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index volume %s: %w", volumeName, err)
+	}
+
+	current := volumeIndex{
+		Folder:     folder,
+		Full:       base == nil,
+		FullPeriod: period,
+		CreatedAt:  time.Now().UTC(),
+		Entries:    make(map[string]script.VolumeIndexEntry, len(entries)),
+	}
+	if base != nil {
+		current.FullPeriod = base.FullPeriod
+	}
+
+	changed, deleted, err := diffVolumeIndex(volumeName, base, entries, current.Entries, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &incrementalBackupResult{
+		Full:    current.Full,
+		Changed: len(changed),
+		Deleted: len(deleted),
+		Pending: pendingIndex{key: key, index: current},
+	}
+
+	if current.Full {
+		result.FileName = key + codec.Extension
+		result.Result, err = script.VolumeBackup(volumeName, filepath.Join(cfg.App.LocalBackupFolder, result.FileName), def.Compression, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	result.FileName = key + script.IncrementalMarker + codec.Extension
+	result.BaseFolder = base.Folder
+	archivePath := filepath.Join(cfg.App.LocalBackupFolder, result.FileName)
+	deletedPath := filepath.Join(cfg.App.LocalBackupFolder, key+script.IncrementalDeletedSuffix)
+
+	err = writeLines(deletedPath, deleted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write deleted list: %w", err)
+	}
+
+	listFile, err := os.CreateTemp("", "gos3-filelist")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file list: %w", err)
+	}
+	listFile.Close()
+	defer os.Remove(listFile.Name())
+
+	err = writeLines(listFile.Name(), changed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write file list: %w", err)
+	}
+
+	result.Result, err = script.VolumeBackupFiles(volumeName, archivePath, listFile.Name(), base.Folder, def.Compression, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// selectBaseIndex returns nil when a full backup is due. A re-run into the same
+// date folder overwrites its archive, so it diffs against the previous folder.
+func selectBaseIndex(key, folder, period string, cfg config.Config) (*volumeIndex, error) {
+	latest, err := loadVolumeIndex(indexPath(key, "latest", cfg))
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil {
+		return nil, nil
+	}
+
+	base := latest
+	if latest.Folder == folder {
+		if latest.Full {
+			return nil, nil
+		}
+		base, err = loadVolumeIndex(indexPath(key, "previous", cfg))
+		if err != nil || base == nil {
+			return nil, err
+		}
+	}
+
+	if base.FullPeriod != period {
+		return nil, nil
+	}
+	return base, nil
+}
+
+func diffVolumeIndex(volumeName string, base *volumeIndex, entries []script.VolumeIndexEntry, next map[string]script.VolumeIndexEntry, cfg config.Config) ([]string, []string, error) {
+	var changed, toHash []string
+
+	for _, entry := range entries {
+		if base != nil {
+			previous, ok := base.Entries[entry.Path]
+			if ok && previous.Size == entry.Size && previous.ModTime == entry.ModTime && previous.Inode == entry.Inode {
+				entry.Hash = previous.Hash
+				next[entry.Path] = entry
+				continue
+			}
+		}
+		next[entry.Path] = entry
+		changed = append(changed, entry.Path)
+		if !entry.Symlink {
+			toHash = append(toHash, entry.Path)
+		}
+	}
+
+	hashes, err := script.VolumeHash(volumeName, toHash, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to hash changed files of %s: %w", volumeName, err)
+	}
+
+	filtered := changed[:0]
+	for _, path := range changed {
+		entry := next[path]
+		entry.Hash = hashes[path]
+		next[path] = entry
+
+		if base != nil && entry.Hash != "" {
+			previous, ok := base.Entries[path]
+			if ok && previous.Hash == entry.Hash && previous.Size == entry.Size {
+				continue
+			}
+		}
+		filtered = append(filtered, path)
+	}
+	changed = filtered
+
+	var deleted []string
+	if base != nil {
+		for path := range base.Entries {
+			if _, ok := next[path]; !ok {
+				deleted = append(deleted, path)
+			}
+		}
+	}
+
+	sort.Strings(changed)
+	sort.Strings(deleted)
+	return changed, deleted, nil
+}
+
+func commitVolumeIndexes(pending []pendingIndex, cfg config.Config) error {
+	for _, p := range pending {
+		latestPath := indexPath(p.key, "latest", cfg)
+
+		latest, err := loadVolumeIndex(latestPath)
+		if err != nil {
+			return err
+		}
+		if latest != nil && latest.Folder != p.index.Folder {
+			err = os.Rename(latestPath, indexPath(p.key, "previous", cfg))
+			if err != nil {
+				return fmt.Errorf("failed to rotate index for %s: %w", p.key, err)
+			}
+		}
+
+		err = saveVolumeIndex(latestPath, p.index)
+		if err != nil {
+			return err
+		}
+		cfg.Logger().Debug("Updated incremental index", "index", p.key, "entries", len(p.index.Entries))
+	}
+	return nil
+}
+
+func fullBackupPeriod(frequency string, now time.Time) string {
+	switch frequency {
+	case "daily":
+		return now.Format("2006-01-02")
+	case "monthly":
+		return now.Format("2006-01")
+	default:
+		year, week := now.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+}
+
+func indexPath(key, name string, cfg config.Config) string {
+	return filepath.Join(cfg.App.StateFolder, "incremental", key, name+".json")
+}
+
+func loadVolumeIndex(path string) (*volumeIndex, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index %s: %w", path, err)
+	}
+
+	var index volumeIndex
+	err = json.Unmarshal(data, &index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse index %s: %w", path, err)
+	}
+	return &index, nil
+}
+
+func saveVolumeIndex(path string, index volumeIndex) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create index folder: %w", err)
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	err = os.WriteFile(tmpPath, data, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write index %s: %w", path, err)
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,175 @@
+package backupops
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"gos3/internal/config"
+	"gos3/internal/script"
+)
+
+func TestFullBackupPeriod(t *testing.T) {
+	tests := []struct {
+		frequency string
+		now       string
+		want      string
+	}{
+		{"daily", "2026-03-01T23:59:00Z", "2026-03-01"},
+		{"daily", "2026-03-02T00:00:00Z", "2026-03-02"},
+		{"weekly", "2026-03-01T12:00:00Z", "2026-W09"},
+		{"weekly", "2026-03-02T12:00:00Z", "2026-W10"},
+		{"", "2026-03-02T12:00:00Z", "2026-W10"},
+		{"weekly", "2027-01-01T12:00:00Z", "2026-W53"},
+		{"weekly", "2027-01-04T12:00:00Z", "2027-W01"},
+		{"monthly", "2026-01-31T12:00:00Z", "2026-01"},
+		{"monthly", "2026-02-01T12:00:00Z", "2026-02"},
+	}
+	for _, test := range tests {
+		now, err := time.Parse(time.RFC3339, test.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fullBackupPeriod(test.frequency, now); got != test.want {
+			t.Errorf("fullBackupPeriod(%q, %s) = %s, want %s", test.frequency, test.now, got, test.want)
+		}
+	}
+}
+
+func TestSelectBaseIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		latest   *volumeIndex
+		previous *volumeIndex
+		folder   string
+		period   string
+		want     string
+	}{
+		{
+			name:   "first run",
+			folder: "2026-03-02",
+		},
+		{
+			name:   "next folder",
+			latest: &volumeIndex{Folder: "2026-03-02", FullPeriod: "2026-W10"},
+			folder: "2026-03-03",
+			want:   "2026-03-02",
+		},
+		{
+			name:   "full period rollover",
+			latest: &volumeIndex{Folder: "2026-03-08", FullPeriod: "2026-W10"},
+			folder: "2026-03-09",
+			period: "2026-W11",
+		},
+		{
+			name:   "rerun of a full backup",
+			latest: &volumeIndex{Folder: "2026-03-03", Full: true, FullPeriod: "2026-W10"},
+			folder: "2026-03-03",
+		},
+		{
+			name:     "rerun of an incremental backup",
+			latest:   &volumeIndex{Folder: "2026-03-03", FullPeriod: "2026-W10"},
+			previous: &volumeIndex{Folder: "2026-03-02", FullPeriod: "2026-W10"},
+			folder:   "2026-03-03",
+			want:     "2026-03-02",
+		},
+		{
+			name:   "rerun without a previous index",
+			latest: &volumeIndex{Folder: "2026-03-03", FullPeriod: "2026-W10"},
+			folder: "2026-03-03",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var cfg config.Config
+			cfg.App.StateFolder = t.TempDir()
+			for name, index := range map[string]*volumeIndex{"latest": test.latest, "previous": test.previous} {
+				if index == nil {
+					continue
+				}
+				err := saveVolumeIndex(indexPath("def-vol", name, cfg), *index)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			period := test.period
+			if period == "" {
+				period = "2026-W10"
+			}
+			base, err := selectBaseIndex("def-vol", test.folder, period, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case test.want == "" && base != nil:
+				t.Errorf("base = %s, want a full backup", base.Folder)
+			case test.want != "" && (base == nil || base.Folder != test.want):
+				t.Errorf("base = %v, want %s", base, test.want)
+			}
+		})
+	}
+}
+
+func TestDiffVolumeIndex(t *testing.T) {
+	var cfg config.Config
+	cfg.App.ScriptsFolder = t.TempDir()
+	// The fake volume-index.sh prints the hashes of every changed file.
+	hashes := "hash-b2  ./b\nhash-c  ./c\nhash-e  ./e\n"
+	err := os.WriteFile(filepath.Join(cfg.App.ScriptsFolder, "hashes"), []byte(hashes), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(cfg.App.ScriptsFolder, "volume-index.sh"), []byte("#!/bin/sh\ncat \"$(dirname \"$0\")/hashes\"\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := &volumeIndex{Entries: map[string]script.VolumeIndexEntry{
+		"./a":    {Path: "./a", Size: 1, ModTime: 10, Inode: 1, Hash: "hash-a"},
+		"./b":    {Path: "./b", Size: 1, ModTime: 10, Inode: 2, Hash: "hash-b"},
+		"./c":    {Path: "./c", Size: 1, ModTime: 10, Inode: 3, Hash: "hash-c"},
+		"./d":    {Path: "./d", Size: 1, ModTime: 10, Inode: 4, Hash: "hash-d"},
+		"./link": {Path: "./link", Size: 1, ModTime: 10, Inode: 5, Symlink: true},
+	}}
+	entries := []script.VolumeIndexEntry{
+		{Path: "./a", Size: 1, ModTime: 10, Inode: 1},
+		{Path: "./b", Size: 2, ModTime: 20, Inode: 2},
+		{Path: "./c", Size: 1, ModTime: 20, Inode: 3},
+		{Path: "./e", Size: 1, ModTime: 20, Inode: 6},
+		{Path: "./link", Size: 1, ModTime: 20, Inode: 5, Symlink: true},
+	}
+
+	tests := []struct {
+		name    string
+		base    *volumeIndex
+		changed []string
+		deleted []string
+	}{
+		{"full", nil, []string{"./a", "./b", "./c", "./e", "./link"}, nil},
+		{"incremental", base, []string{"./b", "./e", "./link"}, []string{"./d"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			next := make(map[string]script.VolumeIndexEntry)
+			changed, deleted, err := diffVolumeIndex("vol", test.base, entries, next, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(changed, test.changed) {
+				t.Errorf("changed = %v, want %v", changed, test.changed)
+			}
+			if !slices.Equal(deleted, test.deleted) {
+				t.Errorf("deleted = %v, want %v", deleted, test.deleted)
+			}
+			if len(next) != len(entries) || next["./b"].Hash != "hash-b2" || next["./c"].Hash != "hash-c" {
+				t.Errorf("next index = %v", next)
+			}
+			if test.base != nil && next["./a"].Hash != "hash-a" {
+				t.Errorf("unchanged file lost its hash: %v", next["./a"])
+			}
+		})
+	}
+}
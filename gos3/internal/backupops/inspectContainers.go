@@ -0,0 +1,51 @@
+package backupops
+
+import (
+	"fmt"
+	"gos3/internal/config"
+	"gos3/internal/manifest"
+	"os/exec"
+	"strings"
+)
+
+// Containers that cannot be inspected are listed by name only.
+func inspectContainers(containers []string, cfg config.Config) []manifest.Container {
+	result := make([]manifest.Container, 0, len(containers))
+	for _, name := range containers {
+		container := manifest.Container{Name: name}
+
+		output, err := exec.Command("docker", "inspect", "--format",
+			`{{.Config.Image}}|{{.Image}}|{{index .Config.Labels "org.opencontainers.image.version"}}`, name).Output()
+		if err != nil {
+			cfg.Logger().Warn("Failed to inspect container", "container", name, "error", err)
+			result = append(result, container)
+			continue
+		}
+
+		fields := strings.SplitN(strings.TrimSpace(string(output)), "|", 3)
+		if len(fields) == 3 {
+			container.Image = fields[0]
+			container.ImageID = fields[1]
+			container.Version = strings.TrimSuffix(fields[2], "<no value>")
+		}
+
+		if container.ImageID != "" {
+			digests, err := imageRepoDigests(container.ImageID)
+			if err != nil {
+				cfg.Logger().Warn("Failed to inspect image of container", "container", name, "error", err)
+			}
+			container.RepoDigests = digests
+		}
+
+		result = append(result, container)
+	}
+	return result
+}
+
+func imageRepoDigests(imageID string) ([]string, error) {
+	output, err := exec.Command("docker", "image", "inspect", "--format", `{{join .RepoDigests " "}}`, imageID).Output()
+	if err != nil {
+		return nil, fmt.Errorf("docker image inspect %s: %w", imageID, err)
+	}
+	return strings.Fields(string(output)), nil
+}
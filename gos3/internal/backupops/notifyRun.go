@@ -0,0 +1,41 @@
+package backupops
+
+import (
+	"errors"
+	"gos3/internal/config"
+	"gos3/internal/notify"
+	"time"
+)
+
+func notifyRun(result BackupResult, err error, duration time.Duration, cfg config.Config) {
+	event := notify.Event{
+		Definition:    result.Definition,
+		Date:          result.DateFolder,
+		Duration:      duration,
+		Bytes:         result.Bytes,
+		FailedTargets: result.FailedTargets(),
+	}
+	for _, target := range result.Targets {
+		if target.Err == nil {
+			event.Targets = append(event.Targets, target.Target)
+		}
+	}
+
+	switch {
+	case err != nil:
+		event.Type = notify.EventRunFailed
+		event.Error = err.Error()
+	case result.Status() == StatusDegraded:
+		event.Type = notify.EventRunPartial
+		var errs []error
+		for _, target := range result.Targets {
+			if target.Err != nil {
+				errs = append(errs, target.Err)
+			}
+		}
+		event.Error = errors.Join(errs...).Error()
+	default:
+		event.Type = notify.EventRunSucceeded
+	}
+	notify.Send(cfg, event)
+}
@@ -0,0 +1,48 @@
+package backupops
+
+import (
+	"gos3/internal/config"
+	"gos3/internal/heartbeat"
+	"gos3/internal/logging"
+)
+
+// The tail sent with a failure ping; healthchecks.io keeps 100 KB of a body.
+const (
+	heartbeatTailLines = 200
+	heartbeatTailBytes = 64 * 1024
+)
+
+// startHeartbeat sends the start ping of def and returns cfg with its log also
+// kept in a tail for the failure ping.
+func startHeartbeat(def config.BackupDefinition, cfg config.Config) (config.Config, *logging.Tail) {
+	if def.HeartbeatURL == "" {
+		return cfg, nil
+	}
+	heartbeat.Ping(def.HeartbeatURL, heartbeat.Start, "", cfg)
+
+	tail := logging.NewTail(heartbeatTailLines, heartbeatTailBytes)
+	cfg.Log = logging.Tee(cfg.Logger(), tail)
+	return cfg, tail
+}
+
+// A run that missed a target sends the failure ping.
+func finishHeartbeat(def config.BackupDefinition, tail *logging.Tail, succeeded bool, cfg config.Config) {
+	if def.HeartbeatURL == "" {
+		return
+	}
+	if succeeded {
+		heartbeat.Ping(def.HeartbeatURL, heartbeat.Success, "", cfg)
+		return
+	}
+	heartbeat.Ping(def.HeartbeatURL, heartbeat.Fail, tail.String(), cfg)
+}
+
+// FailHeartbeats sends a failure ping with reason for every definition with
+// a heartbeat URL, for runs that end before the definitions are reached.
+func FailHeartbeats(definitions []config.BackupDefinition, reason string, cfg config.Config) {
+	for _, def := range definitions {
+		if def.HeartbeatURL != "" {
+			heartbeat.Ping(def.HeartbeatURL, heartbeat.Fail, reason, cfg)
+		}
+	}
+}
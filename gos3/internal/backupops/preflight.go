@@ -0,0 +1,127 @@
+package backupops
+
+import (
+	"errors"
+	"fmt"
+	"gos3/internal/config"
+	"gos3/internal/manifest"
+	"gos3/internal/s3"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// defaultSpaceFactor is the free space needed per byte of volume data when
+// app.preflight.spaceFactor is not set.
+const defaultSpaceFactor = 2.0
+
+// preflight checks, while the containers of def still run, that the backup can
+// complete. Failing targets are only logged; the error lists every failed check.
+func preflight(def config.BackupDefinition, cfg config.Config) error {
+	logger := cfg.Logger().With("step", "preflight")
+	start := time.Now()
+	var problems []string
+
+	var total int64
+	for _, volume := range def.Volumes {
+		size, err := estimateVolumeSize(volume)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		logger.Debug("Estimated volume size", "volume", volume, "bytes", size)
+		total += size
+	}
+
+	factor := cfg.App.Preflight.SpaceFactor
+	if factor <= 0 {
+		factor = defaultSpaceFactor
+	}
+	needed := int64(float64(total) * factor)
+	available, err := availableSpace(cfg.App.LocalBackupFolder)
+	switch {
+	case err != nil:
+		problems = append(problems, fmt.Sprintf("failed to read free space of %s: %v", cfg.App.LocalBackupFolder, err))
+	case available < needed:
+		problems = append(problems, fmt.Sprintf("not enough space in %s: %s needed (%s of volume data x %.1f), %s available",
+			cfg.App.LocalBackupFolder, s3.FormatSize(needed), s3.FormatSize(total), factor, s3.FormatSize(available)))
+	}
+
+	_, err = manifest.KeyFingerprint(cfg.App.PublicKeyFile)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("public key is not usable: %v", err))
+	}
+
+	var targetProblems []string
+	for _, target := range cfg.TargetNames(def) {
+		targetCfg, err := cfg.ForTarget(target)
+		if err == nil {
+			err = s3.ProbeTarget(targetCfg).Err()
+		}
+		if err != nil {
+			logger.Warn("Target failed the preflight check", "target", target, "error", err)
+			targetProblems = append(targetProblems, err.Error())
+		}
+	}
+	if len(targetProblems) == len(cfg.TargetNames(def)) {
+		problems = append(problems, targetProblems...)
+	}
+
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			logger.Error("Preflight check failed", "problem", problem)
+		}
+		return fmt.Errorf("preflight failed, containers were not stopped:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+
+	logger.Info("Preflight checks passed", "volume_bytes", total, "needed_bytes", needed, "available_bytes", available, "duration", time.Since(start))
+	return nil
+}
+
+// A missing volume is an error instead of being created empty by docker run.
+func estimateVolumeSize(volume string) (int64, error) {
+	if isVolumePath(volume) {
+		if _, err := os.Stat(volume); err != nil {
+			return 0, fmt.Errorf("volume path %s is not accessible: %w", volume, err)
+		}
+	} else if err := exec.Command("docker", "volume", "inspect", volume).Run(); err != nil {
+		return 0, fmt.Errorf("docker volume %s does not exist or docker is not reachable: %w", volume, err)
+	}
+
+	output, err := exec.Command("docker", "run", "--rm", "-v", volume+":/volume:ro", "alpine", "du", "-sb", "/volume").Output()
+	// du exits non-zero when files vanish while it runs; the total on its
+	// last line is still a good estimate.
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if fields := strings.Fields(lines[len(lines)-1]); len(fields) == 2 {
+		if size, parseErr := strconv.ParseInt(fields[0], 10, 64); parseErr == nil {
+			return size, nil
+		}
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	if err == nil {
+		err = fmt.Errorf("unexpected du output %q", strings.TrimSpace(string(output)))
+	}
+	return 0, fmt.Errorf("failed to measure volume %s: %w", volume, err)
+}
+
+// availableSpace returns the bytes an unprivileged user can still write.
+func availableSpace(folder string) (int64, error) {
+	err := os.MkdirAll(folder, 0755)
+	if err != nil {
+		return 0, err
+	}
+
+	var stat syscall.Statfs_t
+	err = syscall.Statfs(folder, &stat)
+	if err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
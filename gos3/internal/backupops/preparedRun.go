@@ -0,0 +1,101 @@
+package backupops
+
+import (
+	"encoding/json"
+	"fmt"
+	"gos3/internal/config"
+	"gos3/internal/manifest"
+	"os"
+	"path/filepath"
+)
+
+// preparedRun is an encrypted backup whose upload reached no target. It is
+// kept per definition, so a retry in the same date folder uploads it again
+// instead of archiving anew and resumes its multipart uploads.
+type preparedRun struct {
+	DateFolder string                 `json:"dateFolder"`
+	Run        manifest.Definition    `json:"run"`
+	Indexes    map[string]volumeIndex `json:"indexes"`
+}
+
+func preparedRunFolder(def config.BackupDefinition, cfg config.Config) string {
+	return filepath.Join(cfg.App.StateFolder, "prepared", def.Name)
+}
+
+// loadPreparedRun discards a prepared run of another date folder.
+func loadPreparedRun(def config.BackupDefinition, dateSubfolder string, cfg config.Config) (*preparedRun, error) {
+	folder := preparedRunFolder(def, cfg)
+	data, err := os.ReadFile(folder + ".json")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prepared backup: %w", err)
+	}
+
+	var prepared preparedRun
+	err = json.Unmarshal(data, &prepared)
+	if err != nil || prepared.DateFolder != dateSubfolder {
+		cfg.Logger().Info("Discarding backup prepared by a failed run", "date", prepared.DateFolder)
+		return nil, discardPreparedRun(def, cfg)
+	}
+	if _, err := os.Stat(folder); err != nil {
+		return nil, discardPreparedRun(def, cfg)
+	}
+	return &prepared, nil
+}
+
+func (p preparedRun) pendingIndexes() []pendingIndex {
+	pending := make([]pendingIndex, 0, len(p.Indexes))
+	for key, index := range p.Indexes {
+		pending = append(pending, pendingIndex{key: key, index: index})
+	}
+	return pending
+}
+
+// keepPreparedRun moves localFolder out of the local backup folder, which the
+// next definition cleans.
+func keepPreparedRun(def config.BackupDefinition, localFolder, dateSubfolder string, run manifest.Definition, pending []pendingIndex, cfg config.Config) error {
+	folder := preparedRunFolder(def, cfg)
+	if localFolder != folder {
+		err := os.RemoveAll(folder)
+		if err != nil {
+			return fmt.Errorf("failed to remove old prepared backup: %w", err)
+		}
+		err = os.MkdirAll(filepath.Dir(folder), 0700)
+		if err != nil {
+			return fmt.Errorf("failed to create prepared backup folder: %w", err)
+		}
+		err = os.Rename(localFolder, folder)
+		if err != nil {
+			return fmt.Errorf("failed to move prepared backup: %w", err)
+		}
+		err = os.MkdirAll(localFolder, 0755)
+		if err != nil {
+			return fmt.Errorf("failed to recreate local backup folder: %w", err)
+		}
+	}
+
+	prepared := preparedRun{DateFolder: dateSubfolder, Run: run, Indexes: make(map[string]volumeIndex, len(pending))}
+	for _, p := range pending {
+		prepared.Indexes[p.key] = p.index
+	}
+	data, err := json.Marshal(prepared)
+	if err != nil {
+		return fmt.Errorf("failed to encode prepared backup: %w", err)
+	}
+	err = os.WriteFile(folder+".json", data, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write prepared backup: %w", err)
+	}
+	return nil
+}
+
+func discardPreparedRun(def config.BackupDefinition, cfg config.Config) error {
+	folder := preparedRunFolder(def, cfg)
+	err := os.Remove(folder + ".json")
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove prepared backup: %w", err)
+	}
+	return os.RemoveAll(folder)
+}
@@ -0,0 +1,45 @@
+package backupops
+
+import (
+	"gos3/internal/config"
+	"gos3/internal/history"
+	"time"
+)
+
+func recordHistory(runID string, result BackupResult, err error, startedAt time.Time, cfg config.Config) {
+	record := history.Record{
+		RunID:      runID,
+		Definition: result.Definition,
+		Status:     result.Status(),
+		StartedAt:  startedAt.UTC(),
+		FinishedAt: time.Now().UTC(),
+		Date:       result.DateFolder,
+		Bytes:      result.Bytes,
+		Steps:      history.Steps{DowntimeSeconds: result.Downtime.Seconds()},
+	}
+	if result.Manifest != nil {
+		record.KeyFingerprint = result.Manifest.KeyFingerprint
+	}
+	if result.Timings != nil {
+		record.Steps.ArchiveSeconds = result.Timings.ArchiveSeconds
+		record.Steps.EncryptSeconds = result.Timings.EncryptSeconds
+		record.Steps.SplitSeconds = result.Timings.SplitSeconds
+	}
+	for _, target := range result.Targets {
+		entry := history.Target{Name: target.Target, DurationSeconds: target.Duration.Seconds()}
+		if target.Err != nil {
+			entry.Error = target.Err.Error()
+		}
+		record.Targets = append(record.Targets, entry)
+		record.Steps.UploadSeconds += entry.DurationSeconds
+	}
+	if err != nil {
+		record.Status = StatusFailed
+		record.Error = err.Error()
+	}
+
+	err = history.Append(record, cfg)
+	if err != nil {
+		cfg.Logger().Warn("Failed to record run history", "error", err)
+	}
+}
@@ -0,0 +1,42 @@
+package backupops
+
+import (
+	"gos3/internal/catalog"
+	"gos3/internal/config"
+	"gos3/internal/manifest"
+	"gos3/internal/s3"
+	"os"
+	"time"
+)
+
+// Failing to update the catalog is not a backup failure.
+func recordRun(run manifest.Definition, target, dateSubfolder string, runErr error, cfg config.Config) {
+	host, _ := os.Hostname()
+	entry := catalog.Run{
+		Target:     target,
+		Date:       dateSubfolder,
+		Status:     catalog.StatusSuccess,
+		Host:       host,
+		Definition: run,
+	}
+	if entry.Definition.FinishedAt.IsZero() {
+		entry.Definition.FinishedAt = time.Now().UTC()
+	}
+	if runErr != nil {
+		entry.Status = catalog.StatusFailed
+		entry.Error = runErr.Error()
+		// Nothing of a failed run can be downloaded.
+		entry.Definition.Items = nil
+	} else {
+		entry.Size = s3.StoredSize(run)
+	}
+
+	cat, err := catalog.Open(cfg)
+	if err == nil {
+		err = cat.Record(entry)
+		cat.Close()
+	}
+	if err != nil {
+		cfg.Logger().Warn("Failed to record backup in the catalog", "target", target, "error", err)
+	}
+}
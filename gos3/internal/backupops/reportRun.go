@@ -0,0 +1,34 @@
+package backupops
+
+import (
+	"gos3/internal/config"
+	"gos3/internal/report"
+	"time"
+)
+
+func reportRun(runID string, result BackupResult, err error, startedAt time.Time, cfg config.Config) {
+	if cfg.Report.URL == "" {
+		return
+	}
+
+	r := report.New(runID, result.Definition, cfg)
+	r.Status = result.Status()
+	r.Date = result.DateFolder
+	r.StartedAt = startedAt.UTC()
+	r.FinishedAt = time.Now().UTC()
+	r.Bytes = result.Bytes
+	r.Manifest = result.Manifest
+	for _, target := range result.Targets {
+		t := report.Target{Name: target.Target, DurationSeconds: target.Duration.Seconds()}
+		if target.Err != nil {
+			t.Error = target.Err.Error()
+		}
+		r.Targets = append(r.Targets, t)
+	}
+	if err != nil {
+		r.Status = StatusFailed
+		r.Error = err.Error()
+	}
+
+	report.Submit(r, cfg)
+}
@@ -0,0 +1,50 @@
+package backupops
+
+import (
+	"fmt"
+	"gos3/internal/config"
+	"gos3/internal/manifest"
+	"gos3/internal/metrics"
+	"gos3/internal/s3"
+	"time"
+)
+
+// A failing target does not stop the upload to the others.
+func uploadToTargets(localFolder, dateSubfolder string, targets []string, run manifest.Definition, cfg config.Config) []TargetResult {
+	results := make([]TargetResult, 0, len(targets))
+	for _, target := range targets {
+		start := time.Now()
+		result := TargetResult{Target: target}
+		logger := cfg.Logger().With("step", "upload", "target", target)
+
+		targetCfg, err := cfg.ForTarget(target)
+		if err == nil {
+			logger.Info("Uploading backup")
+			err = s3.UploadFolderToS3Subfolder(localFolder, targetCfg.S3.BackupFolder, dateSubfolder, targetCfg)
+		}
+
+		targetRun := run.Clone()
+		targetRun.Target = target
+		if err == nil {
+			targetRun.Timings.UploadSeconds = time.Since(start).Seconds()
+			targetRun.FinishedAt = time.Now().UTC()
+			err = manifest.Publish(targetRun, dateSubfolder, targetCfg)
+			if err != nil {
+				err = fmt.Errorf("failed to publish manifest: %w", err)
+			}
+		}
+		recordRun(targetRun, target, dateSubfolder, err, cfg)
+
+		result.Err = err
+		result.Duration = time.Since(start)
+		if err != nil {
+			logger.Error("Upload failed", "duration", result.Duration, "error", err)
+		} else {
+			logger.Info("Upload completed", "bytes", s3.StoredSize(run), "duration", result.Duration)
+			metrics.RecordUpload(run.Name, target, s3.StoredSize(run), result.Duration)
+			metrics.RecordSuccess(run.Name, target, targetRun.FinishedAt)
+		}
+		results = append(results, result)
+	}
+	return results
+}
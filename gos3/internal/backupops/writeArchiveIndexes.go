@@ -0,0 +1,52 @@
+package backupops
+
+import (
+	"fmt"
+	"gos3/internal/archive"
+	"gos3/internal/config"
+	"gos3/internal/script"
+	"os"
+	"path/filepath"
+)
+
+// A failed index only costs a download when listing, so it does not fail the
+// backup.
+func writeArchiveIndexes(archives []volumeArchive, dateSubfolder string, cfg config.Config) {
+	for _, volumeArchive := range archives {
+		if volumeArchive.Err != nil {
+			continue
+		}
+
+		logger := cfg.Logger().With("volume", volumeArchive.Volume, "step", "index")
+		archivePath := filepath.Join(cfg.App.LocalBackupFolder, volumeArchive.FileName)
+		deletedList := ""
+		if base, incremental, _, ok := script.ParseArchiveName(volumeArchive.FileName); ok && incremental {
+			deletedList = filepath.Join(cfg.App.LocalBackupFolder, base+script.IncrementalDeletedSuffix)
+		}
+
+		entries, err := archive.BuildIndex(archivePath, deletedList)
+		if err != nil {
+			logger.Warn("Failed to index archive", "file", volumeArchive.FileName, "error", err)
+			os.Remove(archivePath + archive.IndexSuffix)
+			continue
+		}
+		logger.Info("Indexed archive", "file", volumeArchive.FileName, "entries", len(entries))
+
+		err = cacheArchiveIndex(archivePath+archive.IndexSuffix, archive.CachedIndexPath(cfg.App.StateFolder, dateSubfolder, volumeArchive.FileName))
+		if err != nil {
+			logger.Warn("Failed to keep a local copy of the archive index", "file", volumeArchive.FileName, "error", err)
+		}
+	}
+}
+
+func cacheArchiveIndex(source, destination string) error {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(destination), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create index cache folder: %w", err)
+	}
+	return os.WriteFile(destination, data, 0644)
+}
@@ -0,0 +1,199 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gos3/internal/config"
+	"gos3/internal/manifest"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// FileName is the catalog database in the state folder.
+const FileName = "catalog.db"
+
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+var (
+	runsBucket    = []byte("runs")
+	targetsBucket = []byte("targets")
+)
+
+// Catalog records every backup run per target, date and definition so
+// questions about past backups can be answered without scanning the bucket.
+type Catalog struct {
+	db *bolt.DB
+}
+
+// Run is one run of a definition against one target. Failed runs only carry
+// what was known when they failed.
+type Run struct {
+	Target string `json:"target"`
+	Date   string `json:"date"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Host   string `json:"host,omitempty"`
+	// Legacy runs come from date folders without a manifest; only their
+	// stored size is known.
+	Legacy     bool                `json:"legacy,omitempty"`
+	Size       int64               `json:"size"`
+	Definition manifest.Definition `json:"definition"`
+}
+
+// Duration is zero for runs without timestamps.
+func (r Run) Duration() time.Duration {
+	if r.Definition.StartedAt.IsZero() || r.Definition.FinishedAt.IsZero() {
+		return 0
+	}
+	return r.Definition.FinishedAt.Sub(r.Definition.StartedAt)
+}
+
+// Volume returns the archive entry of volume, or nil.
+func (r Run) Volume(volume string) *manifest.Volume {
+	for i := range r.Definition.Volumes {
+		if r.Definition.Volumes[i].Volume == volume {
+			return &r.Definition.Volumes[i]
+		}
+	}
+	return nil
+}
+
+// coverage marks a target whose runs were rebuilt from its bucket.
+type coverage struct {
+	RebuiltAt time.Time `json:"rebuiltAt"`
+}
+
+// Open opens the catalog below the state folder, creating it on first use.
+// It waits a few seconds for another gos3 process holding it.
+func Open(cfg config.Config) (*Catalog, error) {
+	err := os.MkdirAll(cfg.App.StateFolder, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state folder: %w", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(cfg.App.StateFolder, FileName), 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open catalog: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{runsBucket, targetsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize catalog: %w", err)
+	}
+
+	return &Catalog{db: db}, nil
+}
+
+func (c *Catalog) Close() error {
+	return c.db.Close()
+}
+
+// Record stores a run, replacing an earlier record of the same run.
+func (c *Catalog) Record(run Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode catalog run: %w", err)
+	}
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(runsBucket).Put(runKey(run), data)
+	})
+}
+
+// Covers reports whether the catalog was rebuilt from target, so its dates
+// and items can stand in for a bucket listing.
+func (c *Catalog) Covers(target string) (bool, error) {
+	var covered bool
+	err := c.db.View(func(tx *bolt.Tx) error {
+		covered = tx.Bucket(targetsBucket).Get([]byte(target)) != nil
+		return nil
+	})
+	return covered, err
+}
+
+// ReplaceTarget replaces the successful runs of target with runs read from
+// its bucket and marks the target as covered. Failed runs are kept.
+func (c *Catalog) ReplaceTarget(target string, runs []Run) error {
+	marker, err := json.Marshal(coverage{RebuiltAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(runsBucket)
+		err := deletePrefix(bucket, keyPrefix(target), func(run Run) bool {
+			return run.Status == StatusSuccess
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, run := range runs {
+			data, err := json.Marshal(run)
+			if err != nil {
+				return err
+			}
+			err = bucket.Put(runKey(run), data)
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(targetsBucket).Put([]byte(target), marker)
+	})
+}
+
+// DeleteDate forgets every run of a pruned date folder.
+func (c *Catalog) DeleteDate(target, date string) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return deletePrefix(tx.Bucket(runsBucket), keyPrefix(target, date), nil)
+	})
+}
+
+// runKey sorts runs by target, date, definition and start time.
+func runKey(run Run) []byte {
+	return keyPrefix(run.Target, run.Date, run.Definition.Name, run.Definition.StartedAt.UTC().Format(time.RFC3339Nano))
+}
+
+func keyPrefix(parts ...string) []byte {
+	var key bytes.Buffer
+	for _, part := range parts {
+		key.WriteString(part)
+		key.WriteByte(0)
+	}
+	return key.Bytes()
+}
+
+func deletePrefix(bucket *bolt.Bucket, prefix []byte, match func(Run) bool) error {
+	var keys [][]byte
+	cursor := bucket.Cursor()
+	for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+		if match != nil {
+			var run Run
+			if err := json.Unmarshal(value, &run); err != nil || !match(run) {
+				continue
+			}
+		}
+		keys = append(keys, append([]byte(nil), key...))
+	}
+	for _, key := range keys {
+		if err := bucket.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Filter selects runs; zero fields match everything.
+type Filter struct {
+	Target     string
+	Date       string
+	Definition string
+	Volume     string
+	Status     string
+	Since      time.Time
+}
+
+func (f Filter) matches(run Run) bool {
+	if f.Date != "" && run.Date != f.Date {
+		return false
+	}
+	if f.Definition != "" && run.Definition.Name != f.Definition {
+		return false
+	}
+	if f.Volume != "" && run.Volume(f.Volume) == nil {
+		return false
+	}
+	if f.Status != "" && run.Status != f.Status {
+		return false
+	}
+	if !f.Since.IsZero() && run.Definition.StartedAt.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// Runs returns the matching runs, newest first.
+func (c *Catalog) Runs(filter Filter) ([]Run, error) {
+	var prefix []byte
+	if filter.Target != "" && filter.Date != "" {
+		prefix = keyPrefix(filter.Target, filter.Date)
+	} else if filter.Target != "" {
+		prefix = keyPrefix(filter.Target)
+	}
+
+	var runs []Run
+	err := c.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(runsBucket).Cursor()
+		for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+			var run Run
+			err := json.Unmarshal(value, &run)
+			if err != nil {
+				return fmt.Errorf("failed to decode catalog run %q: %w", key, err)
+			}
+			if filter.matches(run) {
+				runs = append(runs, run)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		if runs[i].Date != runs[j].Date {
+			return runs[i].Date > runs[j].Date
+		}
+		return runs[i].Definition.StartedAt.After(runs[j].Definition.StartedAt)
+	})
+	return runs, nil
+}
+
+// Dates returns the date folders of target holding a successful run, newest
+// first like a bucket listing.
+func (c *Catalog) Dates(target string) ([]string, error) {
+	runs, err := c.Runs(Filter{Target: target, Status: StatusSuccess})
+	if err != nil {
+		return nil, err
+	}
+
+	var dates []string
+	for _, run := range runs {
+		if len(dates) == 0 || dates[len(dates)-1] != run.Date {
+			dates = append(dates, run.Date)
+		}
+	}
+	return dates, nil
+}
+
+// DateRuns returns the latest successful run of every definition in a date
+// folder, which is what its manifest describes.
+func (c *Catalog) DateRuns(target, date string) ([]Run, error) {
+	runs, err := c.Runs(Filter{Target: target, Date: date, Status: StatusSuccess})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var latest []Run
+	for _, run := range runs {
+		if seen[run.Definition.Name] {
+			continue
+		}
+		seen[run.Definition.Name] = true
+		latest = append(latest, run)
+	}
+	sort.Slice(latest, func(i, j int) bool {
+		return latest[i].Definition.Name < latest[j].Definition.Name
+	})
+	return latest, nil
+}
@@ -0,0 +1,59 @@
+package catalog
+
+import "time"
+
+// RunSummary is the part of a run `gos3 catalog query` reports.
+type RunSummary struct {
+	Date         string          `json:"date"`
+	Definition   string          `json:"definition"`
+	Target       string          `json:"target"`
+	Status       string          `json:"status"`
+	Error        string          `json:"error,omitempty"`
+	Host         string          `json:"host,omitempty"`
+	Legacy       bool            `json:"legacy,omitempty"`
+	StartedAt    time.Time       `json:"startedAt"`
+	Seconds      float64         `json:"seconds"`
+	Size         int64           `json:"size"`
+	OriginalSize int64           `json:"originalSize"`
+	Volumes      []VolumeSummary `json:"volumes,omitempty"`
+}
+
+type VolumeSummary struct {
+	Volume       string  `json:"volume"`
+	Archive      string  `json:"archive"`
+	Incremental  bool    `json:"incremental,omitempty"`
+	Size         int64   `json:"size"`
+	OriginalSize int64   `json:"originalSize"`
+	Seconds      float64 `json:"seconds"`
+}
+
+// Summarize reports a run, limited to one volume when volume is set.
+func Summarize(run Run, volume string) RunSummary {
+	summary := RunSummary{
+		Date:       run.Date,
+		Definition: run.Definition.Name,
+		Target:     run.Target,
+		Status:     run.Status,
+		Error:      run.Error,
+		Host:       run.Host,
+		Legacy:     run.Legacy,
+		StartedAt:  run.Definition.StartedAt,
+		Seconds:    run.Duration().Seconds(),
+		Size:       run.Size,
+	}
+	for _, v := range run.Definition.Volumes {
+		summary.OriginalSize += v.OriginalSize
+		if volume != "" && v.Volume != volume {
+			continue
+		}
+		summary.Volumes = append(summary.Volumes, VolumeSummary{
+			Volume:       v.Volume,
+			Archive:      v.Archive,
+			Incremental:  v.Incremental,
+			Size:         v.CompressedSize,
+			OriginalSize: v.OriginalSize,
+			Seconds:      v.Seconds,
+		})
+	}
+	return summary
+}
@@ -0,0 +1,238 @@
+package compliance
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"gos3/internal/backupops"
+	"gos3/internal/config"
+	"gos3/internal/history"
+	"gos3/internal/manifest"
+	"gos3/internal/s3"
+)
+
+// Build collects the report for the period from (inclusive) to (exclusive)
+// from the history of this host.
+func Build(from, to time.Time, checkBucket bool, cfg config.Config) (Report, error) {
+	host := cfg.Report.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	report := Report{
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now(),
+		Host:        host,
+		Version:     config.Version,
+	}
+
+	records, err := history.Read(history.Filter{}, cfg)
+	if err != nil {
+		return report, err
+	}
+	// Read returns the newest run first; the gaps are measured forwards.
+	slices.Reverse(records)
+	report.Definitions = summarizeDefinitions(records, from, to, cfg)
+	report.Keys = summarizeKeys(records, from, to)
+
+	fingerprint, err := manifest.KeyFingerprint(cfg.App.PublicKeyFile)
+	if err != nil {
+		cfg.Logger().Warn("Failed to read the public key fingerprint", "file", cfg.App.PublicKeyFile, "error", err)
+	}
+	report.CurrentKeyFingerprint = fingerprint
+	for i := range report.Keys {
+		report.Keys[i].Current = fingerprint != "" && report.Keys[i].Fingerprint == fingerprint
+	}
+
+	verifications, err := history.ReadVerifications(cfg)
+	if err != nil {
+		return report, err
+	}
+	report.Verification = summarizeVerifications(verifications, from, to)
+
+	restoreTests, err := backupops.ReadRestoreTestResults(cfg)
+	if err != nil {
+		return report, err
+	}
+	report.RestoreTests = summarizeRestoreTests(restoreTests, from, to)
+
+	if checkBucket {
+		report.Storage = measureStorage(cfg)
+	}
+	return report, nil
+}
+
+func within(t, from, to time.Time) bool {
+	return !t.Before(from) && t.Before(to)
+}
+
+// summarizeDefinitions expects records oldest first. Every configured
+// definition is listed, also when it never ran in the period.
+func summarizeDefinitions(records []history.Record, from, to time.Time, cfg config.Config) []DefinitionSummary {
+	var names []string
+	for _, def := range cfg.BackupDefinitions {
+		names = append(names, def.Name)
+	}
+	for _, record := range records {
+		if within(record.FinishedAt, from, to) && !slices.Contains(names, record.Definition) {
+			names = append(names, record.Definition)
+		}
+	}
+
+	end := to
+	if now := time.Now(); now.Before(end) {
+		end = now
+	}
+
+	summaries := make([]DefinitionSummary, 0, len(names))
+	for _, name := range names {
+		summary := DefinitionSummary{Name: name}
+		lastSuccess := from
+		var totalSeconds float64
+		gap := func(until time.Time) {
+			if length := until.Sub(lastSuccess).Seconds(); length > summary.MaxGapSeconds {
+				summary.MaxGapSeconds = length
+				summary.MaxGapFrom = lastSuccess
+				summary.MaxGapTo = until
+			}
+		}
+
+		for _, record := range records {
+			if record.Definition != name {
+				continue
+			}
+			if record.FinishedAt.Before(from) {
+				if record.Status == backupops.StatusSuccess {
+					lastSuccess = record.FinishedAt
+					summary.LastSuccess = record.FinishedAt
+				}
+				continue
+			}
+			if !record.FinishedAt.Before(to) {
+				break
+			}
+
+			summary.Runs++
+			summary.BytesUploaded += record.Bytes
+			totalSeconds += record.Duration().Seconds()
+			switch record.Status {
+			case backupops.StatusSuccess:
+				summary.Succeeded++
+				gap(record.FinishedAt)
+				lastSuccess = record.FinishedAt
+				summary.LastSuccess = record.FinishedAt
+			case backupops.StatusDegraded:
+				summary.Degraded++
+			default:
+				summary.Failed++
+			}
+			if record.Status != backupops.StatusSuccess {
+				summary.Failures = append(summary.Failures, Failure{Time: record.FinishedAt, Status: record.Status, Error: record.Error})
+			}
+		}
+		gap(end)
+
+		if summary.Runs > 0 {
+			summary.SuccessRate = float64(summary.Succeeded) / float64(summary.Runs)
+			summary.AverageSeconds = totalSeconds / float64(summary.Runs)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+func summarizeKeys(records []history.Record, from, to time.Time) []KeyUse {
+	var keys []KeyUse
+	index := make(map[string]int)
+	for _, record := range records {
+		if record.KeyFingerprint == "" || !within(record.FinishedAt, from, to) {
+			continue
+		}
+		i, found := index[record.KeyFingerprint]
+		if !found {
+			i = len(keys)
+			index[record.KeyFingerprint] = i
+			keys = append(keys, KeyUse{Fingerprint: record.KeyFingerprint, FirstUsed: record.FinishedAt})
+		}
+		keys[i].Runs++
+		keys[i].LastUsed = record.FinishedAt
+		if !slices.Contains(keys[i].Definitions, record.Definition) {
+			keys[i].Definitions = append(keys[i].Definitions, record.Definition)
+		}
+	}
+	return keys
+}
+
+func summarizeVerifications(verifications []history.Verification, from, to time.Time) VerificationSummary {
+	var summary VerificationSummary
+	for _, verification := range verifications {
+		if !within(verification.Time, from, to) {
+			continue
+		}
+		summary.Runs++
+		summary.ItemsPassed += verification.Passed
+		summary.ItemsFailed += verification.Failed
+		summary.Last = verification.Time
+		if verification.Failed > 0 {
+			summary.Failures = append(summary.Failures, verification)
+		}
+	}
+	return summary
+}
+
+func summarizeRestoreTests(results []backupops.RestoreTestResult, from, to time.Time) []RestoreTestSummary {
+	var summaries []RestoreTestSummary
+	index := make(map[string]int)
+	for _, result := range results {
+		if !within(result.StartedAt, from, to) {
+			continue
+		}
+		i, found := index[result.Name]
+		if !found {
+			i = len(summaries)
+			index[result.Name] = i
+			summaries = append(summaries, RestoreTestSummary{Name: result.Name})
+		}
+		summary := &summaries[i]
+		summary.Definition = result.Definition
+		summary.Runs++
+		if result.Passed {
+			summary.Passed++
+		}
+		summary.LastRun = result.StartedAt
+		summary.LastDate = result.Date
+		summary.LastPassed = result.Passed
+		summary.LastError = result.Error
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries
+}
+
+// A target that cannot be read is listed with its error instead of failing the
+// report.
+func measureStorage(cfg config.Config) []TargetStorage {
+	var targets []TargetStorage
+	for _, name := range cfg.AllTargetNames() {
+		target := TargetStorage{Target: name}
+		targetCfg, err := cfg.ForTarget(name)
+		if err == nil {
+			var sizes map[string]int64
+			sizes, err = s3.BackupFolderSizes(targetCfg)
+			for _, size := range sizes {
+				target.Bytes += size
+			}
+			target.Folders = len(sizes)
+		}
+		if err != nil {
+			cfg.Logger().Warn("Failed to measure storage of target", "target", name, "error", err)
+			target.Error = fmt.Sprint(err)
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
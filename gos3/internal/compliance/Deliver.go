@@ -0,0 +1,77 @@
+package compliance
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"gos3/internal/config"
+	"gos3/internal/notify"
+	"gos3/internal/storage"
+)
+
+// DefaultPrefix is the bucket folder uploaded reports are stored in.
+const DefaultPrefix = "reports"
+
+// Email mails a rendered report through the smtp notification channel
+// named channelName.
+func Email(report Report, content []byte, format, channelName string, cfg config.Config) error {
+	index := slices.IndexFunc(cfg.Notifications.Channels, func(channel config.NotificationChannel) bool {
+		return channel.Name == channelName
+	})
+	if index < 0 {
+		return fmt.Errorf("unknown notification channel: %s", channelName)
+	}
+	channel := cfg.Notifications.Channels[index]
+	if channel.Type != "smtp" {
+		return fmt.Errorf("notification channel %s is of type %s, reports can only be mailed through smtp channels", channelName, channel.Type)
+	}
+
+	contentType := "text/plain"
+	switch format {
+	case "html":
+		contentType = "text/html"
+	case "json":
+		contentType = "application/json"
+	}
+	err := notify.Mail(channel.SMTP, report.Title(), string(content), contentType)
+	if err != nil {
+		return fmt.Errorf("failed to mail report through %s: %w", channelName, err)
+	}
+	return nil
+}
+
+// Upload stores a rendered report below prefix in cfg's target and returns
+// its key.
+func Upload(report Report, content []byte, format, prefix string, cfg config.Config) (string, error) {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	key := path.Join(prefix, FileName(report, format))
+
+	folder, err := os.MkdirTemp("", "gos3-report-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary folder: %w", err)
+	}
+	defer os.RemoveAll(folder)
+
+	localPath := filepath.Join(folder, FileName(report, format))
+	err = os.WriteFile(localPath, content, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+
+	st, err := storage.Open(cfg)
+	if err != nil {
+		return "", err
+	}
+	defer st.Close()
+
+	err = st.Put(localPath, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload report to %s: %w", key, err)
+	}
+	return key, nil
+}
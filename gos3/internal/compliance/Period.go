@@ -0,0 +1,30 @@
+package compliance
+
+import (
+	"fmt"
+	"time"
+
+	"gos3/internal/config"
+)
+
+// Period resolves a report period relative to now: "month" (or empty) is
+// the previous calendar month, "week" the previous Monday to Sunday and a
+// duration such as "30d" the time up to now. to is exclusive.
+func Period(spec string, now time.Time) (from, to time.Time, err error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch spec {
+	case "", "month":
+		to = today.AddDate(0, 0, 1-today.Day())
+		return to.AddDate(0, -1, 0), to, nil
+	case "week":
+		weekday := (int(today.Weekday()) + 6) % 7
+		to = today.AddDate(0, 0, -weekday)
+		return to.AddDate(0, 0, -7), to, nil
+	}
+
+	length, err := config.ParseDuration(spec)
+	if err != nil || length <= 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid report period %q: use month, week or a duration such as 30d", spec)
+	}
+	return now.Add(-length), now, nil
+}
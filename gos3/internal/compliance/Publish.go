@@ -0,0 +1,48 @@
+package compliance
+
+import (
+	"errors"
+	"time"
+
+	"gos3/internal/config"
+)
+
+// Publish builds the report of the configured period and mails and uploads it
+// as cfg.Compliance asks, attempting both even when one fails.
+func Publish(cfg config.Config) error {
+	settings := cfg.Compliance
+	from, to, err := Period(settings.Period, time.Now())
+	if err != nil {
+		return err
+	}
+	format := settings.Format
+	if format == "" {
+		format = "html"
+	}
+
+	report, err := Build(from, to, true, cfg)
+	if err != nil {
+		return err
+	}
+	content, err := Render(report, format)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if settings.Email != "" {
+		err = Email(report, content, format, settings.Email, cfg)
+		if err == nil {
+			cfg.Logger().Info("Mailed compliance report", "channel", settings.Email, "from", from, "to", to)
+		}
+		errs = append(errs, err)
+	}
+	if settings.Upload {
+		key, err := Upload(report, content, format, settings.Prefix, cfg)
+		if err == nil {
+			cfg.Logger().Info("Uploaded compliance report", "target", cfg.CurrentTarget(), "key", key)
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
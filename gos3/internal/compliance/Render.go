@@ -0,0 +1,72 @@
+package compliance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gos3/internal/s3"
+)
+
+// Formats are the output formats Render supports.
+var Formats = []string{"html", "md", "json"}
+
+// Render writes the report as html, md (Markdown) or json.
+func Render(report Report, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "html":
+		err = htmlTemplate.Execute(&buf, report)
+	case "md":
+		err = markdownTemplate.Execute(&buf, report)
+	case "json":
+		var data []byte
+		data, err = json.MarshalIndent(report, "", "  ")
+		buf.Write(append(data, '\n'))
+	default:
+		return nil, fmt.Errorf("unknown report format %q: use html, md or json", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to render report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// FileName names the report of a period by its first and last day, e.g.
+// backup-report_2026-09-01_2026-09-30.html.
+func FileName(report Report, format string) string {
+	return fmt.Sprintf("backup-report_%s_%s.%s", report.From.Format("2006-01-02"), report.lastDay(), format)
+}
+
+// Title is the subject line of a mailed report.
+func (r Report) Title() string {
+	return fmt.Sprintf("Backup report %s: %s to %s", r.Host, r.From.Format("2006-01-02"), r.lastDay())
+}
+
+// lastDay is the last day the period covers; To itself is exclusive.
+func (r Report) lastDay() string {
+	return r.To.Add(-time.Nanosecond).Format("2006-01-02")
+}
+
+var templateFuncs = map[string]any{
+	"size": s3.FormatSize,
+	"percent": func(rate float64) string {
+		return fmt.Sprintf("%.1f%%", rate*100)
+	},
+	"seconds": func(seconds float64) time.Duration {
+		return time.Duration(seconds * float64(time.Second)).Round(time.Second)
+	},
+	"firstLine": func(text string) string {
+		line, _, _ := strings.Cut(text, "\n")
+		return line
+	},
+	"when": func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return t.Local().Format("2006-01-02 15:04")
+	},
+}
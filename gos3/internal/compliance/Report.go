@@ -0,0 +1,86 @@
+package compliance
+
+import (
+	"time"
+
+	"gos3/internal/history"
+)
+
+// Report proves which backups ran, how reliably and how they were checked
+// between From and To (exclusive).
+type Report struct {
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Host        string    `json:"host"`
+	Version     string    `json:"version"`
+
+	Definitions  []DefinitionSummary  `json:"definitions"`
+	Storage      []TargetStorage      `json:"storage"`
+	Verification VerificationSummary  `json:"verification"`
+	RestoreTests []RestoreTestSummary `json:"restoreTests"`
+	Keys         []KeyUse             `json:"keys"`
+	// CurrentKeyFingerprint is the key backups are encrypted for now.
+	CurrentKeyFingerprint string `json:"currentKeyFingerprint,omitempty"`
+}
+
+// DefinitionSummary covers the runs of one definition. MaxGapSeconds is the
+// longest time without a successful backup, the recovery point achieved.
+type DefinitionSummary struct {
+	Name           string    `json:"name"`
+	Runs           int       `json:"runs"`
+	Succeeded      int       `json:"succeeded"`
+	Degraded       int       `json:"degraded"`
+	Failed         int       `json:"failed"`
+	SuccessRate    float64   `json:"successRate"`
+	MaxGapSeconds  float64   `json:"maxGapSeconds"`
+	MaxGapFrom     time.Time `json:"maxGapFrom"`
+	MaxGapTo       time.Time `json:"maxGapTo"`
+	LastSuccess    time.Time `json:"lastSuccess"`
+	BytesUploaded  int64     `json:"bytesUploaded"`
+	AverageSeconds float64   `json:"averageSeconds"`
+	Failures       []Failure `json:"failures,omitempty"`
+}
+
+type Failure struct {
+	Time   time.Time `json:"time"`
+	Status string    `json:"status"`
+	Error  string    `json:"error"`
+}
+
+// TargetStorage is what a target holds at the time of the report.
+type TargetStorage struct {
+	Target  string `json:"target"`
+	Bytes   int64  `json:"bytes"`
+	Folders int    `json:"folders"`
+	Error   string `json:"error,omitempty"`
+}
+
+type VerificationSummary struct {
+	Runs        int                    `json:"runs"`
+	ItemsPassed int                    `json:"itemsPassed"`
+	ItemsFailed int                    `json:"itemsFailed"`
+	Last        time.Time              `json:"last"`
+	Failures    []history.Verification `json:"failures,omitempty"`
+}
+
+type RestoreTestSummary struct {
+	Name       string    `json:"name"`
+	Definition string    `json:"definition"`
+	Runs       int       `json:"runs"`
+	Passed     int       `json:"passed"`
+	LastRun    time.Time `json:"lastRun"`
+	LastDate   string    `json:"lastDate"`
+	LastPassed bool      `json:"lastPassed"`
+	LastError  string    `json:"lastError,omitempty"`
+}
+
+// KeyUse is a public key backups were encrypted for during the period.
+type KeyUse struct {
+	Fingerprint string    `json:"fingerprint"`
+	Definitions []string  `json:"definitions"`
+	Runs        int       `json:"runs"`
+	FirstUsed   time.Time `json:"firstUsed"`
+	LastUsed    time.Time `json:"lastUsed"`
+	Current     bool      `json:"current"`
+}
@@ -0,0 +1,81 @@
+package compliance
+
+import (
+	"html/template"
+)
+
+var htmlTemplate = template.Must(template.New("html").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Backup report {{.Host}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; }
+th, td { text-align: left; padding: .35em .7em; border-bottom: 1px solid #ddd; }
+th { background: #f4f4f4; }
+.success { color: #17803d; } .degraded { color: #b36b00; } .failed { color: #c62828; font-weight: bold; }
+.mono { font-family: monospace; font-size: .9em; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>Backup report {{.Host}}</h1>
+<p>Period {{when .From}} to {{when .To}}, generated {{when .GeneratedAt}} by gos3 {{.Version}}.</p>
+
+<h2>Backups</h2>
+<table>
+<tr><th>Definition</th><th>Runs</th><th>Succeeded</th><th>Degraded</th><th>Failed</th><th>Success rate</th><th>RPO achieved</th><th>Last success</th><th>Uploaded</th><th>Avg. duration</th></tr>
+{{range .Definitions}}<tr>
+<td>{{.Name}}</td>
+<td>{{.Runs}}</td>
+<td>{{.Succeeded}}</td>
+<td{{if .Degraded}} class="degraded"{{end}}>{{.Degraded}}</td>
+<td{{if .Failed}} class="failed"{{end}}>{{.Failed}}</td>
+<td>{{if .Runs}}{{percent .SuccessRate}}{{else}}-{{end}}</td>
+<td title="{{when .MaxGapFrom}} to {{when .MaxGapTo}}">{{seconds .MaxGapSeconds}}</td>
+<td>{{when .LastSuccess}}</td>
+<td>{{size .BytesUploaded}}</td>
+<td>{{seconds .AverageSeconds}}</td>
+</tr>
+{{else}}<tr><td colspan="10">No backup definitions.</td></tr>
+{{end}}</table>
+<p>RPO achieved is the longest time without a successful backup in the period.</p>
+{{range .Definitions}}{{if .Failures}}
+<h3>Unsuccessful runs of {{.Name}}</h3>
+<table>
+<tr><th>Finished</th><th>Status</th><th>Error</th></tr>
+{{range .Failures}}<tr><td>{{when .Time}}</td><td class="{{.Status}}">{{.Status}}</td><td class="mono">{{.Error}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}
+<h2>Storage</h2>
+{{if .Storage}}<table>
+<tr><th>Target</th><th>Date folders</th><th>Stored</th></tr>
+{{range .Storage}}<tr><td>{{.Target}}</td><td>{{.Folders}}</td>{{if .Error}}<td class="failed">unavailable: {{.Error}}</td>{{else}}<td>{{size .Bytes}}</td>{{end}}</tr>
+{{end}}</table>
+{{else}}<p>Not measured.</p>
+{{end}}
+<h2>Verification</h2>
+{{with .Verification}}{{if .Runs}}<p>Verification runs: {{.Runs}}, items passed: {{.ItemsPassed}}, <span{{if .ItemsFailed}} class="failed"{{end}}>items with problems: {{.ItemsFailed}}</span>. Last run {{when .Last}}.</p>
+{{if .Failures}}<table>
+<tr><th>Time</th><th>Target</th><th>Failed</th><th>Problems</th></tr>
+{{range .Failures}}<tr><td>{{when .Time}}</td><td>{{.Target}}</td><td class="failed">{{.Failed}}</td><td class="mono">{{range .Problems}}{{.}}<br>{{end}}</td></tr>
+{{end}}</table>
+{{end}}{{else}}<p>No verification runs in the period.</p>
+{{end}}{{end}}
+<h2>Restore drills</h2>
+{{if .RestoreTests}}<table>
+<tr><th>Test</th><th>Definition</th><th>Runs</th><th>Passed</th><th>Last run</th><th>Last backup date</th><th>Last result</th></tr>
+{{range .RestoreTests}}<tr><td>{{.Name}}</td><td>{{.Definition}}</td><td>{{.Runs}}</td><td>{{.Passed}}</td><td>{{when .LastRun}}</td><td>{{.LastDate}}</td>{{if .LastPassed}}<td class="success">passed</td>{{else}}<td class="failed" title="{{.LastError}}">failed</td>{{end}}</tr>
+{{end}}</table>
+{{else}}<p>No restore drills in the period.</p>
+{{end}}
+<h2>Encryption keys</h2>
+{{if .Keys}}<table>
+<tr><th>Fingerprint</th><th>Definitions</th><th>Runs</th><th>First used</th><th>Last used</th><th>Current</th></tr>
+{{range .Keys}}<tr><td class="mono">{{.Fingerprint}}</td><td>{{range $i, $d := .Definitions}}{{if $i}}, {{end}}{{$d}}{{end}}</td><td>{{.Runs}}</td><td>{{when .FirstUsed}}</td><td>{{when .LastUsed}}</td><td>{{if .Current}}yes{{else}}no{{end}}</td></tr>
+{{end}}</table>
+{{else}}<p>No key fingerprints recorded in the period.</p>
+{{end}}{{if .CurrentKeyFingerprint}}<p>Current public key: <span class="mono">{{.CurrentKeyFingerprint}}</span></p>
+{{end}}</body>
+</html>
+`))
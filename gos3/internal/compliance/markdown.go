@@ -0,0 +1,56 @@
+package compliance
+
+import (
+	"text/template"
+)
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(templateFuncs).Parse(`# Backup report {{.Host}}
+
+Period {{when .From}} to {{when .To}}, generated {{when .GeneratedAt}} by gos3 {{.Version}}.
+
+## Backups
+
+| Definition | Runs | Succeeded | Degraded | Failed | Success rate | RPO achieved | Last success | Uploaded | Avg. duration |
+|---|---|---|---|---|---|---|---|---|---|
+{{range .Definitions}}| {{.Name}} | {{.Runs}} | {{.Succeeded}} | {{.Degraded}} | {{.Failed}} | {{if .Runs}}{{percent .SuccessRate}}{{else}}-{{end}} | {{seconds .MaxGapSeconds}} | {{when .LastSuccess}} | {{size .BytesUploaded}} | {{seconds .AverageSeconds}} |
+{{end}}
+RPO achieved is the longest time without a successful backup in the period.
+{{range .Definitions}}{{if .Failures}}
+### Unsuccessful runs of {{.Name}}
+
+{{range .Failures}}- {{when .Time}} {{.Status}}: {{firstLine .Error}}
+{{end}}{{end}}{{end}}
+## Storage
+{{if .Storage}}
+| Target | Date folders | Stored |
+|---|---|---|
+{{range .Storage}}| {{.Target}} | {{.Folders}} | {{if .Error}}unavailable: {{.Error}}{{else}}{{size .Bytes}}{{end}} |
+{{end}}{{else}}
+Not measured.
+{{end}}
+## Verification
+
+{{with .Verification}}{{if .Runs}}Verification runs: {{.Runs}}, items passed: {{.ItemsPassed}}, items with problems: {{.ItemsFailed}}. Last run {{when .Last}}.
+{{range .Failures}}
+- {{when .Time}} {{.Target}}: {{.Failed}} failed{{range .Problems}}
+  - {{.}}{{end}}{{end}}{{if .Failures}}
+{{end}}{{else}}No verification runs in the period.
+{{end}}{{end}}
+## Restore drills
+{{if .RestoreTests}}
+| Test | Definition | Runs | Passed | Last run | Last backup date | Last result |
+|---|---|---|---|---|---|---|
+{{range .RestoreTests}}| {{.Name}} | {{.Definition}} | {{.Runs}} | {{.Passed}} | {{when .LastRun}} | {{.LastDate}} | {{if .LastPassed}}passed{{else}}failed: {{firstLine .LastError}}{{end}} |
+{{end}}{{else}}
+No restore drills in the period.
+{{end}}
+## Encryption keys
+{{if .Keys}}
+| Fingerprint | Definitions | Runs | First used | Last used | Current |
+|---|---|---|---|---|---|
+{{range .Keys}}| {{.Fingerprint}} | {{range $i, $d := .Definitions}}{{if $i}}, {{end}}{{$d}}{{end}} | {{.Runs}} | {{when .FirstUsed}} | {{when .LastUsed}} | {{if .Current}}yes{{else}}no{{end}} |
+{{end}}{{else}}
+No key fingerprints recorded in the period.
+{{end}}{{if .CurrentKeyFingerprint}}
+Current public key: {{.CurrentKeyFingerprint}}
+{{end}}`))
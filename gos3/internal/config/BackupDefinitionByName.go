@@ -0,0 +1,12 @@
+package config
+
+import "fmt"
+
+func (cfg Config) BackupDefinitionByName(name string) (BackupDefinition, error) {
+	for _, def := range cfg.BackupDefinitions {
+		if def.Name == name {
+			return def, nil
+		}
+	}
+	return BackupDefinition{}, fmt.Errorf("unknown backup definition: %s", name)
+}
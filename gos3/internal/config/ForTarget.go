@@ -0,0 +1,67 @@
+package config
+
+import "fmt"
+
+// DefaultTargetName names the target described by the top-level storage and
+// s3 blocks.
+const DefaultTargetName = "default"
+
+// ForTarget returns a copy of cfg whose storage and s3 blocks point at the
+// named target, so every storage operation can run against it unchanged.
+func (cfg Config) ForTarget(name string) (Config, error) {
+	if name == "" || name == DefaultTargetName {
+		return cfg, nil
+	}
+
+	for _, target := range cfg.Targets {
+		if target.Name != name {
+			continue
+		}
+
+		targetCfg := cfg
+		targetCfg.TargetName = name
+		targetCfg.Storage = target.StorageConfig
+		if target.S3.Bucket != "" {
+			backupFolder := cfg.S3.BackupFolder
+			targetCfg.S3 = target.S3
+			if targetCfg.S3.BackupFolder == "" {
+				targetCfg.S3.BackupFolder = backupFolder
+			}
+		} else if bandwidth := target.S3.Bandwidth; bandwidth.Upload != "" || bandwidth.Download != "" || len(bandwidth.Schedules) > 0 {
+			// Other backends only take the bandwidth limits of their s3 block.
+			targetCfg.S3.Bandwidth = bandwidth
+		}
+		return targetCfg, nil
+	}
+
+	return cfg, fmt.Errorf("unknown storage target: %s", name)
+}
+
+// CurrentTarget names the target cfg points at.
+func (cfg Config) CurrentTarget() string {
+	if cfg.TargetName == "" {
+		return DefaultTargetName
+	}
+	return cfg.TargetName
+}
+
+// TargetNames returns the targets a definition uploads to.
+func (cfg Config) TargetNames(def BackupDefinition) []string {
+	if len(def.Targets) == 0 {
+		return []string{DefaultTargetName}
+	}
+	return def.Targets
+}
+
+// AllTargetNames returns every configured target, starting with the default
+// one when the top-level storage or s3 block is set.
+func (cfg Config) AllTargetNames() []string {
+	var names []string
+	if cfg.Storage.Type != "" || cfg.S3.Bucket != "" {
+		names = append(names, DefaultTargetName)
+	}
+	for _, target := range cfg.Targets {
+		names = append(names, target.Name)
+	}
+	return names
+}
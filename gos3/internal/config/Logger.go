@@ -0,0 +1,17 @@
+package config
+
+import "log/slog"
+
+// Logger returns the default logger with the fields added by WithLog.
+func (cfg Config) Logger() *slog.Logger {
+	if cfg.Log == nil {
+		return slog.Default()
+	}
+	return cfg.Log
+}
+
+// WithLog returns a copy of cfg whose Logger adds args to every record.
+func (cfg Config) WithLog(args ...any) Config {
+	cfg.Log = cfg.Logger().With(args...)
+	return cfg
+}
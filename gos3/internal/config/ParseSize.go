@@ -0,0 +1,22 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseSize converts sizes such as "500", "100K", "20M" or "1G" to bytes.
+// Invalid values yield 0.
+func ParseSize(size string) int64 {
+	var multiplier int64 = 1
+	if strings.HasSuffix(size, "K") {
+		multiplier = 1024
+	} else if strings.HasSuffix(size, "M") {
+		multiplier = 1024 * 1024
+	} else if strings.HasSuffix(size, "G") {
+		multiplier = 1024 * 1024 * 1024
+	}
+
+	numericSize, _ := strconv.ParseInt(strings.TrimRight(size, "KMG"), 10, 64)
+	return numericSize * multiplier
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// SecretSource reads a secret from an environment variable, a file or the
+// output of a command. The first one set is used.
+type SecretSource struct {
+	Env     string   `yaml:"env"`
+	File    string   `yaml:"file"`
+	Command []string `yaml:"command"`
+}
+
+func (s SecretSource) IsSet() bool {
+	return s.Env != "" || s.File != "" || len(s.Command) > 0
+}
+
+func (s SecretSource) Resolve() (string, error) {
+	var value string
+	switch {
+	case s.Env != "":
+		var ok bool
+		value, ok = os.LookupEnv(s.Env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", s.Env)
+		}
+	case s.File != "":
+		data, err := os.ReadFile(s.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		value = string(data)
+	case len(s.Command) > 0:
+		output, err := exec.Command(s.Command[0], s.Command[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to run secret command %s: %w", s.Command[0], err)
+		}
+		value = string(output)
+	default:
+		return "", fmt.Errorf("no secret source configured")
+	}
+
+	value = strings.TrimRight(value, "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("secret is empty")
+	}
+	return value, nil
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// The notify package defines the events and severities; they are repeated
+// here because notify imports config.
+var (
+	notificationTypes      = []string{"webhook", "slack", "mattermost", "smtp", "ntfy", "gotify"}
+	notificationEvents     = []string{"run_started", "run_succeeded", "run_partial", "run_failed", "verification_failed", "retention_pruned"}
+	notificationSeverities = []string{"info", "warning", "error"}
+)
+
+// Validate checks the type, events, minimum severity and template names of a
+// notification channel.
+func (channel NotificationChannel) Validate() error {
+	if !slices.Contains(notificationTypes, channel.Type) {
+		return fmt.Errorf("unknown type %q, use one of %s", channel.Type, strings.Join(notificationTypes, ", "))
+	}
+	for _, event := range channel.Events {
+		if !slices.Contains(notificationEvents, event) {
+			return fmt.Errorf("unknown event %q, use one of %s", event, strings.Join(notificationEvents, ", "))
+		}
+	}
+	if channel.MinSeverity != "" && !slices.Contains(notificationSeverities, channel.MinSeverity) {
+		return fmt.Errorf("unknown minSeverity %q, use one of %s", channel.MinSeverity, strings.Join(notificationSeverities, ", "))
+	}
+	for event := range channel.Templates {
+		if !slices.Contains(notificationEvents, event) {
+			return fmt.Errorf("template for unknown event %q", event)
+		}
+	}
+	return nil
+}
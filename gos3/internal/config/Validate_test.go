@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNotificationChannelValidate(t *testing.T) {
+	tests := []struct {
+		channel NotificationChannel
+		err     string
+	}{
+		{NotificationChannel{Type: "slack", Events: []string{"run_failed"}, MinSeverity: "warning"}, ""},
+		{NotificationChannel{Type: "mattermost"}, ""},
+		{NotificationChannel{Type: "smtp", Templates: map[string]string{"run_started": "x"}}, ""},
+		{NotificationChannel{}, `unknown type ""`},
+		{NotificationChannel{Type: "discord"}, `unknown type "discord"`},
+		{NotificationChannel{Type: "ntfy", Events: []string{"run_failed", "backup_failed"}}, `unknown event "backup_failed"`},
+		{NotificationChannel{Type: "gotify", MinSeverity: "critical"}, `unknown minSeverity "critical"`},
+		{NotificationChannel{Type: "webhook", Templates: map[string]string{"run_fail": "x"}}, `template for unknown event "run_fail"`},
+	}
+	for _, test := range tests {
+		err := test.channel.Validate()
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("Validate(%+v) = %v, want nil", test.channel, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("Validate(%+v) = %v, want %q", test.channel, err, test.err)
+		}
+	}
+}
+
+func TestLoadConfigurationRejectsInvalidChannel(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(file, []byte(`
+notifications:
+  channels:
+    - name: ops
+      type: webhook
+      url: http://127.0.0.1/hook
+      minSeverity: critical
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = LoadConfiguration(file)
+	if err == nil || !strings.Contains(err.Error(), `notification channel ops: unknown minSeverity "critical"`) {
+		t.Errorf("LoadConfiguration = %v, want an invalid minSeverity error", err)
+	}
+}
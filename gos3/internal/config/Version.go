@@ -0,0 +1,5 @@
+package config
+
+// Version is the gos3 release, set at build time with
+// -ldflags "-X gos3/internal/config.Version=1.2.3".
+var Version = "dev"
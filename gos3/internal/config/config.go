@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,30 +10,291 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// BandwidthSchedule overrides the default limits between Start and End
+// ("HH:MM", local time). Windows may wrap around midnight.
+type BandwidthSchedule struct {
+	Start    string `yaml:"start"`
+	End      string `yaml:"end"`
+	Upload   string `yaml:"upload"`
+	Download string `yaml:"download"`
+}
+
+// BandwidthConfig limits transfer rates in bytes per second using the same
+// K/M/G suffixes as MaxFileSize. Empty or "0" means unlimited.
+type BandwidthConfig struct {
+	Upload    string              `yaml:"upload"`
+	Download  string              `yaml:"download"`
+	Schedules []BandwidthSchedule `yaml:"schedules"`
+}
+
 type S3Config struct {
-	Endpoint        string `yaml:"endpoint"`
-	Bucket          string `yaml:"bucket"`
-	AccessKeyID     string `yaml:"accessKeyId"`
-	AccessKeySecret string `yaml:"accessKeySecret"`
-	Region          string `yaml:"region"`
-	MaxFileSize     string `yaml:"maxFileSize"`
-	BackupFolder    string `yaml:"backupFolder"`
+	Endpoint          string          `yaml:"endpoint"`
+	Bucket            string          `yaml:"bucket"`
+	AccessKeyID       string          `yaml:"accessKeyId"`
+	AccessKeySecret   string          `yaml:"accessKeySecret"`
+	Region            string          `yaml:"region"`
+	MaxFileSize       string          `yaml:"maxFileSize"`
+	BackupFolder      string          `yaml:"backupFolder"`
+	UploadPartSize    string          `yaml:"uploadPartSize"`
+	UploadConcurrency int             `yaml:"uploadConcurrency"`
+	ParallelFiles     int             `yaml:"parallelFiles"`
+	Bandwidth         BandwidthConfig `yaml:"bandwidth"`
+	// UploadRetries is the number of extra attempts for a failed file upload.
+	UploadRetries int `yaml:"uploadRetries"`
+	// AbandonedUploadMaxAge is a Go duration (e.g. "48h") after which
+	// unfinished multipart uploads under BackupFolder are aborted.
+	AbandonedUploadMaxAge string `yaml:"abandonedUploadMaxAge"`
+}
+
+// SFTPConfig configures the sftp storage backend. Either Password or
+// PrivateKeyFile must be set. The host key is always checked against
+// KnownHostsFile (~/.ssh/known_hosts by default).
+type SFTPConfig struct {
+	Host                 string `yaml:"host"`
+	Port                 int    `yaml:"port"`
+	User                 string `yaml:"user"`
+	Password             string `yaml:"password"`
+	PrivateKeyFile       string `yaml:"privateKeyFile"`
+	PrivateKeyPassphrase string `yaml:"privateKeyPassphrase"`
+	KnownHostsFile       string `yaml:"knownHostsFile"`
+	// Root is the remote folder that holds s3.backupFolder.
+	Root string `yaml:"root"`
+}
+
+// WebDAVConfig configures the webdav storage backend. URL is the collection
+// that holds s3.backupFolder. Chunked upload is Nextcloud-only and used for
+// files above ChunkSize when UploadsURL is set; other servers get each file
+// in one PUT, which is retried as a whole and never resumed.
+type WebDAVConfig struct {
+	URL        string `yaml:"url"`
+	User       string `yaml:"user"`
+	Password   string `yaml:"password"`
+	UploadsURL string `yaml:"uploadsUrl"`
+	ChunkSize  string `yaml:"chunkSize"`
+}
+
+// AzureConfig configures the azure storage backend. Use either
+// ConnectionString or AccountName and AccountKey; Endpoint overrides the
+// service URL, e.g. for Azurite.
+type AzureConfig struct {
+	ConnectionString string `yaml:"connectionString"`
+	AccountName      string `yaml:"accountName"`
+	AccountKey       string `yaml:"accountKey"`
+	Endpoint         string `yaml:"endpoint"`
+	Container        string `yaml:"container"`
+	BlockSize        string `yaml:"blockSize"`
+	Concurrency      int    `yaml:"concurrency"`
+}
+
+// StorageConfig selects the backup target. Type is "s3" (default, uses the
+// s3 block), "local" (Path is a folder, e.g. a mounted NAS share), "sftp",
+// "webdav" or "azure". Object keys always start with s3.backupFolder.
+type StorageConfig struct {
+	Type   string       `yaml:"type"`
+	Path   string       `yaml:"path"`
+	SFTP   SFTPConfig   `yaml:"sftp"`
+	WebDAV WebDAVConfig `yaml:"webdav"`
+	Azure  AzureConfig  `yaml:"azure"`
+}
+
+// TargetConfig is a named storage target. S3 targets set their own s3 block;
+// an empty backupFolder falls back to the top-level one.
+type TargetConfig struct {
+	Name          string   `yaml:"name"`
+	S3            S3Config `yaml:"s3"`
+	StorageConfig `yaml:",inline"`
+}
+
+// RetentionConfig decides which date folders PruneBackups removes. The
+// KeepLast newest folders are always kept; older ones are removed once they
+// are older than MaxAge ("30d", "12w" or a Go duration). Zero values disable
+// the respective rule; with both unset nothing is pruned.
+type RetentionConfig struct {
+	KeepLast int    `yaml:"keepLast"`
+	MaxAge   string `yaml:"maxAge"`
 }
 
 type AppConfig struct {
-	ScriptsFolder      string `yaml:"scriptsFolder"`
-	LocalBackupFolder  string `yaml:"localBackupFolder"`
-	BackupFrequency    string `yaml:"backupFrequency"`
-	PublicKeyFile      string `yaml:"publicKeyFile"`
-	PrivateKeyFile     string `yaml:"privateKeyFile"`
-	PrivateKeyMetadata string `yaml:"privateKeyMetadata"`
+	ScriptsFolder      string          `yaml:"scriptsFolder"`
+	LocalBackupFolder  string          `yaml:"localBackupFolder"`
+	BackupFrequency    string          `yaml:"backupFrequency"`
+	PublicKeyFile      string          `yaml:"publicKeyFile"`
+	PrivateKeyFile     string          `yaml:"privateKeyFile"`
+	PrivateKeyMetadata string          `yaml:"privateKeyMetadata"`
+	StateFolder        string          `yaml:"stateFolder"`
+	MaxConcurrentIO    int             `yaml:"maxConcurrentIO"`
+	Retention          RetentionConfig `yaml:"retention"`
+	// SigningKeyFile is the PEM private key (Ed25519, ECDSA or RSA) used to
+	// sign run manifests, generated in StateFolder when empty;
+	// SigningPublicKeyFile verifies them when read back.
+	SigningKeyFile       string `yaml:"signingKeyFile"`
+	SigningPublicKeyFile string `yaml:"signingPublicKeyFile"`
+	// PrivateKeyPassword is where unattended restores and verifications get
+	// the password of PrivateKeyFile from.
+	PrivateKeyPassword SecretSource `yaml:"privateKeyPassword"`
+	// RestoreFolder holds downloaded and decrypted archives while a restore
+	// runs; the system temp folder when empty.
+	RestoreFolder string `yaml:"restoreFolder"`
+	// DisableCatalog makes list, download and prune always read the bucket
+	// instead of the local catalog.
+	DisableCatalog bool `yaml:"disableCatalog"`
+	// Preflight checks run before a standard backup stops its containers.
+	Preflight PreflightConfig `yaml:"preflight"`
+}
+
+// PreflightConfig turns the preflight checks off with Disabled. SpaceFactor is
+// the free space the local backup folder needs per byte of volume data.
+type PreflightConfig struct {
+	Disabled    bool    `yaml:"disabled"`
+	SpaceFactor float64 `yaml:"spaceFactor"`
+}
+
+type IncrementalConfig struct {
+	Enabled             bool   `yaml:"enabled"`
+	FullBackupFrequency string `yaml:"fullBackupFrequency"`
+}
+
+type CompressionConfig struct {
+	Codec   string `yaml:"codec"`
+	Level   int    `yaml:"level"`
+	Threads int    `yaml:"threads"`
 }
 
 type BackupDefinition struct {
-	Name       string   `yaml:"name"`
-	Type       string   `yaml:"type"`
-	Containers []string `yaml:"containers"`
-	Volumes    []string `yaml:"volumes"`
+	Name        string            `yaml:"name"`
+	Type        string            `yaml:"type"`
+	Containers  []string          `yaml:"containers"`
+	Volumes     []string          `yaml:"volumes"`
+	Incremental IncrementalConfig `yaml:"incremental"`
+	Compression CompressionConfig `yaml:"compression"`
+	Workers     int               `yaml:"workers"`
+	// Targets lists the names of the targets every backup is uploaded to.
+	// Empty means the top-level storage only.
+	Targets []string `yaml:"targets"`
+	// HeartbeatURL receives healthchecks.io-style start, success and fail
+	// pings for every run of the definition.
+	HeartbeatURL string `yaml:"heartbeatUrl"`
+}
+
+// ServeConfig schedules the jobs run by `gos3 serve`. Schedules are cron
+// expressions ("0 2 * * *") or descriptors such as "@daily" and "@every 6h".
+type ServeConfig struct {
+	BackupSchedule string `yaml:"backupSchedule"`
+}
+
+// MetricsConfig exposes Prometheus metrics: on Listen ("host:port") while
+// `gos3 serve` runs, and as node_exporter textfiles in TextfileFolder after
+// one-shot commands.
+type MetricsConfig struct {
+	Listen         string `yaml:"listen"`
+	TextfileFolder string `yaml:"textfileFolder"`
+}
+
+func (m MetricsConfig) Enabled() bool {
+	return m.Listen != "" || m.TextfileFolder != ""
+}
+
+type NotificationsConfig struct {
+	Channels []NotificationChannel `yaml:"channels"`
+}
+
+// NotificationChannel delivers events to one destination. Events and
+// MinSeverity select what is sent; Templates override the message of an event.
+type NotificationChannel struct {
+	Name        string            `yaml:"name"`
+	Type        string            `yaml:"type"`
+	URL         string            `yaml:"url"`
+	Headers     map[string]string `yaml:"headers"`
+	Token       SecretSource      `yaml:"token"`
+	SMTP        SMTPConfig        `yaml:"smtp"`
+	Events      []string          `yaml:"events"`
+	MinSeverity string            `yaml:"minSeverity"`
+	Templates   map[string]string `yaml:"templates"`
+}
+
+// SMTPConfig sends mail through Host:Port. TLS is "starttls" (default),
+// "tls" for implicit TLS or "none".
+type SMTPConfig struct {
+	Host     string       `yaml:"host"`
+	Port     int          `yaml:"port"`
+	Username string       `yaml:"username"`
+	Password SecretSource `yaml:"password"`
+	From     string       `yaml:"from"`
+	To       []string     `yaml:"to"`
+	TLS      string       `yaml:"tls"`
+}
+
+// ReportConfig sends a report of every backup run to a `gos3 hub` at URL,
+// queueing it in QueueFolder until the hub accepted it.
+type ReportConfig struct {
+	URL         string       `yaml:"url"`
+	Token       SecretSource `yaml:"token"`
+	CAFile      string       `yaml:"caFile"`
+	Host        string       `yaml:"host"`
+	QueueFolder string       `yaml:"queueFolder"`
+	MaxQueued   int          `yaml:"maxQueued"`
+}
+
+// HubConfig configures `gos3 hub`. InsecureHTTP serves plain HTTP for a
+// TLS-terminating proxy in front of it.
+type HubConfig struct {
+	Listen       string      `yaml:"listen"`
+	TLSCert      string      `yaml:"tlsCert"`
+	TLSKey       string      `yaml:"tlsKey"`
+	InsecureHTTP bool        `yaml:"insecureHttp"`
+	Database     string      `yaml:"database"`
+	MaxAge       string      `yaml:"maxAge"`
+	StaleAfter   string      `yaml:"staleAfter"`
+	Agents       []HubAgent  `yaml:"agents"`
+	Viewers      []HubViewer `yaml:"viewers"`
+}
+
+// HubAgent is a token agents send reports with. With Host set the token is
+// only accepted for reports of that host.
+type HubAgent struct {
+	Host  string       `yaml:"host"`
+	Token SecretSource `yaml:"token"`
+}
+
+// HubViewer is a basic auth login for the dashboard and the JSON API.
+type HubViewer struct {
+	Username string       `yaml:"username"`
+	Password SecretSource `yaml:"password"`
+}
+
+// ComplianceConfig generates `gos3 report` on Schedule under `gos3 serve`,
+// mails it through the smtp notification channel named Email and, with Upload,
+// stores it below Prefix.
+type ComplianceConfig struct {
+	Schedule string `yaml:"schedule"`
+	Period   string `yaml:"period"`
+	Format   string `yaml:"format"`
+	Email    string `yaml:"email"`
+	Upload   bool   `yaml:"upload"`
+	Prefix   string `yaml:"prefix"`
+}
+
+// RestoreTestConfig is a restore drill: the backup of Definition picked by
+// Pick ("latest" or "random") is restored into throwaway volumes and checked
+// with the optional Check container.
+type RestoreTestConfig struct {
+	Name       string              `yaml:"name"`
+	Definition string              `yaml:"definition"`
+	Volumes    []string            `yaml:"volumes"`
+	Pick       string              `yaml:"pick"`
+	Target     string              `yaml:"target"`
+	Schedule   string              `yaml:"schedule"`
+	Check      *RestoreCheckConfig `yaml:"check"`
+}
+
+// RestoreCheckConfig is a container started against the restored volumes. The
+// drill passes when it exits with status 0 within Timeout.
+type RestoreCheckConfig struct {
+	Image   string            `yaml:"image"`
+	Command []string          `yaml:"command"`
+	Env     []string          `yaml:"env"`
+	Mounts  map[string]string `yaml:"mounts"`
+	Timeout string            `yaml:"timeout"`
 }
 
 type VolumeConfig struct {
@@ -47,11 +309,24 @@ type AppFolders struct {
 }
 
 type Config struct {
-	S3                S3Config           `yaml:"s3"`
-	App               AppConfig          `yaml:"app"`
-	Volumes           []VolumeConfig     `yaml:"volumes"`
-	BackupDefinitions []BackupDefinition `yaml:"backupDefinitions"`
+	S3                S3Config            `yaml:"s3"`
+	Storage           StorageConfig       `yaml:"storage"`
+	Targets           []TargetConfig      `yaml:"targets"`
+	App               AppConfig           `yaml:"app"`
+	Volumes           []VolumeConfig      `yaml:"volumes"`
+	BackupDefinitions []BackupDefinition  `yaml:"backupDefinitions"`
+	Serve             ServeConfig         `yaml:"serve"`
+	Metrics           MetricsConfig       `yaml:"metrics"`
+	Notifications     NotificationsConfig `yaml:"notifications"`
+	Report            ReportConfig        `yaml:"report"`
+	Hub               HubConfig           `yaml:"hub"`
+	Compliance        ComplianceConfig    `yaml:"compliance"`
+	RestoreTests      []RestoreTestConfig `yaml:"restoreTests"`
 	AppFolders        AppFolders
+	// TargetName is set by ForTarget; it is empty for the default target.
+	TargetName string `yaml:"-"`
+	// Log carries the run fields added by WithLog; see Logger.
+	Log *slog.Logger `yaml:"-"`
 }
 
 func LoadConfiguration(configFileName string) (Config, error) {
@@ -110,6 +385,60 @@ func LoadConfiguration(configFileName string) (Config, error) {
 		return config, fmt.Errorf("failed to get absolute path for private key metadata: %w", err)
 	}
 
+	config.App.SigningKeyFile, err = getAbsPath(config.App.SigningKeyFile, appStartFolder)
+	if err != nil {
+		return config, fmt.Errorf("failed to get absolute path for signing key file: %w", err)
+	}
+
+	config.App.SigningPublicKeyFile, err = getAbsPath(config.App.SigningPublicKeyFile, appStartFolder)
+	if err != nil {
+		return config, fmt.Errorf("failed to get absolute path for signing public key file: %w", err)
+	}
+
+	config.App.RestoreFolder, err = getAbsPath(config.App.RestoreFolder, appStartFolder)
+	if err != nil {
+		return config, fmt.Errorf("failed to get absolute path for restore folder: %w", err)
+	}
+
+	config.App.PrivateKeyPassword.File, err = getAbsPath(config.App.PrivateKeyPassword.File, appStartFolder)
+	if err != nil {
+		return config, fmt.Errorf("failed to get absolute path for private key password file: %w", err)
+	}
+
+	if config.App.StateFolder == "" {
+		config.App.StateFolder = "state"
+	}
+	config.App.StateFolder, err = getAbsPath(config.App.StateFolder, appStartFolder)
+	if err != nil {
+		return config, fmt.Errorf("failed to get absolute path for state folder: %w", err)
+	}
+
+	for _, path := range []*string{&config.Report.CAFile, &config.Report.QueueFolder, &config.Hub.TLSCert, &config.Hub.TLSKey, &config.Hub.Database} {
+		*path, err = getAbsPath(*path, appStartFolder)
+		if err != nil {
+			return config, fmt.Errorf("failed to get absolute path for %s: %w", *path, err)
+		}
+	}
+
+	err = resolveStoragePaths(&config.Storage, appStartFolder)
+	if err != nil {
+		return config, err
+	}
+
+	for i := range config.Targets {
+		err = resolveStoragePaths(&config.Targets[i].StorageConfig, appStartFolder)
+		if err != nil {
+			return config, fmt.Errorf("target %s: %w", config.Targets[i].Name, err)
+		}
+	}
+
+	for _, channel := range config.Notifications.Channels {
+		err = channel.Validate()
+		if err != nil {
+			return config, fmt.Errorf("notification channel %s: %w", channel.Name, err)
+		}
+	}
+
 	for i, bd := range config.BackupDefinitions {
 		for j, volume := range bd.Volumes {
 			if isLikelyPath(volume) {
@@ -144,3 +473,30 @@ func getAbsPath(path, basePath string) (string, error) {
 	}
 	return filepath.Abs(filepath.Join(basePath, path))
 }
+
+func resolveStoragePaths(storage *StorageConfig, appStartFolder string) error {
+	var err error
+
+	if storage.Path != "" {
+		storage.Path, err = getAbsPath(storage.Path, appStartFolder)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path for storage path: %w", err)
+		}
+	}
+
+	if storage.SFTP.PrivateKeyFile != "" {
+		storage.SFTP.PrivateKeyFile, err = getAbsPath(storage.SFTP.PrivateKeyFile, appStartFolder)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path for sftp private key: %w", err)
+		}
+	}
+
+	if storage.SFTP.KnownHostsFile != "" {
+		storage.SFTP.KnownHostsFile, err = getAbsPath(storage.SFTP.KnownHostsFile, appStartFolder)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path for sftp known hosts file: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package doctor
+
+// Statuses of a check. Only StatusFail makes `gos3 doctor` exit non-zero.
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// Check is the outcome of one diagnostic. Fix says what to do about a
+// warning or failure.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	Fix    string `json:"fix,omitempty"`
+}
+
+func ok(name, detail string) Check {
+	return Check{Name: name, Status: StatusOK, Detail: detail}
+}
+
+func warn(name, detail, fix string) Check {
+	return Check{Name: name, Status: StatusWarn, Detail: detail, Fix: fix}
+}
+
+func fail(name, detail, fix string) Check {
+	return Check{Name: name, Status: StatusFail, Detail: detail, Fix: fix}
+}
+
+func skip(name, detail, fix string) Check {
+	return Check{Name: name, Status: StatusSkip, Detail: detail, Fix: fix}
+}
@@ -0,0 +1,21 @@
+package doctor
+
+import (
+	"gos3/internal/config"
+)
+
+// Run diagnoses the host and configuration, passing every check to emit as
+// soon as it completes. password unlocks the private key for the key pair check.
+func Run(password string, cfg config.Config, emit func(Check)) {
+	dockerReady := checkDocker(emit)
+	if dockerReady {
+		checkDefinitions(cfg, emit)
+	} else {
+		emit(skip("backup definitions", "Docker is not reachable", ""))
+	}
+	scriptsReady := checkScripts(cfg, emit)
+	checkTools(cfg, emit)
+	checkFolders(cfg, emit)
+	checkTargets(cfg, emit)
+	checkKeys(password, scriptsReady, cfg, emit)
+}
@@ -0,0 +1,109 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gos3/internal/config"
+)
+
+// minDockerMajor is the oldest Docker release gos3 is used with.
+const minDockerMajor = 20
+
+func checkDocker(emit func(Check)) bool {
+	output, err := exec.Command("docker", "version", "--format", "{{.Server.Version}}").CombinedOutput()
+	if err != nil {
+		if _, lookErr := exec.LookPath("docker"); lookErr != nil {
+			emit(fail("docker", "docker command not found", "Install Docker and make sure docker is on the PATH"))
+			return false
+		}
+		emit(fail("docker", "daemon not reachable: "+firstLine(output, err),
+			"Start the daemon (systemctl start docker) and make sure this user may use it (usermod -aG docker $USER, then log in again)"))
+		return false
+	}
+
+	version := strings.TrimSpace(string(output))
+	major, _ := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	if major < minDockerMajor {
+		emit(warn("docker", "Docker "+version, fmt.Sprintf("Upgrade to Docker %d or newer", minDockerMajor)))
+	} else {
+		emit(ok("docker", "Docker "+version))
+	}
+
+	// volume-backup.sh and the restore scripts run alpine helper containers.
+	if exec.Command("docker", "image", "inspect", "alpine").Run() != nil {
+		emit(warn("alpine image", "not pulled yet, the first backup pulls it",
+			"Run docker pull alpine now if the host has no registry access during backups"))
+	} else {
+		emit(ok("alpine image", "present"))
+	}
+	return true
+}
+
+func checkDefinitions(cfg config.Config, emit func(Check)) {
+	if len(cfg.BackupDefinitions) == 0 {
+		emit(warn("backup definitions", "none configured", "Add backupDefinitions to the configuration"))
+		return
+	}
+
+	for _, def := range cfg.BackupDefinitions {
+		prefix := "definition " + def.Name
+		if def.Type != "standard" {
+			emit(fail(prefix, "unknown type "+strconv.Quote(def.Type), "Set type: standard"))
+			continue
+		}
+
+		for _, container := range def.Containers {
+			name := prefix + " container " + container
+			output, err := exec.Command("docker", "container", "inspect", "--format", "{{.State.Status}}", container).CombinedOutput()
+			if err != nil {
+				emit(fail(name, "not found: "+firstLine(output, err),
+					"Check the name against docker ps -a --format '{{.Names}}' and fix backupDefinitions[].containers"))
+				continue
+			}
+			state := strings.TrimSpace(string(output))
+			if state != "running" {
+				emit(warn(name, state, "The container is stopped for the backup and started afterwards, so a backup starts it"))
+				continue
+			}
+			emit(ok(name, state))
+		}
+
+		for _, volume := range def.Volumes {
+			name := prefix + " volume " + volume
+			if filepath.IsAbs(volume) {
+				if _, err := os.Stat(volume); err != nil {
+					emit(fail(name, err.Error(), "Create the folder or fix backupDefinitions[].volumes"))
+					continue
+				}
+				emit(ok(name, "host folder"))
+				continue
+			}
+			output, err := exec.Command("docker", "volume", "inspect", "--format", "{{.Driver}}", volume).CombinedOutput()
+			if err != nil {
+				emit(fail(name, "not found: "+firstLine(output, err),
+					"Check the name against docker volume ls and fix backupDefinitions[].volumes; docker run would silently create it empty"))
+				continue
+			}
+			emit(ok(name, "driver "+strings.TrimSpace(string(output))))
+		}
+	}
+}
+
+func firstLine(output []byte, err error) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	if line == "" {
+		return errLine(err)
+	}
+	return line
+}
+
+// Script and SDK errors append their whole output.
+func errLine(err error) string {
+	line, _, _ := strings.Cut(err.Error(), "\n")
+	return line
+}
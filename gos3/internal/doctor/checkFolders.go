@@ -0,0 +1,34 @@
+package doctor
+
+import (
+	"os"
+
+	"gos3/internal/config"
+)
+
+func checkFolders(cfg config.Config, emit func(Check)) {
+	folders := []struct{ name, path, setting string }{
+		{"local backup folder", cfg.App.LocalBackupFolder, "app.localBackupFolder"},
+		{"state folder", cfg.App.StateFolder, "app.stateFolder"},
+	}
+	for _, folder := range folders {
+		if folder.path == "" {
+			emit(fail(folder.name, "not configured", "Set "+folder.setting))
+			continue
+		}
+		err := os.MkdirAll(folder.path, 0755)
+		if err == nil {
+			var probe *os.File
+			probe, err = os.CreateTemp(folder.path, ".gos3-doctor")
+			if err == nil {
+				probe.Close()
+				os.Remove(probe.Name())
+			}
+		}
+		if err != nil {
+			emit(fail(folder.name, err.Error(), "Make "+folder.path+" writable for this user or change "+folder.setting))
+			continue
+		}
+		emit(ok(folder.name, folder.path))
+	}
+}
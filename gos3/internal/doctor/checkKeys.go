@@ -0,0 +1,127 @@
+package doctor
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gos3/internal/config"
+	"gos3/internal/manifest"
+	"gos3/internal/script"
+)
+
+// checkKeys checks that the public key is an RSA key openssl can encrypt for
+// and, when the password is known, that the key pair matches.
+func checkKeys(password string, scriptsReady bool, cfg config.Config, emit func(Check)) {
+	publicReady := checkPublicKey(cfg.App.PublicKeyFile, emit)
+
+	privateKey := cfg.App.PrivateKeyFile
+	if privateKey == "" {
+		emit(skip("private key", "app.privateKeyFile is not set", "Restores and verifications need it; it can live on another machine"))
+		return
+	}
+	if _, err := os.Stat(privateKey); err != nil {
+		emit(fail("private key", err.Error(), "Set app.privateKeyFile to the encrypted private key"))
+		return
+	}
+	if _, err := os.Stat(privateKey + ".metadata"); err != nil {
+		emit(fail("private key", "metadata missing: "+err.Error(),
+			"Keep "+filepath.Base(privateKey)+".metadata (salt, iterations and IV) next to the encrypted private key"))
+		return
+	}
+	emit(ok("private key", privateKey))
+
+	if cfg.App.PrivateKeyPassword.IsSet() {
+		var err error
+		password, err = cfg.App.PrivateKeyPassword.Resolve()
+		if err != nil {
+			emit(fail("key pair", "failed to read app.privateKeyPassword: "+err.Error(), "Fix the env, file or command of app.privateKeyPassword"))
+			return
+		}
+	}
+	switch {
+	case password == "":
+		emit(skip("key pair", "no private key password",
+			"Set app.privateKeyPassword or run gos3 doctor on a terminal to enter it"))
+	case !publicReady || !scriptsReady:
+		emit(skip("key pair", "public key or scripts not usable", ""))
+	default:
+		emit(checkKeyPair(password, cfg))
+	}
+}
+
+func checkPublicKey(file string, emit func(Check)) bool {
+	fix := "Set app.publicKeyFile to the PEM public key made by gos3 keygenerate (openssl rsa -pubout)"
+	if file == "" {
+		emit(fail("public key", "app.publicKeyFile is not set", fix))
+		return false
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		emit(fail("public key", err.Error(), fix))
+		return false
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		emit(fail("public key", "no PEM data in "+file, fix))
+		return false
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		emit(fail("public key", "not a public key: "+err.Error(), fix))
+		return false
+	}
+	rsaKey, isRSA := key.(*rsa.PublicKey)
+	if !isRSA {
+		emit(fail("public key", fmt.Sprintf("%T is not an RSA key", key), "Generate an RSA key pair with gos3 keygenerate"))
+		return false
+	}
+
+	fingerprint, _ := manifest.KeyFingerprint(file)
+	detail := fmt.Sprintf("RSA %d bits, %s", rsaKey.N.BitLen(), fingerprint)
+	if rsaKey.N.BitLen() < 2048 {
+		emit(warn("public key", detail, "Generate a new key pair of at least 2048 bits with gos3 keygenerate"))
+		return true
+	}
+	emit(ok("public key", detail))
+	return true
+}
+
+func checkKeyPair(password string, cfg config.Config) Check {
+	folder, err := os.MkdirTemp("", "gos3-doctor-")
+	if err != nil {
+		return fail("key pair", err.Error(), "")
+	}
+	defer os.RemoveAll(folder)
+
+	content := []byte("gos3 doctor key pair probe\n")
+	plain := filepath.Join(folder, "probe")
+	encrypted := plain + ".cpt"
+	decrypted := plain + ".out"
+	err = os.WriteFile(plain, content, 0600)
+	if err != nil {
+		return fail("key pair", err.Error(), "")
+	}
+
+	err = script.KeyEncrypt(plain, encrypted, cfg.App.PublicKeyFile, cfg)
+	if err != nil {
+		return fail("key pair", "encryption failed: "+errLine(err), "Run gos3 keyencrypt by hand to see the openssl error")
+	}
+	err = script.KeyDecrypt2WithPass(encrypted, decrypted, cfg.App.PrivateKeyFile, password, cfg)
+	if err == nil {
+		var result []byte
+		result, err = os.ReadFile(decrypted)
+		if err == nil && !bytes.Equal(result, content) {
+			err = fmt.Errorf("decrypted content differs")
+		}
+	}
+	if err != nil {
+		return fail("key pair", "the private key cannot decrypt what is encrypted for the public key: "+errLine(err),
+			"Check the private key password, or that app.publicKeyFile and app.privateKeyFile belong together; backups made with a foreign public key cannot be restored")
+	}
+	return ok("key pair", "public and private key match")
+}
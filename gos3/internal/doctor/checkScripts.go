@@ -0,0 +1,100 @@
+package doctor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gos3/internal/config"
+	"gos3/internal/script"
+)
+
+// requiredScripts are the scripts backups, verifications and restores call.
+var requiredScripts = []string{
+	"volume-backup.sh", "volume-index.sh", "volume-restore.sh",
+	"key-encrypt.sh", "key-decrypt2.sh", "key-decrypt2-withpass.sh", "key-decrypt2-stream.sh",
+	"derive-key.sh", "split.sh", "join.sh",
+}
+
+func checkScripts(cfg config.Config, emit func(Check)) bool {
+	folder := cfg.App.ScriptsFolder
+	info, err := os.Stat(folder)
+	if err != nil || !info.IsDir() {
+		emit(fail("scripts folder", folder+" is not a folder",
+			"Set app.scriptsFolder to the scripts folder of the gos3 release (relative paths start at the working directory)"))
+		return false
+	}
+
+	var missing, notExecutable []string
+	for _, name := range requiredScripts {
+		info, err := os.Stat(filepath.Join(folder, name))
+		switch {
+		case err != nil:
+			missing = append(missing, name)
+		case info.Mode()&0111 == 0:
+			notExecutable = append(notExecutable, name)
+		}
+	}
+	switch {
+	case len(missing) > 0:
+		emit(fail("scripts folder", "missing "+strings.Join(missing, ", ")+" in "+folder,
+			"Copy the scripts folder of the gos3 release matching this binary"))
+		return false
+	case len(notExecutable) > 0:
+		emit(fail("scripts folder", "not executable: "+strings.Join(notExecutable, ", "),
+			"Run chmod +x "+filepath.Join(folder, "*.sh")))
+		return false
+	}
+	emit(ok("scripts folder", folder))
+	return true
+}
+
+// checkTools also checks that openssl supports the PBKDF2 key derivation every
+// archive is encrypted with.
+func checkTools(cfg config.Config, emit func(Check)) {
+	tools := []string{"openssl", "bc", "stat", "split"}
+	for _, def := range cfg.BackupDefinitions {
+		codec, err := script.GetCodec(def.Compression.Codec)
+		if err != nil {
+			emit(fail("definition "+def.Name+" codec", err.Error(), "Set compression.codec to none, gzip, zstd, xz or lz4"))
+			continue
+		}
+		if len(codec.Decompressor) > 0 && !slices.Contains(tools, codec.Decompressor[0]) {
+			tools = append(tools, codec.Decompressor[0])
+		}
+	}
+
+	var missing []string
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool); err != nil {
+			missing = append(missing, tool)
+		}
+	}
+	if len(missing) > 0 {
+		emit(fail("host tools", "missing "+strings.Join(missing, ", "),
+			"Install them with the package manager, e.g. apt install "+strings.Join(missing, " ")))
+	} else {
+		emit(ok("host tools", strings.Join(tools, ", ")))
+	}
+
+	if slices.Contains(missing, "openssl") {
+		return
+	}
+	output, err := exec.Command("openssl", "version").CombinedOutput()
+	version := strings.TrimSpace(string(output))
+	if err != nil {
+		emit(fail("openssl", firstLine(output, err), "Reinstall OpenSSL 1.1.1 or newer"))
+		return
+	}
+
+	cmd := exec.Command("openssl", "enc", "-aes-256-cbc", "-pbkdf2", "-iter", "1000", "-k", "gos3-doctor")
+	cmd.Stdin = strings.NewReader("probe")
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		emit(fail("openssl", version+" cannot encrypt with -pbkdf2: "+firstLine(output, err), "Install OpenSSL 1.1.1 or newer"))
+		return
+	}
+	emit(ok("openssl", version))
+}
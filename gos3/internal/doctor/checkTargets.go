@@ -0,0 +1,113 @@
+package doctor
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gos3/internal/config"
+	"gos3/internal/s3"
+)
+
+// Clock skew limits: S3 rejects signed requests more than 15 minutes off.
+const (
+	skewWarning = time.Minute
+	skewFailure = 15 * time.Minute
+)
+
+func checkTargets(cfg config.Config, emit func(Check)) {
+	names := cfg.AllTargetNames()
+	if len(names) == 0 {
+		emit(fail("targets", "no storage configured", "Configure s3 or storage, or add targets"))
+		return
+	}
+
+	for _, name := range names {
+		label := "target " + name
+		targetCfg, err := cfg.ForTarget(name)
+		if err != nil {
+			emit(fail(label, err.Error(), "Fix the targets section of the configuration"))
+			continue
+		}
+
+		result := s3.ProbeTarget(targetCfg)
+		switch {
+		case result.List != nil:
+			emit(fail(label, "list failed: "+errLine(result.List), listFix(targetCfg)))
+		case result.Put != nil:
+			emit(fail(label, "listing works, put failed: "+errLine(result.Put), writeFix(targetCfg, "s3:PutObject")))
+		case result.Delete != nil:
+			emit(fail(label, "put works, delete failed: "+errLine(result.Delete),
+				writeFix(targetCfg, "s3:DeleteObject")+"; retention pruning needs it. A probe object may be left below "+targetCfg.S3.BackupFolder+"/"))
+		default:
+			emit(ok(label, "list, put and delete below "+targetCfg.S3.BackupFolder+"/ work"))
+		}
+
+		if isS3(targetCfg) {
+			emit(checkClock(label, targetCfg.S3))
+		}
+	}
+}
+
+func isS3(cfg config.Config) bool {
+	return cfg.Storage.Type == "" || cfg.Storage.Type == "s3"
+}
+
+func listFix(cfg config.Config) string {
+	if isS3(cfg) {
+		return "Check endpoint, region, bucket name, credentials and network access; the key needs s3:ListBucket on " + cfg.S3.Bucket
+	}
+	return "Check the address, credentials and path of the " + cfg.Storage.Type + " storage"
+}
+
+func writeFix(cfg config.Config, action string) string {
+	if isS3(cfg) {
+		return "Grant " + action + " on " + cfg.S3.Bucket + "/" + cfg.S3.BackupFolder + "/* to the access key"
+	}
+	return "Give this user write permission in the " + cfg.Storage.Type + " storage"
+}
+
+func checkClock(label string, settings config.S3Config) Check {
+	name := label + " clock"
+	endpoint := settings.Endpoint
+	switch {
+	case endpoint == "" && (settings.Region == "" || settings.Region == "us-east-1"):
+		endpoint = "https://s3.amazonaws.com"
+	case endpoint == "":
+		endpoint = "https://s3." + settings.Region + ".amazonaws.com"
+	case !strings.Contains(endpoint, "://"):
+		endpoint = "https://" + endpoint
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	sent := time.Now()
+	response, err := client.Head(endpoint)
+	if err != nil {
+		return warn(name, "endpoint not reachable: "+errLine(err), "Check the s3 endpoint and network access")
+	}
+	response.Body.Close()
+	received := time.Now()
+
+	serverTime, err := http.ParseTime(response.Header.Get("Date"))
+	if err != nil {
+		return skip(name, "endpoint sent no Date header", "")
+	}
+	// The header was set during the request and is truncated to the second,
+	// so compare the middle of both.
+	local := sent.Add(received.Sub(sent) / 2)
+	skew := local.Sub(serverTime.Add(time.Second / 2)).Round(time.Second)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	detail := fmt.Sprintf("%s off %s", skew, endpoint)
+	fix := "Synchronize the clock, e.g. with timedatectl set-ntp true or chrony"
+	switch {
+	case skew >= skewFailure:
+		return fail(name, detail+", S3 rejects the requests", fix)
+	case skew >= skewWarning:
+		return warn(name, detail, fix)
+	}
+	return ok(name, detail)
+}
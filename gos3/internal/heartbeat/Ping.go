@@ -0,0 +1,60 @@
+package heartbeat
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gos3/internal/config"
+)
+
+const (
+	Start   = "start"
+	Success = "success"
+	Fail    = "fail"
+)
+
+const attempts = 3
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Ping signals a run to a healthchecks.io style monitor: <url>/start, <url> on
+// success or <url>/fail. Failing to reach the monitor is logged, not returned.
+func Ping(monitorURL, signal, body string, cfg config.Config) {
+	target := strings.TrimRight(monitorURL, "/")
+	if signal != Success {
+		target += "/" + signal
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = post(target, body)
+		if err == nil {
+			return
+		}
+		if attempt < attempts {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+	cfg.Logger().Warn("Failed to send heartbeat", "signal", signal, "error", err)
+}
+
+// post keeps the URL out of errors, as the check UUID in it is a secret.
+func post(target, body string) error {
+	response, err := client.Post(target, "text/plain; charset=utf-8", strings.NewReader(body))
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", response.Request.URL.Host, response.Status)
+	}
+	return nil
+}
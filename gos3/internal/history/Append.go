@@ -0,0 +1,16 @@
+package history
+
+import (
+	"gos3/internal/config"
+)
+
+// Append adds a record to the run history in the state folder.
+func Append(record Record, cfg config.Config) error {
+	return appendLine(FileName, record, cfg)
+}
+
+// AppendVerification adds a verification to the verification history in
+// the state folder.
+func AppendVerification(verification Verification, cfg config.Config) error {
+	return appendLine(VerificationFileName, verification, cfg)
+}
@@ -0,0 +1,49 @@
+package history
+
+import (
+	"slices"
+	"time"
+
+	"gos3/internal/config"
+)
+
+// Filter selects records; zero fields match everything. Limit 0 returns
+// all matches.
+type Filter struct {
+	Definition string
+	Status     string
+	Since      time.Time
+	Limit      int
+}
+
+func (f Filter) matches(record Record) bool {
+	return (f.Definition == "" || record.Definition == f.Definition) &&
+		(f.Status == "" || record.Status == f.Status) &&
+		(f.Since.IsZero() || !record.StartedAt.Before(f.Since))
+}
+
+// Read returns the matching records of the run history, newest first.
+func Read(filter Filter, cfg config.Config) ([]Record, error) {
+	all, err := readLines[Record](FileName, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, record := range all {
+		if filter.matches(record) {
+			records = append(records, record)
+		}
+	}
+
+	slices.Reverse(records)
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[:filter.Limit]
+	}
+	return records, nil
+}
+
+// ReadVerifications returns the verification history, oldest first.
+func ReadVerifications(cfg config.Config) ([]Verification, error) {
+	return readLines[Verification](VerificationFileName, cfg)
+}
@@ -0,0 +1,45 @@
+package history
+
+import (
+	"time"
+)
+
+// FileName is the JSON lines run history in the state folder.
+const FileName = "history.jsonl"
+
+// Record is one run of a backup definition on this host.
+type Record struct {
+	RunID      string    `json:"runId"`
+	Definition string    `json:"definition"`
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Date       string    `json:"date,omitempty"`
+	Bytes      int64     `json:"bytes"`
+	Steps      Steps     `json:"steps"`
+	Targets    []Target  `json:"targets,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	// KeyFingerprint identifies the public key the run was encrypted for.
+	KeyFingerprint string `json:"keyFingerprint,omitempty"`
+}
+
+// Steps are the durations of the steps of a run in seconds, zero for steps
+// that did not complete.
+type Steps struct {
+	DowntimeSeconds float64 `json:"downtimeSeconds"`
+	ArchiveSeconds  float64 `json:"archiveSeconds"`
+	EncryptSeconds  float64 `json:"encryptSeconds"`
+	SplitSeconds    float64 `json:"splitSeconds"`
+	UploadSeconds   float64 `json:"uploadSeconds"`
+}
+
+// Target is the upload of the run to one target.
+type Target struct {
+	Name            string  `json:"name"`
+	Error           string  `json:"error,omitempty"`
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+func (r Record) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
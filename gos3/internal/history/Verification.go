@@ -0,0 +1,22 @@
+package history
+
+import (
+	"time"
+)
+
+// VerificationFileName is the JSON lines file in the state folder every
+// `gos3 verify` run is appended to.
+const VerificationFileName = "verifications.jsonl"
+
+// Verification is one check of stored backups against their manifests.
+// Problems lists "<date>/<item>: <problem>" for the failed items.
+type Verification struct {
+	Time       time.Time `json:"time"`
+	Target     string    `json:"target"`
+	Definition string    `json:"definition,omitempty"`
+	Full       bool      `json:"full"`
+	Dates      []string  `json:"dates"`
+	Passed     int       `json:"passed"`
+	Failed     int       `json:"failed"`
+	Problems   []string  `json:"problems,omitempty"`
+}
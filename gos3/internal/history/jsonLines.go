@@ -0,0 +1,58 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gos3/internal/config"
+)
+
+func appendLine(name string, value any, cfg config.Config) error {
+	err := os.MkdirAll(cfg.App.StateFolder, 0755)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filepath.Join(cfg.App.StateFolder, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// A missing file has no lines; lines that cannot be decoded are skipped.
+func readLines[T any](name string, cfg config.Config) ([]T, error) {
+	file, err := os.Open(filepath.Join(cfg.App.StateFolder, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+
+	var values []T
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var value T
+		if json.Unmarshal(scanner.Bytes(), &value) == nil {
+			values = append(values, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return values, nil
+}
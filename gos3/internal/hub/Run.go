@@ -0,0 +1,136 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"gos3/internal/config"
+)
+
+const defaultStaleAfter = 26 * time.Hour
+
+// Run serves the hub until SIGINT or SIGTERM.
+func Run(cfg config.Config) error {
+	hubCfg := cfg.Hub
+	if !hubCfg.InsecureHTTP && (hubCfg.TLSCert == "" || hubCfg.TLSKey == "") {
+		return errors.New("hub.tlsCert and hub.tlsKey are required, or set hub.insecureHttp behind a TLS-terminating proxy")
+	}
+
+	srv := &server{viewers: make(map[string]string), staleAfter: defaultStaleAfter, cfg: cfg}
+	for _, a := range hubCfg.Agents {
+		token, err := a.Token.Resolve()
+		if err != nil {
+			return fmt.Errorf("failed to read agent token of %q: %w", a.Host, err)
+		}
+		if token == "" {
+			return fmt.Errorf("agent token of %q is empty", a.Host)
+		}
+		srv.agents = append(srv.agents, agent{host: a.Host, token: token})
+	}
+	if len(srv.agents) == 0 {
+		return errors.New("no hub.agents configured, agents could not send reports")
+	}
+	for _, viewer := range hubCfg.Viewers {
+		password, err := viewer.Password.Resolve()
+		if err != nil {
+			return fmt.Errorf("failed to read password of viewer %s: %w", viewer.Username, err)
+		}
+		srv.viewers[viewer.Username] = password
+	}
+	if len(srv.viewers) == 0 {
+		cfg.Logger().Warn("No hub.viewers configured, the dashboard and the JSON API are disabled")
+	}
+
+	if hubCfg.StaleAfter != "" {
+		staleAfter, err := config.ParseDuration(hubCfg.StaleAfter)
+		if err != nil {
+			return fmt.Errorf("invalid hub.staleAfter: %w", err)
+		}
+		srv.staleAfter = staleAfter
+	}
+	var maxAge time.Duration
+	if hubCfg.MaxAge != "" {
+		var err error
+		maxAge, err = config.ParseDuration(hubCfg.MaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid hub.maxAge: %w", err)
+		}
+	}
+
+	database := hubCfg.Database
+	if database == "" {
+		database = filepath.Join(cfg.App.StateFolder, FileName)
+	}
+	store, err := OpenStore(database)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	srv.store = store
+
+	listen := hubCfg.Listen
+	if listen == "" {
+		listen = ":8443"
+	}
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", listen, err)
+	}
+
+	httpServer := &http.Server{Handler: srv.routes(), ReadHeaderTimeout: 10 * time.Second}
+	served := make(chan error, 1)
+	go func() {
+		if hubCfg.InsecureHTTP {
+			served <- httpServer.Serve(listener)
+		} else {
+			served <- httpServer.ServeTLS(listener, hubCfg.TLSCert, hubCfg.TLSKey)
+		}
+	}()
+	cfg.Logger().Info("Hub listening", "address", listener.Addr().String(), "tls", !hubCfg.InsecureHTTP, "database", database)
+
+	stopPruning := make(chan struct{})
+	defer close(stopPruning)
+	if maxAge > 0 {
+		go pruneReports(store, maxAge, stopPruning, cfg)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-served:
+		return fmt.Errorf("hub server stopped: %w", err)
+	case sig := <-signals:
+		cfg.Logger().Info("Stopping hub", "signal", sig.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(ctx)
+}
+
+func pruneReports(store *Store, maxAge time.Duration, stop <-chan struct{}, cfg config.Config) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+	for {
+		pruned, err := store.Prune(time.Now().Add(-maxAge))
+		if err != nil {
+			cfg.Logger().Warn("Failed to prune old reports", "error", err)
+		} else if pruned > 0 {
+			cfg.Logger().Info("Pruned old reports", "reports", pruned)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
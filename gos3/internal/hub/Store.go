@@ -0,0 +1,171 @@
+package hub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gos3/internal/report"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// FileName is the hub database in the state folder.
+const FileName = "hub.db"
+
+// timeLayout sorts lexically in time order, unlike RFC 3339 with trimmed
+// fractions.
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+var (
+	reportsBucket  = []byte("reports")
+	finishedBucket = []byte("finished")
+)
+
+// Store keeps the reports of every agent, by ID and by finish time.
+type Store struct {
+	db *bolt.DB
+}
+
+// Filter selects reports; empty fields match everything. Limit 0 returns
+// all matches.
+type Filter struct {
+	Host       string
+	Definition string
+	Status     string
+	Since      time.Time
+	Limit      int
+}
+
+func OpenStore(path string) (*Store, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hub database folder: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hub database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{reportsBucket, finishedBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize hub database: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Put stores a report, replacing an earlier copy with the same ID.
+func (s *Store) Put(r report.Report) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		reports := tx.Bucket(reportsBucket)
+		finished := tx.Bucket(finishedBucket)
+
+		if old := reports.Get([]byte(r.ID)); old != nil {
+			var previous report.Report
+			if err := json.Unmarshal(old, &previous); err == nil {
+				if err := finished.Delete(finishedKey(previous)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := reports.Put([]byte(r.ID), data); err != nil {
+			return err
+		}
+		return finished.Put(finishedKey(r), []byte(r.ID))
+	})
+}
+
+// Reports returns the matching reports, newest first.
+func (s *Store) Reports(filter Filter) ([]report.Report, error) {
+	var matches []report.Report
+	err := s.db.View(func(tx *bolt.Tx) error {
+		reports := tx.Bucket(reportsBucket)
+		cursor := tx.Bucket(finishedBucket).Cursor()
+		since := []byte(filter.Since.UTC().Format(timeLayout))
+
+		for key, id := cursor.Last(); key != nil; key, id = cursor.Prev() {
+			if !filter.Since.IsZero() && bytes.Compare(key, since) < 0 {
+				break
+			}
+
+			var r report.Report
+			err := json.Unmarshal(reports.Get(id), &r)
+			if err != nil {
+				return fmt.Errorf("failed to decode report %s: %w", id, err)
+			}
+			if !filter.matches(r) {
+				continue
+			}
+
+			matches = append(matches, r)
+			if filter.Limit > 0 && len(matches) == filter.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// Prune removes the reports that finished before cutoff and returns how
+// many there were.
+func (s *Store) Prune(cutoff time.Time) (int, error) {
+	pruned := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		reports := tx.Bucket(reportsBucket)
+		finished := tx.Bucket(finishedBucket)
+		limit := []byte(cutoff.UTC().Format(timeLayout))
+
+		var keys, ids [][]byte
+		cursor := finished.Cursor()
+		for key, id := cursor.First(); key != nil && bytes.Compare(key, limit) < 0; key, id = cursor.Next() {
+			keys = append(keys, append([]byte(nil), key...))
+			ids = append(ids, append([]byte(nil), id...))
+		}
+
+		for i := range keys {
+			if err := reports.Delete(ids[i]); err != nil {
+				return err
+			}
+			if err := finished.Delete(keys[i]); err != nil {
+				return err
+			}
+		}
+		pruned = len(keys)
+		return nil
+	})
+	return pruned, err
+}
+
+func (f Filter) matches(r report.Report) bool {
+	return (f.Host == "" || r.Host == f.Host) &&
+		(f.Definition == "" || r.Definition == f.Definition) &&
+		(f.Status == "" || r.Status == f.Status)
+}
+
+func finishedKey(r report.Report) []byte {
+	return []byte(r.FinishedAt.UTC().Format(timeLayout) + "\x00" + r.ID)
+}
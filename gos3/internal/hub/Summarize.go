@@ -0,0 +1,82 @@
+package hub
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"gos3/internal/report"
+)
+
+// trendLength is the number of runs a summary keeps sizes and durations of.
+const trendLength = 30
+
+// Summary is the state of one definition on one host.
+type Summary struct {
+	Host          string    `json:"host"`
+	Definition    string    `json:"definition"`
+	LastStatus    string    `json:"lastStatus"`
+	LastRunAt     time.Time `json:"lastRunAt"`
+	LastError     string    `json:"lastError,omitempty"`
+	LastSuccessAt time.Time `json:"lastSuccessAt"`
+	LastBytes     int64     `json:"lastBytes"`
+	Runs          int       `json:"runs"`
+	Failures      int       `json:"failures"`
+	// Stale is set when no run succeeded within staleAfter.
+	Stale bool `json:"stale"`
+	// Sizes and DurationsSeconds of the latest runs that reached a target,
+	// oldest first.
+	Sizes            []int64   `json:"sizes"`
+	DurationsSeconds []float64 `json:"durationsSeconds"`
+}
+
+// Summarize groups reports, newest first, by host and definition. Failures
+// count failed and degraded runs.
+func Summarize(reports []report.Report, staleAfter time.Duration, now time.Time) []Summary {
+	index := make(map[[2]string]int)
+	var summaries []Summary
+	for _, r := range reports {
+		key := [2]string{r.Host, r.Definition}
+		i, found := index[key]
+		if !found {
+			i = len(summaries)
+			index[key] = i
+			summaries = append(summaries, Summary{
+				Host:       r.Host,
+				Definition: r.Definition,
+				LastStatus: r.Status,
+				LastRunAt:  r.FinishedAt,
+				LastError:  r.Error,
+			})
+		}
+		summary := &summaries[i]
+
+		summary.Runs++
+		if r.Status != report.StatusSuccess {
+			summary.Failures++
+		}
+		if r.Status == report.StatusSuccess && summary.LastSuccessAt.IsZero() {
+			summary.LastSuccessAt = r.FinishedAt
+			summary.LastBytes = r.Bytes
+		}
+		if r.Status != report.StatusFailed && len(summary.Sizes) < trendLength {
+			summary.Sizes = append(summary.Sizes, r.Bytes)
+			summary.DurationsSeconds = append(summary.DurationsSeconds, r.Duration().Seconds())
+		}
+	}
+
+	for i := range summaries {
+		summary := &summaries[i]
+		summary.Stale = summary.LastSuccessAt.IsZero() || now.Sub(summary.LastSuccessAt) > staleAfter
+		slices.Reverse(summary.Sizes)
+		slices.Reverse(summary.DurationsSeconds)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Host != summaries[j].Host {
+			return summaries[i].Host < summaries[j].Host
+		}
+		return summaries[i].Definition < summaries[j].Definition
+	})
+	return summaries
+}
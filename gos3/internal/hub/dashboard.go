@@ -0,0 +1,122 @@
+package hub
+
+import (
+	"fmt"
+	"html/template"
+	"time"
+
+	"gos3/internal/report"
+	"gos3/internal/s3"
+)
+
+type dashboardPage struct {
+	Generated   time.Time
+	Summaries   []Summary
+	Failures    []report.Report
+	Hosts       []string
+	StoredBytes int64
+	Stale       int
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"size":      s3.FormatSize,
+	"ago":       ago,
+	"sparkline": sparkline,
+	"seconds": func(seconds float64) time.Duration {
+		return time.Duration(seconds * float64(time.Second)).Round(time.Second)
+	},
+	"last": func(values []float64) float64 {
+		if len(values) == 0 {
+			return 0
+		}
+		return values[len(values)-1]
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="60">
+<title>gos3 hub</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { text-align: left; padding: .35em .7em; border-bottom: 1px solid #ddd; }
+th { background: #f4f4f4; }
+.success { color: #17803d; } .degraded { color: #b36b00; } .failed, .stale { color: #c62828; font-weight: bold; }
+.spark { font-family: monospace; letter-spacing: -1px; }
+.error { font-family: monospace; white-space: pre-wrap; font-size: .9em; }
+</style>
+</head>
+<body>
+<h1>gos3 hub</h1>
+<p>{{len .Hosts}} hosts, {{len .Summaries}} definitions, {{size .StoredBytes}} in the latest backups{{if .Stale}}, <span class="stale">{{.Stale}} stale</span>{{end}}. Last 30 days, updated {{.Generated.Format "2006-01-02 15:04:05"}}.</p>
+
+<h2>Last backup per host and definition</h2>
+<table>
+<tr><th>Host</th><th>Definition</th><th>Last run</th><th>Status</th><th>Last success</th><th>Size</th><th>Duration</th><th>Size trend</th><th>Failures</th></tr>
+{{range .Summaries}}<tr>
+<td>{{.Host}}</td>
+<td>{{.Definition}}</td>
+<td title="{{.LastRunAt.Format "2006-01-02 15:04:05 MST"}}">{{ago .LastRunAt}}</td>
+<td class="{{.LastStatus}}" title="{{.LastError}}">{{.LastStatus}}</td>
+<td{{if .Stale}} class="stale"{{end}}>{{if .LastSuccessAt.IsZero}}never{{else}}{{ago .LastSuccessAt}}{{end}}</td>
+<td>{{size .LastBytes}}</td>
+<td>{{seconds (last .DurationsSeconds)}}</td>
+<td class="spark">{{sparkline .Sizes}}</td>
+<td>{{.Failures}} of {{.Runs}}</td>
+</tr>
+{{else}}<tr><td colspan="9">No reports yet.</td></tr>
+{{end}}</table>
+
+<h2>Recent failures</h2>
+<table>
+<tr><th>Finished</th><th>Host</th><th>Definition</th><th>Status</th><th>Error</th></tr>
+{{range .Failures}}<tr>
+<td>{{.FinishedAt.Format "2006-01-02 15:04:05"}}</td>
+<td>{{.Host}}</td>
+<td>{{.Definition}}</td>
+<td class="{{.Status}}">{{.Status}}</td>
+<td class="error">{{.Error}}</td>
+</tr>
+{{else}}<tr><td colspan="5">No failures.</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func ago(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+func sparkline(values []int64) string {
+	const blocks = "▁▂▃▄▅▆▇█"
+	if len(values) == 0 {
+		return ""
+	}
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low = min(low, v)
+		high = max(high, v)
+	}
+
+	levels := []rune(blocks)
+	line := make([]rune, len(values))
+	for i, v := range values {
+		level := len(levels) / 2
+		if high > low {
+			level = int((v - low) * int64(len(levels)-1) / (high - low))
+		}
+		line[i] = levels[level]
+	}
+	return string(line)
+}
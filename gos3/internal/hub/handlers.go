@@ -0,0 +1,236 @@
+package hub
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gos3/internal/config"
+	"gos3/internal/report"
+)
+
+// maxReportSize bounds a report body; manifests of large definitions list
+// every stored object.
+const maxReportSize = 32 << 20
+
+// defaultWindow is how far back the dashboard and summary look.
+const defaultWindow = 30 * 24 * time.Hour
+
+type agent struct {
+	host  string
+	token string
+}
+
+type server struct {
+	store      *Store
+	agents     []agent
+	viewers    map[string]string
+	staleAfter time.Duration
+	cfg        config.Config
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/reports", s.handlePostReport)
+	mux.HandleFunc("GET /api/v1/reports", s.viewer(s.handleReports))
+	mux.HandleFunc("GET /api/v1/summary", s.viewer(s.handleSummary))
+	mux.HandleFunc("GET /{$}", s.viewer(s.handleDashboard))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+func (s *server) handlePostReport(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(r)
+	if !ok {
+		http.Error(w, "missing bearer token", http.StatusUnauthorized)
+		return
+	}
+
+	var rep report.Report
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReportSize))
+	err := decoder.Decode(&rep)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, "report too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "invalid report: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = rep.Validate()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !s.agentMayReport(token, rep.Host) {
+		s.cfg.Logger().Warn("Rejected report, token not accepted for host", "host", rep.Host, "remote", r.RemoteAddr)
+		http.Error(w, "token not accepted for host "+rep.Host, http.StatusForbidden)
+		return
+	}
+
+	rep.ReceivedAt = time.Now().UTC()
+	err = s.store.Put(rep)
+	if err != nil {
+		s.cfg.Logger().Error("Failed to store report", "host", rep.Host, "definition", rep.Definition, "error", err)
+		http.Error(w, "failed to store report", http.StatusInternalServerError)
+		return
+	}
+	s.cfg.Logger().Info("Received report", "host", rep.Host, "definition", rep.Definition, "status", rep.Status, "run_id", rep.RunID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Manifests are left out unless manifest=true.
+func (s *server) handleReports(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := Filter{
+		Host:       query.Get("host"),
+		Definition: query.Get("definition"),
+		Status:     query.Get("status"),
+		Limit:      100,
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = n
+	}
+	since, err := parseSince(query.Get("since"), 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Since = since
+
+	reports, err := s.store.Reports(filter)
+	if err != nil {
+		s.serverError(w, err)
+		return
+	}
+	if query.Get("manifest") != "true" {
+		for i := range reports {
+			reports[i].Manifest = nil
+		}
+	}
+	writeJSON(w, reports)
+}
+
+func (s *server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	since, err := parseSince(r.URL.Query().Get("since"), defaultWindow)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reports, err := s.store.Reports(Filter{Since: since})
+	if err != nil {
+		s.serverError(w, err)
+		return
+	}
+	writeJSON(w, Summarize(reports, s.staleAfter, time.Now()))
+}
+
+func (s *server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	since := time.Now().Add(-defaultWindow)
+	reports, err := s.store.Reports(Filter{Since: since})
+	if err != nil {
+		s.serverError(w, err)
+		return
+	}
+
+	page := dashboardPage{
+		Generated: time.Now(),
+		Summaries: Summarize(reports, s.staleAfter, time.Now()),
+	}
+	for _, rep := range reports {
+		if rep.Status != report.StatusSuccess && len(page.Failures) < 20 {
+			page.Failures = append(page.Failures, rep)
+		}
+	}
+	for _, summary := range page.Summaries {
+		if !slices.Contains(page.Hosts, summary.Host) {
+			page.Hosts = append(page.Hosts, summary.Host)
+		}
+		page.StoredBytes += summary.LastBytes
+		if summary.Stale {
+			page.Stale++
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = dashboardTemplate.Execute(w, page)
+	if err != nil {
+		s.cfg.Logger().Error("Failed to render dashboard", "error", err)
+	}
+}
+
+func (s *server) viewer(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(s.viewers) == 0 {
+			http.Error(w, "no hub.viewers configured", http.StatusForbidden)
+			return
+		}
+		username, password, ok := r.BasicAuth()
+		expected, known := s.viewers[username]
+		if !ok || !known || subtle.ConstantTimeCompare([]byte(password), []byte(expected)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="gos3 hub"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *server) agentMayReport(token, host string) bool {
+	for _, a := range s.agents {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1 && (a.host == "" || a.host == host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *server) serverError(w http.ResponseWriter, err error) {
+	s.cfg.Logger().Error("Failed to read reports", "error", err)
+	http.Error(w, "failed to read reports", http.StatusInternalServerError)
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token, ok && token != ""
+}
+
+// parseSince turns a duration such as 7d into the time that long ago. An
+// empty value uses fallback, 0 meaning no limit.
+func parseSince(value string, fallback time.Duration) (time.Time, error) {
+	window := fallback
+	if value != "" {
+		var err error
+		window, err = config.ParseDuration(value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid since: %w", err)
+		}
+	}
+	if window == 0 {
+		return time.Time{}, nil
+	}
+	return time.Now().Add(-window), nil
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
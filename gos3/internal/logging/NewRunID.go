@@ -0,0 +1,14 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// NewRunID returns an identifier shared by every record of one backup run.
+func NewRunID() string {
+	random := make([]byte, 4)
+	_, _ = rand.Read(random)
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(random)
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Options select the log format, level and an optional log file, rotated at
+// MaxSize keeping MaxFiles older files.
+type Options struct {
+	Format   string
+	Level    string
+	File     string
+	MaxSize  int64
+	MaxFiles int
+}
+
+// Setup installs the default slog logger, also for the standard log package.
+// The returned closer closes the log file.
+func Setup(options Options) (io.Closer, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(options.Level))
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level %q: use debug, info, warn or error", options.Level)
+	}
+
+	var output io.Writer = os.Stderr
+	var closer io.Closer = io.NopCloser(nil)
+	if options.File != "" {
+		file, err := OpenRotatingFile(options.File, options.MaxSize, options.MaxFiles)
+		if err != nil {
+			return nil, err
+		}
+		output = io.MultiWriter(os.Stderr, file)
+		closer = file
+	}
+
+	handlerOptions := &slog.HandlerOptions{Level: level, ReplaceAttr: replaceAttr}
+	var handler slog.Handler
+	switch strings.ToLower(options.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(output, handlerOptions)
+	case "json":
+		handler = slog.NewJSONHandler(output, handlerOptions)
+	default:
+		closer.Close()
+		return nil, fmt.Errorf("invalid log format %q: use text or json", options.Format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return closer, nil
+}
+
+// Durations are written as Go duration strings ("1m30s") instead of nanoseconds.
+func replaceAttr(groups []string, attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() == slog.KindDuration {
+		return slog.String(attr.Key, attr.Value.Duration().Round(time.Millisecond).String())
+	}
+	return attr
+}
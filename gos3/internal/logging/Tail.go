@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"strings"
+	"sync"
+)
+
+// Tail keeps the last records written to it, e.g. to send the end of a
+// run's log along with a failure.
+type Tail struct {
+	maxLines int
+	maxBytes int
+	mutex    sync.Mutex
+	lines    []string
+	size     int
+}
+
+// NewTail keeps at most maxLines records and maxBytes bytes, dropping the
+// oldest first.
+func NewTail(maxLines, maxBytes int) *Tail {
+	return &Tail{maxLines: maxLines, maxBytes: maxBytes}
+}
+
+func (t *Tail) Write(p []byte) (int, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		t.lines = append(t.lines, line)
+		t.size += len(line) + 1
+	}
+	for len(t.lines) > 1 && (len(t.lines) > t.maxLines || t.size > t.maxBytes) {
+		t.size -= len(t.lines[0]) + 1
+		t.lines = t.lines[1:]
+	}
+	return len(p), nil
+}
+
+func (t *Tail) String() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return strings.Join(t.lines, "\n")
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+)
+
+// Tee returns a logger that also writes text records of level info and above
+// to w.
+func Tee(logger *slog.Logger, w io.Writer) *slog.Logger {
+	copyHandler := slog.NewTextHandler(w, &slog.HandlerOptions{ReplaceAttr: replaceAttr})
+	return slog.New(teeHandler{logger.Handler(), copyHandler})
+}
+
+type teeHandler []slog.Handler
+
+func (h teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, record.Level) {
+			errs = append(errs, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(teeHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (h teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make(teeHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
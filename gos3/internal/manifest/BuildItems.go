@@ -0,0 +1,134 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gos3/internal/archive"
+	"gos3/internal/script"
+)
+
+const (
+	encryptedSuffix = ".cpt"
+	passSuffix      = ".cpt.pass"
+	splitSuffix     = ".cpt-split_parts"
+)
+
+// BuildItems describes the encrypted files of a prepared local backup folder.
+// plaintextSizes holds the size of each file before encryption.
+func BuildItems(localFolder string, plaintextSizes map[string]int64) ([]Item, error) {
+	entries, err := os.ReadDir(localFolder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", localFolder, err)
+	}
+
+	var items []Item
+	for _, entry := range entries {
+		var name string
+		switch {
+		case entry.IsDir() && strings.HasSuffix(entry.Name(), splitSuffix):
+			name = strings.TrimSuffix(entry.Name(), splitSuffix)
+		case !entry.IsDir() && strings.HasSuffix(entry.Name(), encryptedSuffix):
+			name = strings.TrimSuffix(entry.Name(), encryptedSuffix)
+		default:
+			continue
+		}
+
+		item, err := buildItem(localFolder, name, entry.IsDir())
+		if err != nil {
+			return nil, err
+		}
+		item.PlaintextSize = plaintextSizes[name]
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	return items, nil
+}
+
+func buildItem(localFolder, name string, split bool) (Item, error) {
+	item := Item{Name: name, Kind: itemKind(name)}
+
+	// The checksum of the whole ciphertext is kept even for split items so
+	// a joined file can be checked without the parts.
+	whole := sha256.New()
+
+	if split {
+		folder := name + splitSuffix
+		parts, err := os.ReadDir(filepath.Join(localFolder, folder))
+		if err != nil {
+			return item, fmt.Errorf("failed to read %s: %w", folder, err)
+		}
+		sort.Slice(parts, func(i, j int) bool {
+			return parts[i].Name() < parts[j].Name()
+		})
+		for _, part := range parts {
+			key := folder + "/" + part.Name()
+			object, err := hashObject(localFolder, key, whole)
+			if err != nil {
+				return item, err
+			}
+			item.Parts = append(item.Parts, object)
+			item.CiphertextSize += object.Size
+		}
+	} else {
+		object, err := hashObject(localFolder, name+encryptedSuffix, whole)
+		if err != nil {
+			return item, err
+		}
+		item.Data = &object
+		item.CiphertextSize = object.Size
+	}
+	item.SHA256 = hex.EncodeToString(whole.Sum(nil))
+
+	pass, err := hashObject(localFolder, name+passSuffix, nil)
+	if err != nil {
+		return item, err
+	}
+	item.Pass = pass
+
+	return item, nil
+}
+
+func itemKind(name string) string {
+	switch {
+	case strings.HasSuffix(name, script.ArchiveMetadataSuffix):
+		return KindMetadata
+	case strings.HasSuffix(name, script.IncrementalDeletedSuffix):
+		return KindDeletedList
+	case strings.HasSuffix(name, archive.IndexSuffix):
+		return KindIndex
+	default:
+		return KindArchive
+	}
+}
+
+func hashObject(localFolder, key string, extra hash.Hash) (Object, error) {
+	file, err := os.Open(filepath.Join(localFolder, filepath.FromSlash(key)))
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to open %s: %w", key, err)
+	}
+	defer file.Close()
+
+	sum := sha256.New()
+	var writer io.Writer = sum
+	if extra != nil {
+		writer = io.MultiWriter(sum, extra)
+	}
+
+	size, err := io.Copy(writer, file)
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to hash %s: %w", key, err)
+	}
+
+	return Object{Key: key, Size: size, SHA256: hex.EncodeToString(sum.Sum(nil))}, nil
+}
@@ -0,0 +1,157 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gos3/internal/config"
+	"gos3/internal/storage"
+)
+
+// Publish records def in the manifest of dateFolder, replacing an earlier
+// entry for the same definition, and signs it.
+func Publish(def Definition, dateFolder string, cfg config.Config) error {
+	st, err := storage.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	prefix := cfg.S3.BackupFolder + "/" + dateFolder + "/"
+
+	for i := range def.Items {
+		for _, object := range def.Items[i].Objects() {
+			stored, err := st.Stat(prefix + object.Key)
+			if err != nil {
+				return fmt.Errorf("failed to check uploaded object %s: %w", object.Key, err)
+			}
+			if stored.Size != object.Size {
+				return fmt.Errorf("uploaded object %s has %d bytes, expected %d", object.Key, stored.Size, object.Size)
+			}
+			object.ETag = stored.ETag
+		}
+	}
+
+	m, err := Load(st, prefix, cfg)
+	if errors.Is(err, storage.ErrNotFound) {
+		m = &Manifest{}
+	} else if err != nil {
+		return fmt.Errorf("failed to read manifest of %s: %w", dateFolder, err)
+	}
+
+	host, _ := os.Hostname()
+	m.FormatVersion = FormatVersion
+	m.ToolVersion = config.Version
+	m.Host = host
+	m.DateFolder = dateFolder
+	m.UpdatedAt = time.Now().UTC()
+
+	definitions := []Definition{def}
+	for _, existing := range m.Definitions {
+		if existing.Name != def.Name {
+			definitions = append(definitions, existing)
+		}
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name < definitions[j].Name
+	})
+	m.Definitions = definitions
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "gos3-manifest")
+	if err != nil {
+		return fmt.Errorf("failed to create temp folder: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	manifestPath := filepath.Join(tempDir, FileName)
+	err = os.WriteFile(manifestPath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	keyFile := cfg.App.SigningKeyFile
+	if keyFile == "" {
+		keyFile, err = defaultSigningKey(cfg)
+		if err != nil {
+			return err
+		}
+	}
+	signature, err := Sign(data, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to sign manifest: %w", err)
+	}
+	signaturePath := manifestPath + SignatureSuffix
+	err = os.WriteFile(signaturePath, signature, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write manifest signature: %w", err)
+	}
+
+	err = st.Put(manifestPath, prefix+FileName)
+	if err != nil {
+		return fmt.Errorf("failed to upload manifest: %w", err)
+	}
+	err = st.Put(signaturePath, prefix+FileName+SignatureSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to upload manifest signature: %w", err)
+	}
+	return nil
+}
+
+// Load reads the manifest stored below folderPrefix. When
+// app.signingPublicKeyFile is set the signature must be present and valid.
+// A missing manifest yields an error wrapping storage.ErrNotFound.
+func Load(st storage.Storage, folderPrefix string, cfg config.Config) (*Manifest, error) {
+	tempDir, err := os.MkdirTemp("", "gos3-manifest")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp folder: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	manifestPath := filepath.Join(tempDir, FileName)
+	err = st.Get(folderPrefix+FileName, manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	if cfg.App.SigningPublicKeyFile != "" {
+		signaturePath := manifestPath + SignatureSuffix
+		err = st.Get(folderPrefix+FileName+SignatureSuffix, signaturePath)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("manifest of %s is not signed", folderPrefix)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to download manifest signature: %w", err)
+		}
+
+		signature, err := os.ReadFile(signaturePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest signature: %w", err)
+		}
+		err = Verify(data, signature, cfg.App.SigningPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("manifest of %s: %w", folderPrefix, err)
+		}
+	}
+
+	var m Manifest
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &m, nil
+}
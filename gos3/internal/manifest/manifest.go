@@ -0,0 +1,127 @@
+package manifest
+
+import "time"
+
+const (
+	// FileName is the manifest object stored in every backup date folder.
+	FileName = "manifest.json"
+	// SignatureSuffix is appended to FileName for the detached signature.
+	SignatureSuffix = ".sig"
+	// FormatVersion is bumped whenever the manifest layout changes.
+	FormatVersion = 1
+)
+
+const (
+	KindArchive     = "archive"
+	KindMetadata    = "metadata"
+	KindDeletedList = "deleted-list"
+	KindIndex       = "index"
+)
+
+// Manifest describes every definition uploaded into one backup date folder.
+type Manifest struct {
+	FormatVersion int          `json:"formatVersion"`
+	ToolVersion   string       `json:"toolVersion"`
+	Host          string       `json:"host"`
+	DateFolder    string       `json:"dateFolder"`
+	UpdatedAt     time.Time    `json:"updatedAt"`
+	Definitions   []Definition `json:"definitions"`
+}
+
+type Definition struct {
+	Name           string      `json:"name"`
+	Type           string      `json:"type"`
+	Target         string      `json:"target"`
+	KeyFingerprint string      `json:"keyFingerprint"`
+	StartedAt      time.Time   `json:"startedAt"`
+	FinishedAt     time.Time   `json:"finishedAt"`
+	Timings        Timings     `json:"timings"`
+	Containers     []Container `json:"containers"`
+	Volumes        []Volume    `json:"volumes"`
+	Items          []Item      `json:"items"`
+}
+
+type Timings struct {
+	ArchiveSeconds float64 `json:"archiveSeconds"`
+	EncryptSeconds float64 `json:"encryptSeconds"`
+	SplitSeconds   float64 `json:"splitSeconds"`
+	UploadSeconds  float64 `json:"uploadSeconds"`
+}
+
+type Container struct {
+	Name        string   `json:"name"`
+	Image       string   `json:"image"`
+	ImageID     string   `json:"imageId"`
+	RepoDigests []string `json:"repoDigests,omitempty"`
+	Version     string   `json:"version,omitempty"`
+}
+
+// Volume is one archived volume. OriginalSize is the uncompressed tar size,
+// CompressedSize the archive size before encryption.
+type Volume struct {
+	Volume         string  `json:"volume"`
+	Archive        string  `json:"archive"`
+	Codec          string  `json:"codec"`
+	Level          int     `json:"level,omitempty"`
+	Threads        int     `json:"threads,omitempty"`
+	Incremental    bool    `json:"incremental"`
+	OriginalSize   int64   `json:"originalSize"`
+	CompressedSize int64   `json:"compressedSize"`
+	Seconds        float64 `json:"seconds"`
+}
+
+// Item is one encrypted file of the run: the data object (or its split
+// parts) and the encrypted password next to it. Keys are relative to the
+// date folder. BaseFolder is set on incremental archives to the date folder
+// they were diffed against.
+type Item struct {
+	Name           string   `json:"name"`
+	Volume         string   `json:"volume,omitempty"`
+	Kind           string   `json:"kind"`
+	PlaintextSize  int64    `json:"plaintextSize"`
+	CiphertextSize int64    `json:"ciphertextSize"`
+	SHA256         string   `json:"sha256"`
+	BaseFolder     string   `json:"baseFolder,omitempty"`
+	Data           *Object  `json:"data,omitempty"`
+	Parts          []Object `json:"parts,omitempty"`
+	Pass           Object   `json:"pass"`
+}
+
+type Object struct {
+	Key    string `json:"key"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	ETag   string `json:"etag,omitempty"`
+}
+
+// Split reports whether the item was uploaded as split parts.
+func (i Item) Split() bool {
+	return len(i.Parts) > 0
+}
+
+// Objects returns every stored object of the item.
+func (i *Item) Objects() []*Object {
+	var objects []*Object
+	if i.Data != nil {
+		objects = append(objects, i.Data)
+	}
+	for p := range i.Parts {
+		objects = append(objects, &i.Parts[p])
+	}
+	return append(objects, &i.Pass)
+}
+
+// Clone returns a copy of d whose items can be changed without affecting d.
+func (d Definition) Clone() Definition {
+	items := make([]Item, len(d.Items))
+	for i, item := range d.Items {
+		if item.Data != nil {
+			data := *item.Data
+			item.Data = &data
+		}
+		item.Parts = append([]Object(nil), item.Parts...)
+		items[i] = item
+	}
+	d.Items = items
+	return d
+}
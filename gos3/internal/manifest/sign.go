@@ -0,0 +1,151 @@
+package manifest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gos3/internal/config"
+)
+
+// Names of the key pair generated in app.stateFolder when
+// app.signingKeyFile is not set.
+const (
+	DefaultSigningKeyFile       = "signing_key.pem"
+	DefaultSigningPublicKeyFile = "signing_public_key.pem"
+)
+
+// Sign returns a detached signature of data made with the PEM private key in
+// keyFile, compatible with `openssl dgst -sha256 -verify`.
+func Sign(data []byte, keyFile string) ([]byte, error) {
+	block, err := readPEM(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if rsaKey, rsaErr := x509.ParsePKCS1PrivateKey(block.Bytes); rsaErr == nil {
+			key = rsaKey
+		} else if ecKey, ecErr := x509.ParseECPrivateKey(block.Bytes); ecErr == nil {
+			key = ecKey
+		} else {
+			return nil, fmt.Errorf("failed to parse signing key %s: %w", keyFile, err)
+		}
+	}
+
+	digest := sha256.Sum256(data)
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return ed25519.Sign(key, data), nil
+	case *rsa.PrivateKey:
+		return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		return ecdsa.SignASN1(rand.Reader, key, digest[:])
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T", key)
+	}
+}
+
+// Verify checks a signature made by Sign against the PEM public key in
+// publicKeyFile.
+func Verify(data, signature []byte, publicKeyFile string) error {
+	block, err := readPEM(publicKeyFile)
+	if err != nil {
+		return err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key %s: %w", publicKeyFile, err)
+	}
+
+	digest := sha256.Sum256(data)
+	valid := false
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, data, signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest[:], signature)
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+
+	if !valid {
+		return fmt.Errorf("invalid manifest signature")
+	}
+	return nil
+}
+
+// KeyFingerprint returns "SHA256:<hex>" of the DER encoded public key in a PEM
+// file, the same value as
+// `openssl pkey -pubin -in key.pem -outform DER | sha256sum`.
+func KeyFingerprint(publicKeyFile string) (string, error) {
+	block, err := readPEM(publicKeyFile)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(block.Bytes)
+	return "SHA256:" + hex.EncodeToString(sum[:]), nil
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", file)
+	}
+	return block, nil
+}
+
+func defaultSigningKey(cfg config.Config) (string, error) {
+	keyFile := filepath.Join(cfg.App.StateFolder, DefaultSigningKeyFile)
+	if _, err := os.Stat(keyFile); err == nil {
+		return keyFile, nil
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode signing key: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode signing public key: %w", err)
+	}
+
+	err = os.MkdirAll(cfg.App.StateFolder, 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create state folder: %w", err)
+	}
+	publicKeyFile := filepath.Join(cfg.App.StateFolder, DefaultSigningPublicKeyFile)
+	err = os.WriteFile(publicKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to write signing public key: %w", err)
+	}
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to write signing key: %w", err)
+	}
+
+	cfg.Logger().Info("Generated manifest signing key, set app.signingPublicKeyFile to its public key where manifests are verified",
+		"key", keyFile, "public_key", publicKeyFile)
+	return keyFile, nil
+}
@@ -0,0 +1,14 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler serves the gos3 metrics together with the Go runtime and process
+// metrics of the default registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(prometheus.Gatherers{Registry, prometheus.DefaultGatherer}, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Registry holds the gos3 metrics. Vectors only appear once a command set a
+// value, so every textfile holds the metrics of the command that wrote it.
+var Registry = prometheus.NewRegistry()
+
+var (
+	backupRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gos3_backup_runs_total",
+		Help: "Backup runs per definition and status (success, degraded, failed).",
+	}, []string{"definition", "status"})
+
+	lastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gos3_backup_last_success_timestamp_seconds",
+		Help: "Time the last backup of a definition reached a target.",
+	}, []string{"definition", "target"})
+
+	runDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gos3_backup_duration_seconds",
+		Help: "Duration of the last backup run of a definition.",
+	}, []string{"definition"})
+
+	originalBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gos3_backup_volume_original_bytes",
+		Help: "Size of the last archive of a volume before compression.",
+	}, []string{"definition", "volume"})
+
+	compressedBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gos3_backup_volume_compressed_bytes",
+		Help: "Size of the last archive of a volume after compression.",
+	}, []string{"definition", "volume"})
+
+	downtime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gos3_backup_container_downtime_seconds",
+		Help: "Time the containers of a definition were stopped during its last backup.",
+	}, []string{"definition"})
+
+	uploadBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gos3_upload_bytes_total",
+		Help: "Bytes uploaded per target.",
+	}, []string{"target"})
+
+	uploadSeconds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gos3_upload_duration_seconds_total",
+		Help: "Time spent uploading per target.",
+	}, []string{"target"})
+
+	uploadThroughput = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gos3_upload_throughput_bytes_per_second",
+		Help: "Throughput of the last upload of a definition to a target.",
+	}, []string{"definition", "target"})
+
+	uploadRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gos3_upload_retries_total",
+		Help: "Upload attempts repeated after a failure, per storage backend.",
+	}, []string{"storage"})
+
+	bucketSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gos3_bucket_size_bytes",
+		Help: "Stored bytes per backup date folder of a target.",
+	}, []string{"target", "prefix"})
+
+	verifyItems = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gos3_verify_items",
+		Help: "Items checked by the last verification of a target, by result (passed, failed).",
+	}, []string{"target", "result"})
+
+	verifyTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gos3_verify_last_timestamp_seconds",
+		Help: "Time of the last verification of a target.",
+	}, []string{"target"})
+
+	restoreTestPassed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gos3_restore_test_passed",
+		Help: "1 when the last run of a restore test passed, 0 when it failed.",
+	}, []string{"name", "definition"})
+
+	restoreTestTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gos3_restore_test_last_timestamp_seconds",
+		Help: "Time of the last run of a restore test.",
+	}, []string{"name", "definition"})
+
+	restoreTestDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gos3_restore_test_duration_seconds",
+		Help: "Duration of the last run of a restore test.",
+	}, []string{"name", "definition"})
+)
+
+func init() {
+	Registry.MustRegister(
+		backupRuns, lastSuccess, runDuration, originalBytes, compressedBytes, downtime,
+		uploadBytes, uploadSeconds, uploadThroughput, uploadRetries, bucketSize,
+		verifyItems, verifyTimestamp,
+		restoreTestPassed, restoreTestTimestamp, restoreTestDuration,
+	)
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RecordRun counts a finished backup run of a definition.
+func RecordRun(definition, status string, duration time.Duration) {
+	backupRuns.WithLabelValues(definition, status).Inc()
+	runDuration.WithLabelValues(definition).Set(duration.Seconds())
+}
+
+// RecordSuccess marks the time a backup of definition reached target.
+func RecordSuccess(definition, target string, finishedAt time.Time) {
+	lastSuccess.WithLabelValues(definition, target).Set(float64(finishedAt.Unix()))
+}
+
+func RecordVolume(definition, volume string, original, compressed int64) {
+	originalBytes.WithLabelValues(definition, volume).Set(float64(original))
+	compressedBytes.WithLabelValues(definition, volume).Set(float64(compressed))
+}
+
+func RecordDowntime(definition string, stopped time.Duration) {
+	downtime.WithLabelValues(definition).Set(stopped.Seconds())
+}
+
+// RecordUpload adds a finished upload of a definition's run to target.
+func RecordUpload(definition, target string, bytes int64, duration time.Duration) {
+	uploadBytes.WithLabelValues(target).Add(float64(bytes))
+	uploadSeconds.WithLabelValues(target).Add(duration.Seconds())
+	if duration > 0 {
+		uploadThroughput.WithLabelValues(definition, target).Set(float64(bytes) / duration.Seconds())
+	}
+}
+
+func RecordUploadRetry(storage string) {
+	uploadRetries.WithLabelValues(storage).Inc()
+}
+
+// RecordBucketSizes replaces the folder sizes of target, so pruned folders
+// disappear.
+func RecordBucketSizes(target string, sizes map[string]int64) {
+	bucketSize.DeletePartialMatch(prometheus.Labels{"target": target})
+	for prefix, size := range sizes {
+		bucketSize.WithLabelValues(target, prefix).Set(float64(size))
+	}
+}
+
+func RecordVerification(target string, passed, failed int) {
+	verifyItems.WithLabelValues(target, "passed").Set(float64(passed))
+	verifyItems.WithLabelValues(target, "failed").Set(float64(failed))
+	verifyTimestamp.WithLabelValues(target).Set(float64(time.Now().Unix()))
+}
+
+func RecordRestoreTest(name, definition string, passed bool, startedAt time.Time, duration time.Duration) {
+	value := 0.0
+	if passed {
+		value = 1
+	}
+	restoreTestPassed.WithLabelValues(name, definition).Set(value)
+	restoreTestTimestamp.WithLabelValues(name, definition).Set(float64(startedAt.Unix()))
+	restoreTestDuration.WithLabelValues(name, definition).Set(duration.Seconds())
+}
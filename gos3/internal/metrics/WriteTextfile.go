@@ -0,0 +1,19 @@
+package metrics
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WriteTextfile atomically writes the metrics set by a one-shot command to
+// "gos3_<command>.prom" in folder for node_exporter's textfile collector.
+func WriteTextfile(folder, command string) error {
+	path := filepath.Join(folder, "gos3_"+command+".prom")
+	err := prometheus.WriteToTextfile(path, Registry)
+	if err != nil {
+		return fmt.Errorf("failed to write metrics textfile: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package notify
+
+import (
+	"fmt"
+
+	"gos3/internal/config"
+)
+
+// Deliver renders event with the channel's template and sends it, whether
+// or not the channel wants the event.
+func Deliver(channel config.NotificationChannel, event Event) error {
+	event = complete(event)
+	title, body, err := render(channel, event)
+	if err != nil {
+		return err
+	}
+
+	switch channel.Type {
+	case "webhook":
+		return sendWebhook(channel, event, title, body)
+	case "slack", "mattermost":
+		return sendSlack(channel, title, body)
+	case "ntfy":
+		return sendNtfy(channel, event, title, body)
+	case "gotify":
+		return sendGotify(channel, event, title, body)
+	case "smtp":
+		return sendSMTP(channel, title, body)
+	default:
+		return fmt.Errorf("unknown notification channel type: %s", channel.Type)
+	}
+}
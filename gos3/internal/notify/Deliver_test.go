@@ -0,0 +1,256 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gos3/internal/config"
+)
+
+type request struct {
+	path    string
+	headers http.Header
+	body    string
+}
+
+func startHTTP(t *testing.T) (string, <-chan request) {
+	t.Helper()
+	requests := make(chan request, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{path: r.URL.Path, headers: r.Header, body: string(body)}
+		if r.URL.Query().Has("fail") {
+			http.Error(w, "rejected", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, requests
+}
+
+func receive(t *testing.T, requests <-chan request) request {
+	t.Helper()
+	select {
+	case r := <-requests:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("no request received")
+		return request{}
+	}
+}
+
+var failedRun = Event{
+	Type:       EventRunFailed,
+	Definition: "db",
+	Host:       "host1",
+	Error:      "docker stop failed",
+	Duration:   1500 * time.Millisecond,
+}
+
+func TestDeliverWebhook(t *testing.T) {
+	url, requests := startHTTP(t)
+	t.Setenv("GOS3_TEST_TOKEN", "token1")
+	channel := config.NotificationChannel{
+		Type:    "webhook",
+		URL:     url + "/hook",
+		Headers: map[string]string{"X-Custom": "yes"},
+		Token:   config.SecretSource{Env: "GOS3_TEST_TOKEN"},
+	}
+
+	err := Deliver(channel, failedRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := receive(t, requests)
+	if r.headers.Get("Authorization") != "Bearer token1" || r.headers.Get("X-Custom") != "yes" {
+		t.Errorf("headers = %v", r.headers)
+	}
+	var payload map[string]any
+	err = json.Unmarshal([]byte(r.body), &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"event":           EventRunFailed,
+		"severity":        SeverityError,
+		"definition":      "db",
+		"host":            "host1",
+		"title":           "Backup db failed on host1",
+		"message":         "docker stop failed",
+		"durationSeconds": 1.5,
+	}
+	for key, value := range want {
+		if payload[key] != value {
+			t.Errorf("payload[%s] = %v, want %v", key, payload[key], value)
+		}
+	}
+
+	channel.URL = url + "/hook?fail"
+	err = Deliver(channel, failedRun)
+	if err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("Deliver to a failing endpoint = %v, want the response in the error", err)
+	}
+}
+
+func TestDeliverSlack(t *testing.T) {
+	url, requests := startHTTP(t)
+	channel := config.NotificationChannel{
+		Type: "slack",
+		URL:  url,
+		Templates: map[string]string{
+			EventRunSucceeded: "{{.Definition}} stored {{size .Bytes}} on {{join .Targets \" and \"}}\nin {{.Date}}",
+		},
+	}
+
+	err := Deliver(channel, Event{Type: EventRunSucceeded, Definition: "db", Bytes: 3 * 1024 * 1024, Targets: []string{"a", "b"}, Date: "2026-01-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]string
+	json.Unmarshal([]byte(receive(t, requests).body), &payload)
+	if want := "*db stored 3.0 MiB on a and b*\nin 2026-01-01"; payload["text"] != want {
+		t.Errorf("text = %q, want %q", payload["text"], want)
+	}
+
+	channel.Templates[EventRunSucceeded] = "{{.Missing}}"
+	err = Deliver(channel, Event{Type: EventRunSucceeded})
+	if err == nil {
+		t.Error("Deliver with a broken template succeeded")
+	}
+}
+
+func TestDeliverNtfy(t *testing.T) {
+	url, requests := startHTTP(t)
+	channel := config.NotificationChannel{Type: "ntfy", URL: url + "/backups"}
+
+	err := Deliver(channel, Event{Type: EventRunPartial, Definition: "db", Host: "host1", FailedTargets: []string{"offsite"}, Error: "timeout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := receive(t, requests)
+	if r.path != "/backups" {
+		t.Errorf("path = %s", r.path)
+	}
+	if r.headers.Get("Title") != "Backup db reached only some targets on host1" || r.headers.Get("Priority") != "high" || r.headers.Get("Tags") != "warning" {
+		t.Errorf("headers = %v", r.headers)
+	}
+	if r.body != "Failed targets: offsite\ntimeout" {
+		t.Errorf("body = %q", r.body)
+	}
+}
+
+func TestDeliverGotify(t *testing.T) {
+	url, requests := startHTTP(t)
+	t.Setenv("GOS3_TEST_TOKEN", "apptoken")
+	channel := config.NotificationChannel{Type: "gotify", URL: url + "/", Token: config.SecretSource{Env: "GOS3_TEST_TOKEN"}}
+
+	err := Deliver(channel, Event{Type: EventRunStarted, Definition: "db", Host: "host1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := receive(t, requests)
+	if r.path != "/message" || r.headers.Get("X-Gotify-Key") != "apptoken" {
+		t.Errorf("path = %s, headers = %v", r.path, r.headers)
+	}
+	var payload map[string]any
+	json.Unmarshal([]byte(r.body), &payload)
+	if payload["title"] != "Backup db started on host1" || payload["message"] != "Backup db started on host1" || payload["priority"] != 2.0 {
+		t.Errorf("payload = %v", payload)
+	}
+}
+
+// startSMTP accepts one mail without TLS or authentication and returns its
+// data.
+func startSMTP(t *testing.T) (config.SMTPConfig, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		reply("220 stub")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					messages <- data.String()
+					reply("250 queued")
+				} else {
+					data.WriteString(line)
+				}
+				continue
+			}
+			switch command := strings.ToUpper(strings.Fields(line)[0]); command {
+			case "DATA":
+				inData = true
+				reply("354 go ahead")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return config.SMTPConfig{
+		Host: host,
+		Port: portNumber,
+		From: "backup@example.com",
+		To:   []string{"ops@example.com", "dev@example.com"},
+		TLS:  "none",
+	}, messages
+}
+
+func TestDeliverSMTP(t *testing.T) {
+	settings, messages := startSMTP(t)
+	channel := config.NotificationChannel{Type: "smtp", SMTP: settings}
+
+	err := Deliver(channel, Event{Type: EventRetentionPruned, Target: "offsite", Details: []string{"2026-01-01", "2026-01-02"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var message string
+	select {
+	case message = <-messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail received")
+	}
+	for _, want := range []string{
+		"From: backup@example.com\r\n",
+		"To: ops@example.com, dev@example.com\r\n",
+		"Subject: Pruned 2 backup folders from offsite\r\n",
+		"\r\n\r\n2026-01-01\r\n2026-01-02\r\n",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("mail does not contain %q:\n%s", want, message)
+		}
+	}
+}
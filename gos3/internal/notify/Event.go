@@ -0,0 +1,57 @@
+package notify
+
+import "time"
+
+const (
+	EventRunStarted         = "run_started"
+	EventRunSucceeded       = "run_succeeded"
+	EventRunPartial         = "run_partial"
+	EventRunFailed          = "run_failed"
+	EventVerificationFailed = "verification_failed"
+	EventRetentionPruned    = "retention_pruned"
+)
+
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// EventTypes lists every event in the order they are documented.
+var EventTypes = []string{
+	EventRunStarted, EventRunSucceeded, EventRunPartial, EventRunFailed,
+	EventVerificationFailed, EventRetentionPruned,
+}
+
+var eventSeverities = map[string]string{
+	EventRunStarted:         SeverityInfo,
+	EventRunSucceeded:       SeverityInfo,
+	EventRunPartial:         SeverityWarning,
+	EventRunFailed:          SeverityError,
+	EventVerificationFailed: SeverityError,
+	EventRetentionPruned:    SeverityInfo,
+}
+
+var severityRanks = map[string]int{
+	SeverityInfo:    0,
+	SeverityWarning: 1,
+	SeverityError:   2,
+}
+
+// Event is what happened, as templates and the JSON webhook see it. Fields
+// that do not apply to an event are empty.
+type Event struct {
+	Type          string        `json:"event"`
+	Severity      string        `json:"severity"`
+	Time          time.Time     `json:"time"`
+	Host          string        `json:"host"`
+	Definition    string        `json:"definition,omitempty"`
+	Target        string        `json:"target,omitempty"`
+	Targets       []string      `json:"targets,omitempty"`
+	FailedTargets []string      `json:"failedTargets,omitempty"`
+	Date          string        `json:"date,omitempty"`
+	Error         string        `json:"error,omitempty"`
+	Duration      time.Duration `json:"-"`
+	Bytes         int64         `json:"bytes,omitempty"`
+	Details       []string      `json:"details,omitempty"`
+}
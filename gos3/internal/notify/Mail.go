@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gos3/internal/config"
+)
+
+// Mail sends body with the given content type, e.g. "text/html", through
+// the SMTP server of settings.
+func Mail(settings config.SMTPConfig, subject, body, contentType string) error {
+	if settings.Host == "" || settings.From == "" || len(settings.To) == 0 {
+		return fmt.Errorf("smtp channel needs host, from and to")
+	}
+	port := settings.Port
+	if port == 0 {
+		port = 587
+		if settings.TLS == "tls" {
+			port = 465
+		}
+	}
+	address := net.JoinHostPort(settings.Host, strconv.Itoa(port))
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+	if settings.TLS == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, &tls.Config{ServerName: settings.Host})
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	client, err := smtp.NewClient(conn, settings.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to greet %s: %w", address, err)
+	}
+	defer client.Close()
+
+	switch settings.TLS {
+	case "", "starttls":
+		err = client.StartTLS(&tls.Config{ServerName: settings.Host})
+		if err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	case "tls", "none":
+	default:
+		return fmt.Errorf("invalid smtp tls mode: %s", settings.TLS)
+	}
+
+	if settings.Username != "" {
+		password, err := settings.Password.Resolve()
+		if err != nil {
+			return fmt.Errorf("failed to read smtp password: %w", err)
+		}
+		err = client.Auth(smtp.PlainAuth("", settings.Username, password, settings.Host))
+		if err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	err = client.Mail(settings.From)
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	for _, to := range settings.To {
+		err = client.Rcpt(to)
+		if err != nil {
+			return fmt.Errorf("failed to send mail to %s: %w", to, err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	_, err = writer.Write(mailMessage(settings, subject, body, contentType))
+	if err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return client.Quit()
+}
+
+func mailMessage(settings config.SMTPConfig, subject, body, contentType string) []byte {
+	var message strings.Builder
+	message.WriteString("From: " + settings.From + "\r\n")
+	message.WriteString("To: " + strings.Join(settings.To, ", ") + "\r\n")
+	message.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	message.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: " + contentType + "; charset=utf-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(strings.ReplaceAll(body, "\n", "\r\n") + "\r\n")
+	return []byte(message.String())
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"gos3/internal/config"
+	"os"
+	"slices"
+	"time"
+)
+
+// Send delivers event to every configured channel that wants it. Delivery
+// failures are logged, never returned.
+func Send(cfg config.Config, event Event) {
+	if len(cfg.Notifications.Channels) == 0 {
+		return
+	}
+
+	event = complete(event)
+	for _, channel := range cfg.Notifications.Channels {
+		if !Wants(channel, event.Type) {
+			continue
+		}
+		err := Deliver(channel, event)
+		if err != nil {
+			cfg.Logger().Warn("Failed to send notification", "channel", channel.Name, "event", event.Type, "error", err)
+		}
+	}
+}
+
+// Wants reports whether channel sends events of eventType: it must be listed
+// in Events (all events when empty) and reach MinSeverity.
+func Wants(channel config.NotificationChannel, eventType string) bool {
+	if len(channel.Events) > 0 && !slices.Contains(channel.Events, eventType) {
+		return false
+	}
+	if channel.MinSeverity == "" {
+		return true
+	}
+	return severityRanks[eventSeverities[eventType]] >= severityRanks[channel.MinSeverity]
+}
+
+func complete(event Event) Event {
+	event.Severity = eventSeverities[event.Type]
+	event.Duration = event.Duration.Round(time.Millisecond)
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.Host == "" {
+		event.Host, _ = os.Hostname()
+	}
+	return event
+}
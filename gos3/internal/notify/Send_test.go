@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gos3/internal/config"
+)
+
+func TestWants(t *testing.T) {
+	tests := []struct {
+		channel config.NotificationChannel
+		event   string
+		want    bool
+	}{
+		{config.NotificationChannel{}, EventRunStarted, true},
+		{config.NotificationChannel{Events: []string{EventRunFailed}}, EventRunFailed, true},
+		{config.NotificationChannel{Events: []string{EventRunFailed}}, EventRunSucceeded, false},
+		{config.NotificationChannel{MinSeverity: SeverityWarning}, EventRunSucceeded, false},
+		{config.NotificationChannel{MinSeverity: SeverityWarning}, EventRunPartial, true},
+		{config.NotificationChannel{MinSeverity: SeverityWarning}, EventVerificationFailed, true},
+		{config.NotificationChannel{MinSeverity: SeverityError}, EventRunPartial, false},
+		{config.NotificationChannel{Events: []string{EventRunStarted}, MinSeverity: SeverityError}, EventRunStarted, false},
+	}
+	for _, test := range tests {
+		if got := Wants(test.channel, test.event); got != test.want {
+			t.Errorf("Wants(events %v, minSeverity %q, %s) = %v, want %v", test.channel.Events, test.channel.MinSeverity, test.event, got, test.want)
+		}
+	}
+}
+
+func TestSendFiltersChannels(t *testing.T) {
+	url, requests := startHTTP(t)
+	var cfg config.Config
+	cfg.Notifications.Channels = []config.NotificationChannel{
+		{Name: "all", Type: "webhook", URL: url + "/all"},
+		{Name: "errors", Type: "webhook", URL: url + "/errors", MinSeverity: SeverityError},
+		{Name: "pruned", Type: "webhook", URL: url + "/pruned", Events: []string{EventRetentionPruned}},
+	}
+
+	Send(cfg, Event{Type: EventRunFailed, Definition: "db"})
+	var paths []string
+	for range 2 {
+		r := receive(t, requests)
+		paths = append(paths, r.path)
+		var payload Event
+		json.Unmarshal([]byte(r.body), &payload)
+		if payload.Severity != SeverityError || payload.Host == "" || payload.Time.IsZero() {
+			t.Errorf("event was not completed: %+v", payload)
+		}
+	}
+	if paths[0] != "/all" || paths[1] != "/errors" {
+		t.Errorf("run_failed went to %v, want [/all /errors]", paths)
+	}
+	select {
+	case r := <-requests:
+		t.Errorf("unexpected request to %s", r.path)
+	default:
+	}
+}
+
+// The configuration validates channels against its own copy of the event
+// and severity names.
+func TestConfigKnowsEvents(t *testing.T) {
+	for _, event := range EventTypes {
+		channel := config.NotificationChannel{Type: "webhook", Events: []string{event}, MinSeverity: eventSeverities[event]}
+		err := channel.Validate()
+		if err != nil {
+			t.Errorf("event %s: %v", event, err)
+		}
+	}
+	for severity := range severityRanks {
+		channel := config.NotificationChannel{Type: "webhook", MinSeverity: severity}
+		err := channel.Validate()
+		if err != nil {
+			t.Errorf("severity %s: %v", severity, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"gos3/internal/config"
+)
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+func postJSON(url string, payload any, headers map[string]string) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	headers["Content-Type"] = "application/json"
+	return post(url, data, headers)
+}
+
+// The start of a non-2xx response is included in the error.
+func post(url string, body []byte, headers map[string]string) error {
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		text, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("%s returned %s: %s", request.URL.Host, response.Status, bytes.TrimSpace(text))
+	}
+	return nil
+}
+
+func channelHeaders(channel config.NotificationChannel) map[string]string {
+	headers := make(map[string]string, len(channel.Headers)+2)
+	for name, value := range channel.Headers {
+		headers[name] = value
+	}
+	return headers
+}
+
+func channelToken(channel config.NotificationChannel) (string, error) {
+	if !channel.Token.IsSet() {
+		return "", nil
+	}
+	token, err := channel.Token.Resolve()
+	if err != nil {
+		return "", fmt.Errorf("failed to read token: %w", err)
+	}
+	return token, nil
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"gos3/internal/config"
+)
+
+var defaultTemplates = map[string]string{
+	EventRunStarted: `Backup {{.Definition}} started on {{.Host}}`,
+	EventRunSucceeded: `Backup {{.Definition}} succeeded on {{.Host}}
+
+{{size .Bytes}} stored in {{.Date}} on {{join .Targets ", "}} in {{.Duration}}.`,
+	EventRunPartial: `Backup {{.Definition}} reached only some targets on {{.Host}}
+
+Failed targets: {{join .FailedTargets ", "}}
+{{.Error}}`,
+	EventRunFailed: `Backup {{.Definition}} failed on {{.Host}}
+
+{{.Error}}`,
+	EventVerificationFailed: `Verification {{if .Definition}}of {{.Definition}} {{end}}failed on {{.Host}}
+{{range .Details}}
+{{.}}{{end}}`,
+	EventRetentionPruned: `Pruned {{len .Details}} backup folders from {{.Target}}
+{{range .Details}}
+{{.}}{{end}}`,
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"size": formatSize,
+}
+
+// The first line is the title (mail subject, push title), the rest the body;
+// a single line is used as both.
+func render(channel config.NotificationChannel, event Event) (title, body string, err error) {
+	text, ok := channel.Templates[event.Type]
+	if !ok {
+		text = defaultTemplates[event.Type]
+	}
+
+	tmpl, err := template.New(event.Type).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid %s template: %w", event.Type, err)
+	}
+	var output bytes.Buffer
+	err = tmpl.Execute(&output, event)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render %s template: %w", event.Type, err)
+	}
+
+	message := strings.TrimSpace(output.String())
+	title, body, _ = strings.Cut(message, "\n")
+	body = strings.TrimSpace(body)
+	if body == "" {
+		body = title
+	}
+	return title, body, nil
+}
+
+// formatSize matches s3.FormatSize, which this package cannot import.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
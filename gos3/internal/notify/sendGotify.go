@@ -0,0 +1,28 @@
+package notify
+
+import (
+	"strings"
+
+	"gos3/internal/config"
+)
+
+var gotifyPriorities = map[string]int{
+	SeverityInfo:    2,
+	SeverityWarning: 5,
+	SeverityError:   8,
+}
+
+func sendGotify(channel config.NotificationChannel, event Event, title, body string) error {
+	headers := channelHeaders(channel)
+	token, err := channelToken(channel)
+	if err != nil {
+		return err
+	}
+	headers["X-Gotify-Key"] = token
+
+	return postJSON(strings.TrimRight(channel.URL, "/")+"/message", map[string]any{
+		"title":    title,
+		"message":  body,
+		"priority": gotifyPriorities[event.Severity],
+	}, headers)
+}
@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"gos3/internal/config"
+)
+
+var ntfyPriorities = map[string]string{
+	SeverityInfo:    "default",
+	SeverityWarning: "high",
+	SeverityError:   "urgent",
+}
+
+var ntfyTags = map[string]string{
+	SeverityInfo:    "white_check_mark",
+	SeverityWarning: "warning",
+	SeverityError:   "rotating_light",
+}
+
+func sendNtfy(channel config.NotificationChannel, event Event, title, body string) error {
+	headers := channelHeaders(channel)
+	headers["Title"] = title
+	headers["Priority"] = ntfyPriorities[event.Severity]
+	headers["Tags"] = ntfyTags[event.Severity]
+
+	token, err := channelToken(channel)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+
+	return post(channel.URL, []byte(body), headers)
+}
@@ -0,0 +1,9 @@
+package notify
+
+import (
+	"gos3/internal/config"
+)
+
+func sendSMTP(channel config.NotificationChannel, title, body string) error {
+	return Mail(channel.SMTP, title, body, "text/plain")
+}
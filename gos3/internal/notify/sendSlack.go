@@ -0,0 +1,14 @@
+package notify
+
+import (
+	"gos3/internal/config"
+)
+
+// Slack and Mattermost incoming webhooks both accept {"text": ...}.
+func sendSlack(channel config.NotificationChannel, title, body string) error {
+	text := "*" + title + "*"
+	if body != title {
+		text += "\n" + body
+	}
+	return postJSON(channel.URL, map[string]string{"text": text}, channelHeaders(channel))
+}
@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"gos3/internal/config"
+)
+
+type webhookPayload struct {
+	Event
+	Title           string  `json:"title"`
+	Message         string  `json:"message"`
+	DurationSeconds float64 `json:"durationSeconds,omitempty"`
+}
+
+func sendWebhook(channel config.NotificationChannel, event Event, title, body string) error {
+	headers := channelHeaders(channel)
+	token, err := channelToken(channel)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+
+	return postJSON(channel.URL, webhookPayload{
+		Event:           event,
+		Title:           title,
+		Message:         body,
+		DurationSeconds: event.Duration.Seconds(),
+	}, headers)
+}
@@ -0,0 +1,54 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"gos3/internal/config"
+)
+
+var flushing sync.Mutex
+
+// Flush sends the queued reports to the hub, oldest first. It stops at the
+// first report that could not be delivered and returns why.
+func Flush(cfg config.Config) error {
+	flushing.Lock()
+	defer flushing.Unlock()
+
+	paths, err := queuedReports(cfg)
+	if err != nil || len(paths) == 0 {
+		return err
+	}
+
+	token, err := cfg.Report.Token.Resolve()
+	if err != nil {
+		return fmt.Errorf("failed to read report token: %w", err)
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read queued report: %w", err)
+		}
+
+		err = deliver(client, cfg.Report.URL, token, data)
+		var rejected *rejectedError
+		if errors.As(err, &rejected) {
+			cfg.Logger().Error("Dropping report the hub rejected", "file", path, "error", err)
+		} else if err != nil {
+			return fmt.Errorf("failed to send report to hub, %d queued: %w", len(paths)-i, err)
+		}
+		os.Remove(path)
+	}
+
+	if len(paths) > 1 {
+		cfg.Logger().Info("Sent queued reports to hub", "reports", len(paths))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"gos3/internal/config"
+	"gos3/internal/manifest"
+)
+
+// The statuses of a run, as reported by backupops.
+const (
+	StatusSuccess  = "success"
+	StatusDegraded = "degraded"
+	StatusFailed   = "failed"
+)
+
+// Report summarizes one run of a backup definition on one host. Agents send
+// it to the hub, which files it under Host and Definition.
+type Report struct {
+	// ID identifies the run of the definition, so a report the hub already
+	// has replaces the stored copy instead of counting twice.
+	ID         string    `json:"id"`
+	Host       string    `json:"host"`
+	RunID      string    `json:"runId"`
+	Definition string    `json:"definition"`
+	Status     string    `json:"status"`
+	Date       string    `json:"date,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Bytes      int64     `json:"bytes"`
+	Error      string    `json:"error,omitempty"`
+	Targets    []Target  `json:"targets,omitempty"`
+	Version    string    `json:"version"`
+	// Manifest is the run manifest entry of the definition, nil when the
+	// run failed before it was built.
+	Manifest *manifest.Definition `json:"manifest,omitempty"`
+	// ReceivedAt is set by the hub.
+	ReceivedAt time.Time `json:"receivedAt"`
+}
+
+// Target is the upload of the run to one target.
+type Target struct {
+	Name            string  `json:"name"`
+	Error           string  `json:"error,omitempty"`
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+// New starts a report of a run of definition on this host.
+func New(runID, definition string, cfg config.Config) Report {
+	host := cfg.Report.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	return Report{
+		ID:         runID + "/" + definition,
+		Host:       host,
+		RunID:      runID,
+		Definition: definition,
+		Version:    config.Version,
+	}
+}
+
+func (r Report) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// Validate checks the fields the hub files a report under.
+func (r Report) Validate() error {
+	switch {
+	case r.ID == "":
+		return errors.New("report has no id")
+	case r.Host == "":
+		return errors.New("report has no host")
+	case r.Definition == "":
+		return errors.New("report has no definition")
+	case r.Status == "":
+		return errors.New("report has no status")
+	case r.FinishedAt.IsZero():
+		return errors.New("report has no finish time")
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package report
+
+import (
+	"gos3/internal/config"
+)
+
+// Submit queues the report and sends the queue to the hub. A hub that
+// cannot be reached is logged; the report goes out with the next Flush.
+func Submit(r Report, cfg config.Config) {
+	err := enqueue(r, cfg)
+	if err != nil {
+		cfg.Logger().Error("Failed to queue report for hub", "error", err)
+		return
+	}
+
+	err = Flush(cfg)
+	if err != nil {
+		cfg.Logger().Warn("Hub unreachable, report queued", "error", err)
+	}
+}
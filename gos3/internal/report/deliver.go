@@ -0,0 +1,75 @@
+package report
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gos3/internal/config"
+)
+
+// rejectedError is a report the hub refused for good.
+type rejectedError struct {
+	status string
+	reason string
+}
+
+func (e *rejectedError) Error() string {
+	return fmt.Sprintf("hub rejected report: %s: %s", e.status, e.reason)
+}
+
+func newClient(cfg config.Config) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Report.CAFile != "" {
+		pem, err := os.ReadFile(cfg.Report.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hub CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.Report.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &http.Client{Transport: transport, Timeout: 30 * time.Second}, nil
+}
+
+// Authentication failures are not a rejectedError; they go through once the
+// token is fixed.
+func deliver(client *http.Client, hubURL, token string, data []byte) error {
+	request, err := http.NewRequest(http.MethodPost, strings.TrimRight(hubURL, "/")+"/api/v1/reports", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := client.Do(request)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 200 && response.StatusCode <= 299 {
+		return nil
+	}
+	text, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	reason := string(bytes.TrimSpace(text))
+	switch response.StatusCode {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return &rejectedError{status: response.Status, reason: reason}
+	}
+	return fmt.Errorf("hub returned %s: %s", response.Status, reason)
+}
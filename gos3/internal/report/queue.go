@@ -0,0 +1,91 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gos3/internal/config"
+)
+
+const defaultMaxQueued = 1000
+
+func queueFolder(cfg config.Config) string {
+	if cfg.Report.QueueFolder != "" {
+		return cfg.Report.QueueFolder
+	}
+	return filepath.Join(cfg.App.StateFolder, "report-queue")
+}
+
+// File names start with the time so the queue is sent in order.
+func enqueue(r Report, cfg config.Config) error {
+	folder := queueFolder(cfg)
+	err := os.MkdirAll(folder, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create report queue: %w", err)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	name := fmt.Sprintf("%d-%s.json", time.Now().UnixNano(), strings.ReplaceAll(r.ID, "/", "_"))
+	temp := filepath.Join(folder, "."+name)
+	err = os.WriteFile(temp, data, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to queue report: %w", err)
+	}
+	err = os.Rename(temp, filepath.Join(folder, name))
+	if err != nil {
+		return fmt.Errorf("failed to queue report: %w", err)
+	}
+
+	return trimQueue(cfg)
+}
+
+func queuedReports(cfg config.Config) ([]string, error) {
+	entries, err := os.ReadDir(queueFolder(cfg))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report queue: %w", err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".json") && !strings.HasPrefix(entry.Name(), ".") {
+			paths = append(paths, filepath.Join(queueFolder(cfg), entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// trimQueue keeps a hub that stays unreachable from filling the disk.
+func trimQueue(cfg config.Config) error {
+	maxQueued := cfg.Report.MaxQueued
+	if maxQueued <= 0 {
+		maxQueued = defaultMaxQueued
+	}
+
+	paths, err := queuedReports(cfg)
+	if err != nil {
+		return err
+	}
+	if len(paths) <= maxQueued {
+		return nil
+	}
+
+	dropped := paths[:len(paths)-maxQueued]
+	for _, path := range dropped {
+		os.Remove(path)
+	}
+	cfg.Logger().Warn("Report queue is full, dropped the oldest reports", "dropped", len(dropped), "max_queued", maxQueued)
+	return nil
+}
@@ -0,0 +1,39 @@
+package s3
+
+import (
+	"strings"
+
+	"gos3/internal/config"
+	"gos3/internal/storage"
+)
+
+// BackupFolderSizes returns the stored bytes of every date folder of cfg's
+// target, keyed by folder prefix, from the catalog when it covers the target.
+func BackupFolderSizes(cfg config.Config) (map[string]int64, error) {
+	backupPrefix := cfg.S3.BackupFolder + "/"
+	sizes := make(map[string]int64)
+
+	if items, ok := CatalogListing(cfg, backupPrefix, "/"); ok {
+		for _, item := range items {
+			sizes[item.Name] = item.Size
+		}
+		return sizes, nil
+	}
+
+	st, err := storage.Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+
+	it := storage.NewIterator(st, storage.ListOptions{Prefix: backupPrefix})
+	for it.Next() {
+		for _, object := range it.Page().Objects {
+			folder, _, found := strings.Cut(strings.TrimPrefix(object.Key, backupPrefix), "/")
+			if found {
+				sizes[backupPrefix+folder+"/"] += object.Size
+			}
+		}
+	}
+	return sizes, it.Err()
+}
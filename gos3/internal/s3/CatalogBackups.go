@@ -0,0 +1,304 @@
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gos3/internal/catalog"
+	"gos3/internal/config"
+	"gos3/internal/manifest"
+	"gos3/internal/storage"
+)
+
+// CatalogBackupDates returns the backup dates of cfg's target from the local
+// catalog when it covers the target, and from a bucket listing otherwise.
+func CatalogBackupDates(cfg config.Config) ([]BackupDate, error) {
+	cat := openCoveringCatalog(cfg)
+	if cat == nil {
+		return GetBackupDates(cfg)
+	}
+	defer cat.Close()
+
+	folders, err := cat.Dates(cfg.CurrentTarget())
+	if err != nil {
+		cfg.Logger().Warn("Failed to read catalog, listing the bucket", "target", cfg.CurrentTarget(), "error", err)
+		return GetBackupDates(cfg)
+	}
+
+	dates := make([]BackupDate, 0, len(folders))
+	for _, folder := range folders {
+		dates = append(dates, BackupDate{FolderName: folder})
+	}
+	return dates, nil
+}
+
+// CatalogBackupItems returns the items of a date folder from the local
+// catalog, reading folders it only knows by name from the bucket.
+func CatalogBackupItems(cfg config.Config, date BackupDate) ([]BackupItem, error) {
+	cat := openCoveringCatalog(cfg)
+	if cat == nil {
+		return GetBackupItems(cfg, date)
+	}
+	defer cat.Close()
+
+	runs, err := cat.DateRuns(cfg.CurrentTarget(), date.FolderName)
+	if err != nil {
+		cfg.Logger().Warn("Failed to read catalog, reading the bucket", "target", cfg.CurrentTarget(), "error", err)
+		return GetBackupItems(cfg, date)
+	}
+
+	m := &manifest.Manifest{DateFolder: date.FolderName}
+	for _, run := range runs {
+		if run.Legacy {
+			return GetBackupItems(cfg, date)
+		}
+		m.Definitions = append(m.Definitions, run.Definition)
+	}
+	if len(m.Definitions) == 0 {
+		return GetBackupItems(cfg, date)
+	}
+
+	return getBackupItemsFromManifest(cfg.S3.BackupFolder+"/"+date.FolderName+"/", m), nil
+}
+
+// CatalogListing answers a `gos3 list` of the backup folder or of one date
+// folder from the catalog. ok is false when the bucket has to be listed.
+func CatalogListing(cfg config.Config, prefix, delimiter string) (items []S3Item, ok bool) {
+	backupPrefix := cfg.S3.BackupFolder + "/"
+	if delimiter != "/" || !strings.HasPrefix(prefix, backupPrefix) {
+		return nil, false
+	}
+	date, isDate := strings.CutSuffix(strings.TrimPrefix(prefix, backupPrefix), "/")
+	if isDate && (date == "" || strings.Contains(date, "/")) {
+		return nil, false
+	}
+	if !isDate && prefix != backupPrefix {
+		return nil, false
+	}
+
+	cat := openCoveringCatalog(cfg)
+	if cat == nil {
+		return nil, false
+	}
+	defer cat.Close()
+
+	if !isDate {
+		runs, err := cat.Runs(catalog.Filter{Target: cfg.CurrentTarget(), Status: catalog.StatusSuccess})
+		if err != nil {
+			cfg.Logger().Warn("Failed to read catalog, listing the bucket", "target", cfg.CurrentTarget(), "error", err)
+			return nil, false
+		}
+		return catalogDateFolders(backupPrefix, runs), true
+	}
+
+	runs, err := cat.DateRuns(cfg.CurrentTarget(), date)
+	if err != nil || len(runs) == 0 {
+		return nil, false
+	}
+	for _, run := range runs {
+		if run.Legacy {
+			return nil, false
+		}
+		items = append(items, catalogRunObjects(prefix, run)...)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	return items, true
+}
+
+// A rerun into the same folder replaced the objects of the earlier one, so only
+// the latest run of each definition counts.
+func catalogDateFolders(backupPrefix string, runs []catalog.Run) []S3Item {
+	var items []S3Item
+	index := make(map[string]int)
+	counted := make(map[string]bool)
+	for _, run := range runs {
+		key := run.Date + "/" + run.Definition.Name
+		if counted[key] {
+			continue
+		}
+		counted[key] = true
+
+		i, found := index[run.Date]
+		if !found {
+			i = len(items)
+			index[run.Date] = i
+			items = append(items, S3Item{Name: backupPrefix + run.Date + "/", IsFolder: true})
+		}
+		items[i].Size += run.Size
+		if finished := run.Definition.FinishedAt; finished.UnixMilli() > items[i].LastModified {
+			items[i].LastModified = finished.UnixMilli()
+			items[i].LastModifiedStr = finished.UTC().Format("2006-01-02 15:04:05.000")
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	return items
+}
+
+// Split items show as one folder holding the size of all parts, as in a
+// delimited listing.
+func catalogRunObjects(prefix string, run catalog.Run) []S3Item {
+	finished := run.Definition.FinishedAt
+	object := func(key string, size int64, folder bool) S3Item {
+		return S3Item{
+			Name:            prefix + key,
+			IsFolder:        folder,
+			LastModified:    finished.UnixMilli(),
+			LastModifiedStr: finished.UTC().Format("2006-01-02 15:04:05.000"),
+			Size:            size,
+		}
+	}
+
+	var items []S3Item
+	for _, item := range run.Definition.Items {
+		if item.Split() {
+			folder, _, _ := strings.Cut(item.Parts[0].Key, "/")
+			items = append(items, object(folder+"/", item.CiphertextSize, true))
+		} else if item.Data != nil {
+			items = append(items, object(item.Data.Key, item.Data.Size, false))
+		}
+		items = append(items, object(item.Pass.Key, item.Pass.Size, false))
+	}
+	return items
+}
+
+// RebuildCatalog replaces the runs of cfg's target in the catalog with the run
+// manifests of its bucket and returns the number of runs found.
+func RebuildCatalog(cfg config.Config) (int, error) {
+	dates, err := GetBackupDates(cfg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get backup dates: %w", err)
+	}
+
+	st, err := storage.Open(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer st.Close()
+
+	var runs []catalog.Run
+	for _, date := range dates {
+		dateRuns, err := catalogRuns(st, cfg, date.FolderName)
+		if err != nil {
+			return 0, err
+		}
+		runs = append(runs, dateRuns...)
+	}
+
+	cat, err := catalog.Open(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer cat.Close()
+
+	err = cat.ReplaceTarget(cfg.CurrentTarget(), runs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update catalog: %w", err)
+	}
+	return len(runs), nil
+}
+
+func catalogRuns(st storage.Storage, cfg config.Config, date string) ([]catalog.Run, error) {
+	target := cfg.CurrentTarget()
+	prefix := cfg.S3.BackupFolder + "/" + date + "/"
+
+	m, err := manifest.Load(st, prefix, cfg)
+	if errors.Is(err, storage.ErrNotFound) {
+		size, err := folderSize(st, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", date, err)
+		}
+		return []catalog.Run{{Target: target, Date: date, Status: catalog.StatusSuccess, Legacy: true, Size: size}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest of %s: %w", date, err)
+	}
+
+	runs := make([]catalog.Run, 0, len(m.Definitions))
+	for _, def := range m.Definitions {
+		runs = append(runs, catalog.Run{
+			Target:     target,
+			Date:       date,
+			Status:     catalog.StatusSuccess,
+			Host:       m.Host,
+			Size:       StoredSize(def),
+			Definition: def,
+		})
+	}
+	return runs, nil
+}
+
+// StoredSize is the number of bytes a run occupies in the bucket.
+func StoredSize(def manifest.Definition) int64 {
+	var size int64
+	for _, item := range def.Items {
+		size += item.CiphertextSize + item.Pass.Size
+	}
+	return size
+}
+
+func folderSize(st storage.Storage, prefix string) (int64, error) {
+	var size int64
+	it := storage.NewIterator(st, storage.ListOptions{Prefix: prefix})
+	for it.Next() {
+		for _, object := range it.Page().Objects {
+			size += object.Size
+		}
+	}
+	return size, it.Err()
+}
+
+// openCoveringCatalog returns nil when callers have to fall back to the bucket.
+func openCoveringCatalog(cfg config.Config) *catalog.Catalog {
+	if cfg.App.DisableCatalog {
+		return nil
+	}
+
+	cat, err := catalog.Open(cfg)
+	if err != nil {
+		cfg.Logger().Warn("Failed to open catalog, reading the bucket", "error", err)
+		return nil
+	}
+
+	covered, err := cat.Covers(cfg.CurrentTarget())
+	if err != nil || !covered {
+		cat.Close()
+		return nil
+	}
+	return cat
+}
+
+func recordCatalogDate(st storage.Storage, cfg config.Config, date string) error {
+	runs, err := catalogRuns(st, cfg, date)
+	if err != nil {
+		return err
+	}
+
+	cat, err := catalog.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer cat.Close()
+
+	for _, run := range runs {
+		err = cat.Record(run)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func forgetCatalogDate(cfg config.Config, date string) error {
+	cat, err := catalog.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer cat.Close()
+	return cat.DeleteDate(cfg.CurrentTarget(), date)
+}
@@ -1,16 +1,15 @@
 package s3
 
 import (
+	"errors"
 	"fmt"
 	"gos3/internal/config"
+	"gos3/internal/manifest"
+	"gos3/internal/storage"
 	"os"
 	"path/filepath"
 	"strings"
-
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"time"
 )
 
 type BackupItem struct {
@@ -19,22 +18,35 @@ type BackupItem struct {
 	PassItem     string
 	IsDataFolder bool
 	S3BaseFolder string
+	// Definition, Target and Entry come from the run manifest; they are
+	// empty for backups made before manifests were written.
+	Definition string
+	Target     string
+	Entry      *manifest.Item
 }
 
 func GetBackupItems(cfg config.Config, date BackupDate) ([]BackupItem, error) {
-	sess, err := createS3Session(cfg.S3)
+	st, err := storage.Open(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create S3 session: %w", err)
+		return nil, err
 	}
-
-	svc := s3.New(sess)
+	defer st.Close()
 
 	parentFolder := cfg.S3.BackupFolder + "/" + date.FolderName + "/"
 
-	resp, err := svc.ListObjectsV2(&s3.ListObjectsV2Input{
-		Bucket:    aws.String(cfg.S3.Bucket),
-		Prefix:    aws.String(parentFolder),
-		Delimiter: aws.String("/"),
+	m, err := manifest.Load(st, parentFolder, cfg)
+	if err == nil {
+		return getBackupItemsFromManifest(parentFolder, m), nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("failed to read manifest of %s: %w", date.FolderName, err)
+	}
+
+	// Backups made before manifests were written: infer the items from the
+	// object names.
+	resp, err := storage.ListAll(st, storage.ListOptions{
+		Prefix:    parentFolder,
+		Delimiter: "/",
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list objects: %w", err)
@@ -42,43 +54,42 @@ func GetBackupItems(cfg config.Config, date BackupDate) ([]BackupItem, error) {
 
 	folderItems := make([]string, 0)
 
-	for _, prefix := range resp.CommonPrefixes {
-		folderName := strings.TrimPrefix(*prefix.Prefix, cfg.S3.BackupFolder)
+	for _, prefix := range resp.Prefixes {
+		folderName := strings.TrimPrefix(prefix, cfg.S3.BackupFolder)
 		folderName = strings.TrimPrefix(folderName, "/")
 		folderName = strings.TrimSuffix(folderName, "/")
 
 		if folderName != "" {
-			folderItems = append(folderItems, *prefix.Prefix)
+			folderItems = append(folderItems, prefix)
 		}
 	}
 
 	fileItems := make([]string, 0)
-	for _, obj := range resp.Contents {
-		fileItems = append(fileItems, *obj.Key)
+	for _, obj := range resp.Objects {
+		fileItems = append(fileItems, obj.Key)
 	}
 
 	return getBackupItemsFromFolderAndFiles(parentFolder, folderItems, fileItems), nil
 
 }
 
-func DownloadBackupItem(item BackupItem, cfg config.Config) error {
-	sess, err := createS3Session(cfg.S3)
+func DownloadBackupItem(item BackupItem, localFolder string, cfg config.Config) error {
+	st, err := storage.Open(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create S3 session: %w", err)
+		return err
 	}
-
-	downloader := s3manager.NewDownloader(sess)
+	defer st.Close()
 
 	if item.IsDataFolder {
-		err = downloadFolder(sess, downloader, item.S3BaseFolder, item.DataItem, cfg)
+		err = downloadFolder(st, item.S3BaseFolder, item.DataItem, localFolder, cfg)
 	} else {
-		err = downloadFile(downloader, cfg.S3.Bucket, item.S3BaseFolder, item.DataItem, cfg)
+		err = downloadFile(st, item.S3BaseFolder, item.DataItem, localFolder, cfg)
 	}
 	if err != nil {
 		return fmt.Errorf("failed to download data item: %w", err)
 	}
 
-	err = downloadFile(downloader, cfg.S3.Bucket, item.S3BaseFolder, item.PassItem, cfg)
+	err = downloadFile(st, item.S3BaseFolder, item.PassItem, localFolder, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to download pass item: %w", err)
 	}
@@ -86,6 +97,31 @@ func DownloadBackupItem(item BackupItem, cfg config.Config) error {
 	return nil
 }
 
+func getBackupItemsFromManifest(parentFolder string, m *manifest.Manifest) []BackupItem {
+	items := make([]BackupItem, 0)
+	for _, def := range m.Definitions {
+		for i := range def.Items {
+			entry := &def.Items[i]
+			item := BackupItem{
+				Name:         entry.Name,
+				PassItem:     parentFolder + entry.Pass.Key,
+				IsDataFolder: entry.Split(),
+				S3BaseFolder: parentFolder,
+				Definition:   def.Name,
+				Target:       def.Target,
+				Entry:        entry,
+			}
+			if entry.Split() {
+				item.DataItem = parentFolder + entry.Name + ".cpt-split_parts/"
+			} else if entry.Data != nil {
+				item.DataItem = parentFolder + entry.Data.Key
+			}
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getBackupItemsFromFolderAndFiles(parentFolder string, folderItems []string, fileItems []string) []BackupItem {
 	items := make([]BackupItem, 0)
 	for _, folder := range folderItems {
@@ -151,19 +187,16 @@ func getBackupItemsFromFolderAndFiles(parentFolder string, folderItems []string,
 	return items
 }
 
-func downloadFolder(sess *session.Session, downloader *s3manager.Downloader, baseFolder string, folderPath string, cfg config.Config) error {
-	svc := s3.New(sess)
-
-	resp, err := svc.ListObjectsV2(&s3.ListObjectsV2Input{
-		Bucket: aws.String(cfg.S3.Bucket),
-		Prefix: aws.String(folderPath),
+func downloadFolder(st storage.Storage, baseFolder string, folderPath string, localFolder string, cfg config.Config) error {
+	resp, err := storage.ListAll(st, storage.ListOptions{
+		Prefix: folderPath,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to list objects in folder: %w", err)
 	}
 
-	for _, item := range resp.Contents {
-		err := downloadFile(downloader, cfg.S3.Bucket, baseFolder, *item.Key, cfg)
+	for _, item := range resp.Objects {
+		err := downloadFile(st, baseFolder, item.Key, localFolder, cfg)
 		if err != nil {
 			return err
 		}
@@ -172,28 +205,19 @@ func downloadFolder(sess *session.Session, downloader *s3manager.Downloader, bas
 	return nil
 }
 
-func downloadFile(downloader *s3manager.Downloader, bucket, baseFolder, filePath string, cfg config.Config) error {
-	localPath := filepath.Join(cfg.App.LocalBackupFolder, strings.TrimPrefix(filePath, baseFolder))
-
-	err := os.MkdirAll(filepath.Dir(localPath), 0755)
-	if err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
-	}
-
-	file, err := os.Create(localPath)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer file.Close()
+func downloadFile(st storage.Storage, baseFolder, filePath string, localFolder string, cfg config.Config) error {
+	localPath := filepath.Join(localFolder, strings.TrimPrefix(filePath, baseFolder))
 
-	_, err = downloader.Download(file, &s3.GetObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(filePath),
-	})
+	start := time.Now()
+	err := st.Get(filePath, localPath)
 	if err != nil {
 		return fmt.Errorf("failed to download file: %w", err)
 	}
 
-	fmt.Printf("Downloaded: %s\n", localPath)
+	var size int64
+	if info, err := os.Stat(localPath); err == nil {
+		size = info.Size()
+	}
+	cfg.Logger().Info("Downloaded file", "step", "download", "target", cfg.CurrentTarget(), "file", localPath, "bytes", size, "duration", time.Since(start))
 	return nil
 }
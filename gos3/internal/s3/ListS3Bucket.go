@@ -5,11 +5,7 @@ import (
 	"strings"
 
 	"gos3/internal/config"
-
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
+	"gos3/internal/storage"
 )
 
 type S3Item struct {
@@ -20,49 +16,49 @@ type S3Item struct {
 	Size            int64  `json:"size" yaml:"size"`
 }
 
-func ListS3Bucket(s3config config.Config, prefix string, delimiter string) ([]S3Item, error) {
-	sess, err := session.NewSession(&aws.Config{
-		Region:      aws.String(s3config.S3.Region),
-		Endpoint:    aws.String(s3config.S3.Endpoint),
-		Credentials: credentials.NewStaticCredentials(s3config.S3.AccessKeyID, s3config.S3.AccessKeySecret, ""),
-	})
-
+// ListS3Bucket lists every item below prefix, following continuation tokens.
+// maxKeys limits the number of returned items; 0 returns all of them.
+func ListS3Bucket(s3config config.Config, prefix string, delimiter string, maxKeys int) ([]S3Item, error) {
+	st, err := storage.Open(s3config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
+		return nil, err
 	}
+	defer st.Close()
 
-	svc := s3.New(sess)
+	var items []S3Item
 
-	input := &s3.ListObjectsV2Input{
-		Bucket:    aws.String(s3config.S3.Bucket),
-		Prefix:    aws.String(prefix),
-		Delimiter: aws.String(delimiter),
-	}
+	it := storage.NewIterator(st, storage.ListOptions{
+		Prefix:    prefix,
+		Delimiter: delimiter,
+	})
+	for it.Next() {
+		page := it.Page()
+
+		for _, prefix := range page.Prefixes {
+			items = append(items, S3Item{
+				Name:     prefix,
+				IsFolder: true,
+			})
+		}
 
-	result, err := svc.ListObjectsV2(input)
-	if err != nil {
-		return nil, fmt.Errorf("unable to list items in bucket %q, %v", s3config.S3.Bucket, err)
-	}
+		for _, item := range page.Objects {
+			lastModifiedUnix := item.LastModified.UnixMilli()
+			lastModifiedStr := item.LastModified.UTC().Format("2006-01-02 15:04:05.000")
 
-	var items []S3Item
+			items = append(items, S3Item{
+				Name:            item.Key,
+				LastModified:    lastModifiedUnix,
+				LastModifiedStr: lastModifiedStr,
+				Size:            item.Size,
+			})
+		}
 
-	for _, prefix := range result.CommonPrefixes {
-		items = append(items, S3Item{
-			Name:     *prefix.Prefix,
-			IsFolder: true,
-		})
+		if maxKeys > 0 && len(items) >= maxKeys {
+			return items[:maxKeys], nil
+		}
 	}
-
-	for _, item := range result.Contents {
-		lastModifiedUnix := item.LastModified.UnixMilli()
-		lastModifiedStr := item.LastModified.UTC().Format("2006-01-02 15:04:05.000")
-
-		items = append(items, S3Item{
-			Name:            *item.Key,
-			LastModified:    lastModifiedUnix,
-			LastModifiedStr: lastModifiedStr,
-			Size:            *item.Size,
-		})
+	if err := it.Err(); err != nil {
+		return nil, err
 	}
 
 	return items, nil
@@ -102,3 +98,34 @@ func PrintS3ItemList(items []S3Item) {
 			maxIsfolderWidth, isFolder)
 	}
 }
+
+// PrintS3ItemSummary prints the number of listed objects and folders and the
+// total size of the objects. Folders only have a size in catalog listings.
+func PrintS3ItemSummary(items []S3Item) {
+	var objects, folders int
+	var totalSize int64
+	for _, item := range items {
+		totalSize += item.Size
+		if item.IsFolder {
+			folders++
+			continue
+		}
+		objects++
+	}
+
+	fmt.Printf("\nTotal: %d objects, %d folders, %s (%d bytes)\n", objects, folders, FormatSize(totalSize), totalSize)
+}
+
+// FormatSize renders a byte count with binary units, e.g. "1.5 GiB".
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
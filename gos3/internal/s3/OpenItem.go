@@ -0,0 +1,108 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"os/exec"
+
+	"gos3/internal/config"
+	"gos3/internal/script"
+	"gos3/internal/storage"
+)
+
+// ItemReader streams the plaintext of a stored item; only ciphertext touches
+// the disk.
+type ItemReader struct {
+	decrypt       *exec.Cmd
+	decryptErrors bytes.Buffer
+	plaintext     *countingReader
+	whole         hash.Hash
+	feedDone      chan feedResult
+	feed          feedResult
+	passFile      string
+}
+
+// OpenItem starts streaming item from st. The caller reads the plaintext and
+// must Close the reader, which reports download and decryption failures.
+func OpenItem(st storage.Storage, item BackupItem, workFolder, privateKeyPassword string, cfg config.Config) (*ItemReader, error) {
+	objects, err := itemDataObjects(st, item)
+	if err != nil {
+		return nil, err
+	}
+
+	passFile, err := os.CreateTemp(workFolder, "item-*.cpt.pass")
+	if err != nil {
+		return nil, err
+	}
+	passFile.Close()
+	reader := &ItemReader{passFile: passFile.Name(), whole: sha256.New(), feedDone: make(chan feedResult, 1)}
+
+	err = st.Get(item.PassItem, reader.passFile)
+	if err != nil {
+		os.Remove(reader.passFile)
+		return nil, fmt.Errorf("failed to download pass file: %w", err)
+	}
+
+	reader.decrypt = script.KeyDecryptStream(reader.passFile, cfg.App.PrivateKeyFile, privateKeyPassword, cfg)
+	reader.decrypt.Stderr = &reader.decryptErrors
+	ciphertext, err := reader.decrypt.StdinPipe()
+	if err != nil {
+		os.Remove(reader.passFile)
+		return nil, err
+	}
+	plaintext, err := reader.decrypt.StdoutPipe()
+	if err != nil {
+		os.Remove(reader.passFile)
+		return nil, err
+	}
+	err = reader.decrypt.Start()
+	if err != nil {
+		os.Remove(reader.passFile)
+		return nil, fmt.Errorf("failed to start decryption: %w", err)
+	}
+	reader.plaintext = &countingReader{reader: plaintext}
+
+	go func() {
+		reader.feedDone <- feedCiphertext(st, objects, ciphertext, reader.whole, workFolder)
+	}()
+	return reader, nil
+}
+
+func (r *ItemReader) Read(p []byte) (int, error) {
+	return r.plaintext.Read(p)
+}
+
+// Close drains the plaintext and waits for the download and decryption. A
+// decryption failure takes precedence.
+func (r *ItemReader) Close() error {
+	defer os.Remove(r.passFile)
+
+	_, _ = io.Copy(io.Discard, r.plaintext)
+	r.feed = <-r.feedDone
+	err := r.decrypt.Wait()
+	if err != nil {
+		return fmt.Errorf("decryption failed: %w: %s", err, lastLine(r.decryptErrors.String()))
+	}
+	return r.feed.err
+}
+
+// PartProblems lists the parts whose checksum differs from the manifest.
+// It is valid after Close.
+func (r *ItemReader) PartProblems() []string {
+	return r.feed.problems
+}
+
+// CiphertextSHA256 is the checksum of all parts in order, valid after Close.
+func (r *ItemReader) CiphertextSHA256() string {
+	return hex.EncodeToString(r.whole.Sum(nil))
+}
+
+// PlaintextSize is the number of decrypted bytes, valid after Close.
+func (r *ItemReader) PlaintextSize() int64 {
+	return r.plaintext.count
+}
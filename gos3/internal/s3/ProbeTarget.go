@@ -0,0 +1,66 @@
+package s3
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"gos3/internal/config"
+	"gos3/internal/storage"
+)
+
+// ProbeResult holds the error of every step of ProbeTarget, nil when the
+// step succeeded. Delete is only attempted after a successful Put.
+type ProbeResult struct {
+	Target string
+	List   error
+	Put    error
+	Delete error
+}
+
+// Err describes the first failed step, nil when all succeeded.
+func (r ProbeResult) Err() error {
+	switch {
+	case r.List != nil:
+		return fmt.Errorf("failed to list %s: %w", r.Target, r.List)
+	case r.Put != nil:
+		return fmt.Errorf("failed to write to %s: %w", r.Target, r.Put)
+	case r.Delete != nil:
+		return fmt.Errorf("failed to delete from %s: %w", r.Target, r.Delete)
+	}
+	return nil
+}
+
+// ProbeTarget checks that cfg's target is reachable and writable: it lists
+// the backup folder, puts a small probe object into it and deletes it again.
+func ProbeTarget(cfg config.Config) ProbeResult {
+	result := ProbeResult{Target: cfg.CurrentTarget()}
+
+	st, err := storage.Open(cfg)
+	if err != nil {
+		result.List = err
+		result.Put = err
+		return result
+	}
+	defer st.Close()
+
+	_, result.List = st.List(storage.ListOptions{Prefix: cfg.S3.BackupFolder + "/", Delimiter: "/", MaxKeys: 1})
+
+	probe, err := os.CreateTemp("", "gos3-probe")
+	if err != nil {
+		result.Put = fmt.Errorf("failed to create temp file: %w", err)
+		return result
+	}
+	probe.WriteString("gos3 probe\n")
+	probe.Close()
+	defer os.Remove(probe.Name())
+
+	host, _ := os.Hostname()
+	key := cfg.S3.BackupFolder + "/.gos3-probe-" + host + "-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	result.Put = st.Put(probe.Name(), key)
+	if result.Put == nil {
+		result.Delete = st.Delete(key)
+	}
+	return result
+}
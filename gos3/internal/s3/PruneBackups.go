@@ -0,0 +1,159 @@
+package s3
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gos3/internal/archive"
+	"gos3/internal/config"
+	"gos3/internal/notify"
+	"gos3/internal/storage"
+)
+
+// PruneBackups removes the date folders outside app.retention, keeping those a
+// kept incremental backup depends on, and returns them.
+func PruneBackups(cfg config.Config, dryRun bool) ([]BackupDate, error) {
+	retention := cfg.App.Retention
+	if retention.KeepLast <= 0 && retention.MaxAge == "" {
+		return nil, nil
+	}
+
+	var maxAge time.Duration
+	if retention.MaxAge != "" {
+		var err error
+		maxAge, err = config.ParseDuration(retention.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse retention max age: %w", err)
+		}
+	}
+
+	dates, err := CatalogBackupDates(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup dates: %w", err)
+	}
+
+	now := time.Now()
+	pending := make(map[string]bool)
+	var expired []BackupDate
+
+	// Dates are sorted newest first, so every folder an incremental chain
+	// needs is visited after the folder that references it.
+	for i, date := range dates {
+		keep := i < retention.KeepLast
+		if !keep && maxAge > 0 {
+			created, ok := ParseSubfolderDate(date.FolderName)
+			keep = !ok || now.Sub(created) <= maxAge
+		}
+
+		items, err := CatalogBackupItems(cfg, date)
+		if err != nil {
+			return nil, err
+		}
+
+		needed := false
+		completed := make([]string, 0)
+		for _, item := range items {
+			base, isFull := ChainLinkBase(item.Name)
+			if base == "" {
+				continue
+			}
+			if pending[base] {
+				needed = true
+				if isFull {
+					completed = append(completed, base)
+				}
+			}
+			if keep && !isFull {
+				pending[base] = true
+			}
+		}
+		if needed && !keep {
+			cfg.Logger().Info("Keeping backup folder needed by a newer incremental backup", "step", "prune", "date", date.FolderName)
+			keep = true
+			for _, item := range items {
+				if base, isFull := ChainLinkBase(item.Name); base != "" && !isFull {
+					pending[base] = true
+				}
+			}
+		}
+		for _, base := range completed {
+			delete(pending, base)
+		}
+
+		if !keep {
+			expired = append(expired, date)
+		}
+	}
+
+	if dryRun {
+		return expired, nil
+	}
+
+	st, err := storage.Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+
+	for _, date := range expired {
+		cfg.Logger().Info("Pruning backup folder", "step", "prune", "target", cfg.CurrentTarget(), "date", date.FolderName)
+		err = deleteFolder(st, cfg.S3.BackupFolder+"/"+date.FolderName+"/")
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune %s: %w", date.FolderName, err)
+		}
+		err = forgetCatalogDate(cfg, date.FolderName)
+		if err != nil {
+			cfg.Logger().Warn("Failed to remove backup folder from the catalog", "date", date.FolderName, "error", err)
+		}
+		err = os.RemoveAll(archive.CachedIndexFolder(cfg.App.StateFolder, date.FolderName))
+		if err != nil {
+			cfg.Logger().Warn("Failed to remove cached archive indexes", "date", date.FolderName, "error", err)
+		}
+	}
+
+	if len(expired) > 0 {
+		event := notify.Event{Type: notify.EventRetentionPruned, Target: cfg.CurrentTarget()}
+		for _, date := range expired {
+			event.Details = append(event.Details, date.FolderName)
+		}
+		notify.Send(cfg, event)
+	}
+
+	return expired, nil
+}
+
+// ParseSubfolderDate returns the start of the period a folder created by
+// GenerateSubfolderName covers.
+func ParseSubfolderDate(folderName string) (time.Time, bool) {
+	for _, layout := range []string{"2006-01-02-15", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, folderName, time.Local); err == nil {
+			return t, true
+		}
+	}
+
+	var year, week int
+	if _, err := fmt.Sscanf(folderName, "%d-W%d", &year, &week); err == nil {
+		// January 4th is always in ISO week 1.
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.Local)
+		monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+		return monday.AddDate(0, 0, (week-1)*7), true
+	}
+
+	return time.Time{}, false
+}
+
+func deleteFolder(st storage.Storage, prefix string) error {
+	all, err := storage.ListAll(st, storage.ListOptions{Prefix: prefix})
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+
+	for _, object := range all.Objects {
+		err = st.Delete(object.Key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
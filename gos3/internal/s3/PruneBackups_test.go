@@ -0,0 +1,87 @@
+package s3
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gos3/internal/config"
+	"gos3/internal/storage"
+	"gos3/internal/storage/storagetest"
+)
+
+func TestPruneBackupsSFTP(t *testing.T) {
+	var cfg config.Config
+	cfg.Storage.Type = "sftp"
+	cfg.Storage.SFTP = storagetest.StartSFTPServer(t)
+	cfg.S3.BackupFolder = "backups"
+	cfg.App.StateFolder = t.TempDir()
+	cfg.App.DisableCatalog = true
+	cfg.App.Retention.KeepLast = 2
+
+	st, err := storage.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	empty := filepath.Join(t.TempDir(), "empty")
+	err = os.WriteFile(empty, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-01-02 holds an incremental backup of vol1, so the full backup it
+	// builds on in 2026-01-01 must survive although it is outside KeepLast.
+	for _, key := range []string{
+		"backups/2025-12-31/def-vol1.tar.gz.cpt",
+		"backups/2025-12-31/def-vol1.tar.gz.cpt.pass",
+		"backups/2026-01-01/def-vol1.tar.gz.cpt",
+		"backups/2026-01-01/def-vol1.tar.gz.cpt.pass",
+		"backups/2026-01-02/def-vol1.incr.tar.gz.cpt",
+		"backups/2026-01-02/def-vol1.incr.tar.gz.cpt.pass",
+		"backups/2026-01-03/def-vol2.tar.gz.cpt",
+		"backups/2026-01-03/def-vol2.tar.gz.cpt.pass",
+	} {
+		err = st.Put(empty, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expired, err := PruneBackups(cfg, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].FolderName != "2025-12-31" {
+		t.Fatalf("dry run expired %v, want [2025-12-31]", expired)
+	}
+	_, err = st.Stat("backups/2025-12-31/def-vol1.tar.gz.cpt")
+	if err != nil {
+		t.Fatalf("dry run deleted a file: %v", err)
+	}
+
+	expired, err = PruneBackups(cfg, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].FolderName != "2025-12-31" {
+		t.Fatalf("expired %v, want [2025-12-31]", expired)
+	}
+
+	dates, err := GetBackupDates(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var folders []string
+	for _, date := range dates {
+		folders = append(folders, date.FolderName)
+	}
+	if want := []string{"2026-01-03", "2026-01-02", "2026-01-01"}; !slices.Equal(folders, want) {
+		t.Errorf("remaining folders = %v, want %v", folders, want)
+	}
+	_, err = os.Stat(filepath.Join(cfg.Storage.SFTP.Root, "backups", "2025-12-31"))
+	if !os.IsNotExist(err) {
+		t.Errorf("pruned folder still exists on the server: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package s3
+
+import (
+	"fmt"
+	"gos3/internal/config"
+)
+
+// SelectBackupDates resolves a date argument of the commands: "latest" (or
+// empty) selects the newest date folder, "all" every folder and anything
+// else the folder of that name. Dates are returned newest first.
+func SelectBackupDates(cfg config.Config, date string) ([]BackupDate, error) {
+	dates, err := GetBackupDates(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup dates: %w", err)
+	}
+	if len(dates) == 0 {
+		return nil, fmt.Errorf("no backups found")
+	}
+
+	switch date {
+	case "", "latest":
+		return dates[:1], nil
+	case "all":
+		return dates, nil
+	}
+
+	for _, d := range dates {
+		if d.FolderName == date {
+			return []BackupDate{d}, nil
+		}
+	}
+	return nil, fmt.Errorf("backup date folder %s not found", date)
+}
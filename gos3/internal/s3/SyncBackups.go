@@ -0,0 +1,106 @@
+package s3
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gos3/internal/config"
+	"gos3/internal/storage"
+)
+
+// SyncBackups copies the date folders missing on the destination and returns
+// them. With dryRun nothing is copied.
+func SyncBackups(source, destination config.Config, dryRun bool) ([]BackupDate, error) {
+	sourceDates, err := GetBackupDates(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup dates from source: %w", err)
+	}
+
+	destinationDates, err := GetBackupDates(destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup dates from destination: %w", err)
+	}
+
+	existing := make(map[string]bool)
+	for _, date := range destinationDates {
+		existing[date.FolderName] = true
+	}
+
+	var missing []BackupDate
+	for _, date := range sourceDates {
+		if !existing[date.FolderName] {
+			missing = append(missing, date)
+		}
+	}
+
+	if dryRun || len(missing) == 0 {
+		return missing, nil
+	}
+
+	src, err := storage.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	dst, err := storage.Open(destination)
+	if err != nil {
+		return nil, err
+	}
+	defer dst.Close()
+
+	tempDir, err := os.MkdirTemp("", "gos3-sync")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp folder: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	copied := make([]BackupDate, 0, len(missing))
+	for _, date := range missing {
+		start := time.Now()
+		source.Logger().Info("Copying backup folder", "step", "sync", "date", date.FolderName, "from", src.Name(), "to", dst.Name())
+		err = copyFolder(src, dst, source.S3.BackupFolder+"/"+date.FolderName+"/", destination.S3.BackupFolder+"/"+date.FolderName+"/", tempDir)
+		if err != nil {
+			return copied, fmt.Errorf("failed to copy %s: %w", date.FolderName, err)
+		}
+		err = recordCatalogDate(dst, destination, date.FolderName)
+		if err != nil {
+			source.Logger().Warn("Failed to add backup folder to the catalog", "date", date.FolderName, "target", destination.CurrentTarget(), "error", err)
+		}
+		source.Logger().Info("Copied backup folder", "step", "sync", "date", date.FolderName, "duration", time.Since(start))
+		copied = append(copied, date)
+	}
+
+	return copied, nil
+}
+
+func copyFolder(src, dst storage.Storage, sourcePrefix, destinationPrefix, tempDir string) error {
+	it := storage.NewIterator(src, storage.ListOptions{Prefix: sourcePrefix})
+	for it.Next() {
+		for _, object := range it.Page().Objects {
+			if strings.HasSuffix(object.Key, "/") {
+				continue
+			}
+
+			localPath := filepath.Join(tempDir, filepath.Base(object.Key))
+			err := src.Get(object.Key, localPath)
+			if err != nil {
+				return err
+			}
+
+			err = dst.Put(localPath, destinationPrefix+strings.TrimPrefix(object.Key, sourcePrefix))
+			os.Remove(localPath)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to list %s: %w", sourcePrefix, err)
+	}
+	return nil
+}
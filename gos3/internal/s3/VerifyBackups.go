@@ -0,0 +1,132 @@
+package s3
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gos3/internal/config"
+	"gos3/internal/history"
+	"gos3/internal/manifest"
+	"gos3/internal/metrics"
+	"gos3/internal/notify"
+	"gos3/internal/storage"
+)
+
+// VerifyOptions selects what VerifyBackups checks. Full also downloads and
+// decrypts every item, which needs the private key password.
+type VerifyOptions struct {
+	Full               bool
+	Target             string
+	Definition         string
+	PrivateKeyPassword string
+}
+
+// VerifyResult is the outcome of one backup item.
+type VerifyResult struct {
+	Date       string
+	Definition string
+	Item       string
+	Problems   []string
+	Details    []string
+}
+
+func (r VerifyResult) Passed() bool {
+	return len(r.Problems) == 0
+}
+
+// VerifyBackups checks every item of the given date folders. An unreadable or
+// tampered manifest is reported as a failed manifest item.
+func VerifyBackups(cfg config.Config, dates []BackupDate, options VerifyOptions) ([]VerifyResult, error) {
+	st, err := storage.Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+
+	workFolder, err := os.MkdirTemp("", "gos3-verify")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp folder: %w", err)
+	}
+	defer os.RemoveAll(workFolder)
+
+	var results []VerifyResult
+	for _, date := range dates {
+		items, err := GetBackupItems(cfg, date)
+		if err != nil {
+			results = append(results, VerifyResult{
+				Date:     date.FolderName,
+				Item:     manifest.FileName,
+				Problems: []string{err.Error()},
+			})
+			continue
+		}
+
+		for _, item := range items {
+			if options.Definition != "" && item.Definition != options.Definition {
+				continue
+			}
+
+			result := VerifyResult{Date: date.FolderName, Definition: item.Definition, Item: item.Name}
+			result.Problems, result.Details = verifyObjects(st, item, options.Target)
+			if options.Full && result.Passed() {
+				problems, details := verifyStream(st, item, workFolder, options.PrivateKeyPassword, cfg)
+				result.Problems = append(result.Problems, problems...)
+				result.Details = append(result.Details, details...)
+			}
+			results = append(results, result)
+		}
+	}
+
+	failed := 0
+	event := notify.Event{Type: notify.EventVerificationFailed, Definition: options.Definition, Target: cfg.CurrentTarget()}
+	for _, result := range results {
+		if !result.Passed() {
+			failed++
+			event.Details = append(event.Details, result.Date+"/"+result.Item+": "+strings.Join(result.Problems, "; "))
+		}
+	}
+	metrics.RecordVerification(cfg.CurrentTarget(), len(results)-failed, failed)
+
+	verification := history.Verification{
+		Time:       time.Now().UTC(),
+		Target:     cfg.CurrentTarget(),
+		Definition: options.Definition,
+		Full:       options.Full,
+		Passed:     len(results) - failed,
+		Failed:     failed,
+		Problems:   event.Details,
+	}
+	for _, date := range dates {
+		verification.Dates = append(verification.Dates, date.FolderName)
+	}
+	err = history.AppendVerification(verification, cfg)
+	if err != nil {
+		cfg.Logger().Warn("Failed to record verification", "error", err)
+	}
+	if failed > 0 {
+		notify.Send(cfg, event)
+	}
+
+	return results, nil
+}
+
+func PrintVerifyResults(results []VerifyResult) {
+	failed := 0
+	for _, result := range results {
+		status := "PASS"
+		notes := result.Details
+		if !result.Passed() {
+			status = "FAIL"
+			notes = result.Problems
+			failed++
+		}
+		definition := result.Definition
+		if definition == "" {
+			definition = "-"
+		}
+		fmt.Printf("%s  %s  %-20s  %s  %s\n", status, result.Date, definition, result.Item, strings.Join(notes, "; "))
+	}
+	fmt.Printf("%d items verified, %d passed, %d failed\n", len(results), len(results)-failed, failed)
+}
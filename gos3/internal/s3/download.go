@@ -3,15 +3,15 @@ package s3
 import (
 	"bufio"
 	"fmt"
+	"gos3/internal/archive"
 	"gos3/internal/config"
 	"gos3/internal/script"
+	"gos3/internal/storage"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
-
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/s3"
 )
 
 type BackupDate struct {
@@ -19,25 +19,23 @@ type BackupDate struct {
 }
 
 func GetBackupDates(cfg config.Config) ([]BackupDate, error) {
-	sess, err := createS3Session(cfg.S3)
+	st, err := storage.Open(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create S3 session: %w", err)
+		return nil, err
 	}
+	defer st.Close()
 
-	svc := s3.New(sess)
-
-	resp, err := svc.ListObjectsV2(&s3.ListObjectsV2Input{
-		Bucket:    aws.String(cfg.S3.Bucket),
-		Prefix:    aws.String(cfg.S3.BackupFolder + "/"),
-		Delimiter: aws.String("/"),
+	resp, err := storage.ListAll(st, storage.ListOptions{
+		Prefix:    cfg.S3.BackupFolder + "/",
+		Delimiter: "/",
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list objects: %w", err)
 	}
 
 	var dates []BackupDate
-	for _, prefix := range resp.CommonPrefixes {
-		folderName := strings.TrimPrefix(*prefix.Prefix, cfg.S3.BackupFolder)
+	for _, prefix := range resp.Prefixes {
+		folderName := strings.TrimPrefix(prefix, cfg.S3.BackupFolder)
 		folderName = strings.TrimPrefix(folderName, "/")
 		folderName = strings.TrimSuffix(folderName, "/")
 
@@ -56,7 +54,7 @@ func GetBackupDates(cfg config.Config) ([]BackupDate, error) {
 }
 
 func DownloadBackup(cfg config.Config) error {
-	dates, err := GetBackupDates(cfg)
+	dates, err := CatalogBackupDates(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to get backup dates: %w", err)
 	}
@@ -75,15 +73,14 @@ func DownloadBackup(cfg config.Config) error {
 		return err
 	}
 
-	backupItems, err := GetBackupItems(cfg, selectedDate)
+	backupItems, err := CatalogBackupItems(cfg, selectedDate)
 	if err != nil {
 		return err
 	}
-	for _, backupItem := range backupItems {
-		err = DownloadBackupItem(backupItem, cfg)
-		if err != nil {
-			return err
-		}
+
+	err = DownloadBackupItems(cfg, dates, selectedDate, backupItems, cfg.App.LocalBackupFolder)
+	if err != nil {
+		return err
 	}
 
 	err = script.Join(cfg.App.LocalBackupFolder, cfg)
@@ -94,6 +91,135 @@ func DownloadBackup(cfg config.Config) error {
 	return nil
 }
 
+// DownloadSet is the items to download from one backup date folder.
+type DownloadSet struct {
+	Date  BackupDate
+	Items []BackupItem
+}
+
+// DownloadBackupItems downloads items of selectedDate and the older backups
+// they depend on into localRoot/<date>. Split files are not joined.
+func DownloadBackupItems(cfg config.Config, dates []BackupDate, selectedDate BackupDate, items []BackupItem, localRoot string) error {
+	sets, err := PlanBackupDownload(cfg, dates, selectedDate, items)
+	if err != nil {
+		return err
+	}
+	return DownloadSets(cfg, sets, localRoot)
+}
+
+// PlanBackupDownload returns the items of selectedDate and the older backups
+// their incremental chains depend on, grouped by date folder.
+func PlanBackupDownload(cfg config.Config, dates []BackupDate, selectedDate BackupDate, items []BackupItem) ([]DownloadSet, error) {
+	sets := []DownloadSet{{Date: selectedDate, Items: items}}
+	chains, err := planIncrementalChains(cfg, dates, selectedDate, items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve incremental chain: %w", err)
+	}
+	return append(sets, chains...), nil
+}
+
+// DownloadSets downloads every set into its own date subfolder of localRoot.
+func DownloadSets(cfg config.Config, sets []DownloadSet, localRoot string) error {
+	for _, set := range sets {
+		localFolder := filepath.Join(localRoot, set.Date.FolderName)
+		for _, item := range set.Items {
+			err := DownloadBackupItem(item, localFolder, cfg)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func planIncrementalChains(cfg config.Config, dates []BackupDate, selectedDate BackupDate, selectedItems []BackupItem) ([]DownloadSet, error) {
+	// pending maps every base to the folder its chain continues in, empty for
+	// backups that did not record it: the newest earlier folder holding it.
+	pending := make(map[string]string)
+	for _, item := range selectedItems {
+		base, incremental, _, ok := script.ParseArchiveName(item.Name)
+		if ok && incremental {
+			pending[base] = chainBaseFolder(pending[base], item)
+		}
+	}
+
+	var sets []DownloadSet
+	for _, date := range dates {
+		if len(pending) == 0 {
+			return sets, nil
+		}
+		if date.FolderName >= selectedDate.FolderName {
+			continue
+		}
+
+		items, err := CatalogBackupItems(cfg, date)
+		if err != nil {
+			return nil, err
+		}
+
+		set := DownloadSet{Date: date}
+		next := make(map[string]string)
+		completed := make([]string, 0)
+		for _, item := range items {
+			base, isFull := ChainLinkBase(item.Name)
+			folder, ok := pending[base]
+			if base == "" || !ok || (folder != "" && folder != date.FolderName) {
+				continue
+			}
+
+			set.Items = append(set.Items, item)
+			next[base] = chainBaseFolder(next[base], item)
+			if isFull {
+				completed = append(completed, base)
+			}
+		}
+		if len(set.Items) > 0 {
+			sets = append(sets, set)
+		}
+
+		for base, folder := range next {
+			pending[base] = folder
+		}
+		for _, base := range completed {
+			delete(pending, base)
+		}
+	}
+
+	if len(pending) > 0 {
+		missing := make([]string, 0, len(pending))
+		for base := range pending {
+			missing = append(missing, base)
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("no full backup found for: %s", strings.Join(missing, ", "))
+	}
+
+	return sets, nil
+}
+
+func chainBaseFolder(folder string, item BackupItem) string {
+	if item.Entry != nil && item.Entry.BaseFolder != "" {
+		return item.Entry.BaseFolder
+	}
+	return folder
+}
+
+// ChainLinkBase returns the "<definition>-<volume>" base name of a backup item
+// and whether it belongs to a full backup.
+func ChainLinkBase(name string) (string, bool) {
+	name = strings.TrimSuffix(name, script.ArchiveMetadataSuffix)
+	name = strings.TrimSuffix(name, archive.IndexSuffix)
+	if base, found := strings.CutSuffix(name, script.IncrementalDeletedSuffix); found {
+		return base, false
+	}
+
+	base, incremental, _, ok := script.ParseArchiveName(name)
+	if !ok {
+		return "", false
+	}
+	return base, !incremental
+}
+
 func selectBackupDate(dates []BackupDate) (BackupDate, error) {
 	reader := bufio.NewReader(os.Stdin)
 
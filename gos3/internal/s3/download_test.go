@@ -0,0 +1,85 @@
+package s3
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"gos3/internal/config"
+	"gos3/internal/manifest"
+)
+
+// A degraded run 2026-01-03 did not advance the incremental indexes, so the
+// next run diffs against 2026-01-02 again. A target that received 2026-01-03
+// must not restore it before 2026-01-04.
+func TestPlanBackupDownloadSkipsSupersededRun(t *testing.T) {
+	var cfg config.Config
+	cfg.Storage.Type = "local"
+	cfg.Storage.Path = t.TempDir()
+	cfg.S3.BackupFolder = "backups"
+	cfg.App.StateFolder = t.TempDir()
+	cfg.App.DisableCatalog = true
+
+	runs := map[string][]manifest.Item{
+		"2026-01-01": {{Name: "def-vol.tar.gz"}},
+		"2026-01-02": {{Name: "def-vol.incr.tar.gz", BaseFolder: "2026-01-01"}, {Name: "def-vol.incr.deleted"}},
+		"2026-01-03": {{Name: "def-vol.incr.tar.gz", BaseFolder: "2026-01-02"}, {Name: "def-vol.incr.deleted"}},
+		"2026-01-04": {{Name: "def-vol.incr.tar.gz", BaseFolder: "2026-01-02"}, {Name: "def-vol.incr.deleted"}},
+		"2026-01-05": {{Name: "other-vol.tar.gz"}},
+	}
+	var dates []BackupDate
+	for folder, items := range runs {
+		for i := range items {
+			items[i].Data = &manifest.Object{Key: items[i].Name + ".cpt"}
+			items[i].Pass = manifest.Object{Key: items[i].Name + ".cpt.pass"}
+		}
+		data, err := json.Marshal(manifest.Manifest{
+			DateFolder:  folder,
+			Definitions: []manifest.Definition{{Name: "def", Items: items}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(cfg.Storage.Path, "backups", folder, manifest.FileName)
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, data, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dates = append(dates, BackupDate{FolderName: folder})
+	}
+	slices.SortFunc(dates, func(a, b BackupDate) int { return strings.Compare(b.FolderName, a.FolderName) })
+
+	selected := BackupDate{FolderName: "2026-01-04"}
+	items, err := GetBackupItems(cfg, selected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets, err := PlanBackupDownload(cfg, dates, selected, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, set := range sets {
+		for _, item := range set.Items {
+			got = append(got, set.Date.FolderName+"/"+item.Name)
+		}
+	}
+	want := []string{
+		"2026-01-04/def-vol.incr.tar.gz",
+		"2026-01-04/def-vol.incr.deleted",
+		"2026-01-02/def-vol.incr.tar.gz",
+		"2026-01-02/def-vol.incr.deleted",
+		"2026-01-01/def-vol.tar.gz",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("planned downloads = %v, want %v", got, want)
+	}
+}
@@ -2,21 +2,14 @@ package s3
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"gos3/internal/config"
-	"gos3/internal/script"
-
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"gos3/internal/storage"
 )
 
 func GenerateSubfolderName(frequency string) string {
@@ -36,33 +29,18 @@ func GenerateSubfolderName(frequency string) string {
 	}
 }
 
-func EnsureS3FolderExists(folderPath string, cfg config.S3Config) error {
-	sess, err := session.NewSession(&aws.Config{
-		Region:   aws.String(cfg.Region),
-		Endpoint: aws.String(cfg.Endpoint),
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
-	}
-
-	svc := s3.New(sess)
-
-	_, err = svc.PutObject(&s3.PutObjectInput{
-		Bucket: aws.String(cfg.Bucket),
-		Key:    aws.String(folderPath + "/"),
-		Body:   strings.NewReader(""),
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create folder in S3: %w", err)
-	}
-
-	return nil
+func UploadFolderToS3(localFolder, s3Folder string, cfg config.Config) error {
+	return UploadFolderToS3Subfolder(localFolder, s3Folder, GenerateSubfolderName(cfg.App.BackupFrequency), cfg)
 }
 
-func UploadFolderToS3(localFolder, s3Folder string, cfg config.Config) error {
-	dateSubfolder := GenerateSubfolderName(cfg.App.BackupFrequency)
+// UploadFolderToS3Subfolder uploads localFolder into s3Folder/dateSubfolder.
+func UploadFolderToS3Subfolder(localFolder, s3Folder, dateSubfolder string, cfg config.Config) error {
 	s3FullPath := filepath.Join(s3Folder, dateSubfolder)
 
+	// A deterministic staging path lets a later run resume a failed upload.
+	stageDir := filepath.Join(cfg.App.StateFolder, "split", s3FullPath)
+	var jobs []uploadJob
+
 	err := filepath.Walk(localFolder, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -80,30 +58,23 @@ func UploadFolderToS3(localFolder, s3Folder string, cfg config.Config) error {
 		s3Path := filepath.Join(s3FullPath, relPath)
 		s3Path = strings.ReplaceAll(s3Path, "\\", "/") // Ensure forward slashes for S3 paths
 
-		if info.Size() > parseSize(cfg.S3.MaxFileSize) {
-			fmt.Printf("File %s exceeds maximum size. Splitting...\n", path)
-			splitFiles, err := splitFile(path, cfg)
+		maxSize := config.ParseSize(cfg.S3.MaxFileSize)
+		if maxSize > 0 && info.Size() > maxSize {
+			fileStageDir := filepath.Join(stageDir, filepath.Dir(relPath))
+			splitFiles, err := splitLargeFile(path, fileStageDir, maxSize)
 			if err != nil {
 				return fmt.Errorf("failed to split file %s: %w", path, err)
 			}
+			cfg.Logger().Info("Split file exceeding the maximum size", "step", "upload", "file", path, "bytes", info.Size(), "parts", len(splitFiles))
 
 			for _, splitFile := range splitFiles {
-				splitRelPath, _ := filepath.Rel(filepath.Dir(path), splitFile)
-				splitS3Path := filepath.Join(s3FullPath, splitRelPath)
+				splitRelPath, _ := filepath.Rel(fileStageDir, splitFile)
+				splitS3Path := filepath.Join(filepath.Dir(s3Path), splitRelPath)
 				splitS3Path = strings.ReplaceAll(splitS3Path, "\\", "/")
-
-				fmt.Printf("Uploading split file %s to s3://%s/%s\n", splitFile, cfg.S3.Bucket, splitS3Path)
-				err = UploadToS3(splitFile, splitS3Path, cfg)
-				if err != nil {
-					return fmt.Errorf("failed to upload split file %s: %w", splitFile, err)
-				}
+				jobs = append(jobs, uploadJob{localPath: splitFile, remotePath: splitS3Path})
 			}
 		} else {
-			fmt.Printf("Uploading %s to s3://%s/%s\n", path, cfg.S3.Bucket, s3Path)
-			err = UploadToS3(path, s3Path, cfg)
-			if err != nil {
-				return fmt.Errorf("failed to upload file %s: %w", path, err)
-			}
+			jobs = append(jobs, uploadJob{localPath: path, remotePath: s3Path})
 		}
 
 		return nil
@@ -113,81 +84,76 @@ func UploadFolderToS3(localFolder, s3Folder string, cfg config.Config) error {
 		return fmt.Errorf("error walking through local folder: %w", err)
 	}
 
-	return nil
-}
-
-func splitFile(filePath string, cfg config.Config) ([]string, error) {
-	tempDir, err := os.MkdirTemp("", "split_files")
+	err = uploadFiles(jobs, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+		return err
 	}
-	defer os.RemoveAll(tempDir)
-
-	err = script.Split(filePath, cfg.S3.MaxFileSize, cfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to split file: %w", err)
-	}
-
-	splitFiles, err := filepath.Glob(filepath.Join(tempDir, "*"))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get split files: %w", err)
-	}
-
-	return splitFiles, nil
+	os.RemoveAll(stageDir)
+	return nil
 }
 
-func UploadToS3(localPath, remotePath string, cfg config.Config) error {
-	log.Printf("Starting upload of %s to S3 path %s", localPath, remotePath)
+type uploadJob struct {
+	localPath  string
+	remotePath string
+}
 
-	sess, err := session.NewSession(&aws.Config{
-		Region:      aws.String(cfg.S3.Region),
-		Endpoint:    aws.String(cfg.S3.Endpoint),
-		Credentials: credentials.NewStaticCredentials(cfg.S3.AccessKeyID, cfg.S3.AccessKeySecret, ""),
-	})
+// uploadFiles returns the first error once every started upload has finished.
+func uploadFiles(jobs []uploadJob, cfg config.Config) error {
+	st, err := storage.Open(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
+		return err
 	}
+	defer st.Close()
 
-	file, err := os.Open(localPath)
-	if err != nil {
-		return fmt.Errorf("failed to open file %s: %w", localPath, err)
+	workers := cfg.S3.ParallelFiles
+	if workers <= 0 {
+		workers = 1
 	}
-	defer file.Close()
 
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return fmt.Errorf("failed to get file info: %w", err)
+	jobChan := make(chan uploadJob)
+	errChan := make(chan error, len(jobs))
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobChan {
+				start := time.Now()
+				if err := st.Put(job.localPath, job.remotePath); err != nil {
+					errChan <- fmt.Errorf("failed to upload file %s: %w", job.localPath, err)
+					continue
+				}
+				var size int64
+				if info, err := os.Stat(job.localPath); err == nil {
+					size = info.Size()
+				}
+				cfg.Logger().Info("Uploaded file", "step", "upload", "target", cfg.CurrentTarget(), "key", job.remotePath, "bytes", size, "duration", time.Since(start))
+			}
+		}()
 	}
 
-	maxSize := parseSize(cfg.S3.MaxFileSize)
-	if fileInfo.Size() > maxSize {
-		return uploadLargeFile(sess, localPath, remotePath, cfg, maxSize)
+	for _, job := range jobs {
+		jobChan <- job
 	}
+	close(jobChan)
+	wg.Wait()
+	close(errChan)
 
-	uploader := s3manager.NewUploader(sess)
-	_, err = uploader.Upload(&s3manager.UploadInput{
-		Bucket: aws.String(cfg.S3.Bucket),
-		Key:    aws.String(remotePath),
-		Body:   file,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to upload file to S3: %w", err)
+	for err := range errChan {
+		if err != nil {
+			return err
+		}
 	}
-
-	log.Printf("Successfully uploaded %s to S3", localPath)
 	return nil
 }
 
-func parseSize(size string) int64 {
-	var multiplier int64 = 1
-	if strings.HasSuffix(size, "K") {
-		multiplier = 1024
-	} else if strings.HasSuffix(size, "M") {
-		multiplier = 1024 * 1024
-	} else if strings.HasSuffix(size, "G") {
-		multiplier = 1024 * 1024 * 1024
+func UploadToS3(localPath, remotePath string, cfg config.Config) error {
+	st, err := storage.Open(cfg)
+	if err != nil {
+		return err
 	}
+	defer st.Close()
 
-	numericSize, _ := strconv.ParseInt(strings.TrimRight(size, "KMG"), 10, 64)
-	return numericSize * multiplier
+	return st.Put(localPath, remotePath)
 }
@@ -2,68 +2,53 @@ package s3
 
 import (
 	"fmt"
-	"gos3/internal/config"
-	"gos3/internal/script"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
-	"strings"
-
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 )
 
-func uploadLargeFile(sess *session.Session, localPath, remotePath string, cfg config.Config, maxSize int64) error {
-	log.Printf("File %s exceeds max size. Splitting into parts", localPath)
-
-	// Create a temporary directory for split files
-	tempDir, err := os.MkdirTemp("", "split_files")
+// splitLargeFile uses the "<file>-split_parts/<file>.part-NNNN" layout of
+// split.sh so downloaded parts can be joined with join.sh.
+func splitLargeFile(localPath, stageDir string, maxSize int64) ([]string, error) {
+	fileName := filepath.Base(localPath)
+	partsFolder := filepath.Join(stageDir, fileName+"-split_parts")
+	err := os.RemoveAll(partsFolder)
 	if err != nil {
-		return fmt.Errorf("failed to create temp directory: %w", err)
+		return nil, fmt.Errorf("failed to clear parts folder: %w", err)
 	}
-	defer os.RemoveAll(tempDir)
-
-	err = script.Split(localPath, fmt.Sprintf("%d", maxSize), cfg)
+	err = os.MkdirAll(partsFolder, 0755)
 	if err != nil {
-		return fmt.Errorf("failed to split file: %w", err)
+		return nil, fmt.Errorf("failed to create parts folder: %w", err)
 	}
 
-	// Create a subfolder for split files in S3
-	fileName := filepath.Base(localPath)
-	subfolderPath := filepath.Join(filepath.Dir(remotePath), strings.TrimSuffix(fileName, filepath.Ext(fileName)))
-
-	uploader := s3manager.NewUploader(sess)
-
-	// Upload each split file
-	splitFiles, err := filepath.Glob(filepath.Join(tempDir, "*"))
+	source, err := os.Open(localPath)
 	if err != nil {
-		return fmt.Errorf("failed to list split files: %w", err)
+		return nil, fmt.Errorf("failed to open %s: %w", localPath, err)
 	}
+	defer source.Close()
 
-	for i, splitFile := range splitFiles {
-		file, err := os.Open(splitFile)
+	var parts []string
+	for i := 0; ; i++ {
+		partPath := filepath.Join(partsFolder, fmt.Sprintf("%s.part-%04d", fileName, i))
+		part, err := os.Create(partPath)
 		if err != nil {
-			return fmt.Errorf("failed to open split file %s: %w", splitFile, err)
+			return nil, fmt.Errorf("failed to create part %s: %w", partPath, err)
 		}
-		defer file.Close()
-
-		splitFileName := filepath.Base(splitFile)
-		partRemotePath := filepath.Join(subfolderPath, splitFileName)
-
-		log.Printf("Uploading part %d/%d of %s", i+1, len(splitFiles), fileName)
 
-		_, err = uploader.Upload(&s3manager.UploadInput{
-			Bucket: aws.String(cfg.S3.Bucket),
-			Key:    aws.String(partRemotePath),
-			Body:   file,
-		})
+		written, err := io.CopyN(part, source, maxSize)
+		part.Close()
+		if written > 0 {
+			parts = append(parts, partPath)
+		} else {
+			os.Remove(partPath)
+		}
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			return fmt.Errorf("failed to upload file part to S3: %w", err)
+			return nil, fmt.Errorf("failed to write part %s: %w", partPath, err)
 		}
 	}
 
-	log.Printf("Successfully uploaded all %d parts of %s", len(splitFiles), fileName)
-
-	return nil
+	return parts, nil
 }
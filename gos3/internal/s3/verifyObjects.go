@@ -0,0 +1,108 @@
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gos3/internal/manifest"
+	"gos3/internal/storage"
+)
+
+// ETags are only compared on the target that wrote the manifest.
+func verifyObjects(st storage.Storage, item BackupItem, target string) (problems, details []string) {
+	if item.Entry == nil {
+		data, err := itemDataObjects(st, item)
+		if err != nil {
+			return []string{err.Error()}, nil
+		}
+		if len(data) == 0 {
+			problems = append(problems, "no data objects found")
+		}
+		_, err = st.Stat(item.PassItem)
+		if err != nil {
+			problems = append(problems, describeStatError(item.PassItem, err))
+		}
+		return problems, []string{fmt.Sprintf("%d objects present (no manifest)", len(data)+1)}
+	}
+
+	checkETag := item.Target == target
+	var total int64
+	objects := item.Entry.Objects()
+	for _, object := range objects {
+		key := item.S3BaseFolder + object.Key
+		stored, err := st.Stat(key)
+		if err != nil {
+			problems = append(problems, describeStatError(key, err))
+			continue
+		}
+		total += stored.Size
+		if stored.Size != object.Size {
+			problems = append(problems, fmt.Sprintf("%s: size %d, manifest %d", object.Key, stored.Size, object.Size))
+		}
+		if checkETag && object.ETag != "" && stored.ETag != "" && stored.ETag != object.ETag {
+			problems = append(problems, fmt.Sprintf("%s: etag %s, manifest %s", object.Key, stored.ETag, object.ETag))
+		}
+	}
+
+	if item.Entry.Split() {
+		resp, err := storage.ListAll(st, storage.ListOptions{Prefix: item.DataItem})
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("failed to list parts: %v", err))
+		} else if len(resp.Objects) != len(item.Entry.Parts) {
+			problems = append(problems, fmt.Sprintf("%d parts stored, manifest lists %d", len(resp.Objects), len(item.Entry.Parts)))
+		}
+	}
+
+	return problems, []string{fmt.Sprintf("%d objects, %s", len(objects), FormatSize(total))}
+}
+
+func describeStatError(key string, err error) string {
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Sprintf("%s: missing", key)
+	}
+	return err.Error()
+}
+
+func itemDataObjects(st storage.Storage, item BackupItem) ([]manifest.Object, error) {
+	if item.Entry != nil {
+		var objects []manifest.Object
+		if item.Entry.Split() {
+			objects = append(objects, item.Entry.Parts...)
+		} else if item.Entry.Data != nil {
+			objects = append(objects, *item.Entry.Data)
+		}
+		for i := range objects {
+			objects[i].Key = item.S3BaseFolder + objects[i].Key
+		}
+		return objects, nil
+	}
+
+	if !strings.HasSuffix(item.DataItem, "/") {
+		stored, err := st.Stat(item.DataItem)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []manifest.Object{{Key: stored.Key, Size: stored.Size}}, nil
+	}
+
+	resp, err := storage.ListAll(st, storage.ListOptions{Prefix: item.DataItem})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list parts: %w", err)
+	}
+	objects := make([]manifest.Object, 0, len(resp.Objects))
+	for _, stored := range resp.Objects {
+		if strings.HasSuffix(stored.Key, "/") {
+			continue
+		}
+		objects = append(objects, manifest.Object{Key: stored.Key, Size: stored.Size})
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	return objects, nil
+}
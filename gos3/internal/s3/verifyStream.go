@@ -0,0 +1,189 @@
+package s3
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gos3/internal/archive"
+	"gos3/internal/config"
+	"gos3/internal/manifest"
+	"gos3/internal/script"
+	"gos3/internal/storage"
+)
+
+// Only ciphertext is written to workFolder.
+func verifyStream(st storage.Storage, item BackupItem, workFolder, privateKeyPassword string, cfg config.Config) (problems, details []string) {
+	reader, err := OpenItem(st, item, workFolder, privateKeyPassword, cfg)
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+
+	var contentProblems []string
+	var contentDetails []string
+	switch itemKind(item) {
+	case manifest.KindArchive:
+		contentProblems, contentDetails = verifyArchive(reader, item.Name)
+	case manifest.KindMetadata:
+		var metadata script.ArchiveMetadata
+		err = json.NewDecoder(reader).Decode(&metadata)
+		if err != nil {
+			contentProblems = append(contentProblems, fmt.Sprintf("invalid archive metadata: %v", err))
+		}
+	case manifest.KindIndex:
+		_, err = archive.ReadIndex(reader)
+		if err != nil {
+			contentProblems = append(contentProblems, err.Error())
+		}
+	}
+
+	err = reader.Close()
+	problems = append(problems, reader.PartProblems()...)
+	if err != nil {
+		return append(problems, err.Error()), nil
+	}
+	problems = append(problems, contentProblems...)
+
+	if item.Entry != nil {
+		sum := reader.CiphertextSHA256()
+		if item.Entry.SHA256 != "" && sum != item.Entry.SHA256 {
+			problems = append(problems, fmt.Sprintf("ciphertext sha256 %s, manifest %s", sum, item.Entry.SHA256))
+		}
+		if reader.PlaintextSize() != item.Entry.PlaintextSize {
+			problems = append(problems, fmt.Sprintf("plaintext size %d, manifest %d", reader.PlaintextSize(), item.Entry.PlaintextSize))
+		}
+	}
+
+	details = append([]string{fmt.Sprintf("decrypted %s", FormatSize(reader.PlaintextSize()))}, contentDetails...)
+	return problems, details
+}
+
+type feedResult struct {
+	problems []string
+	err      error
+}
+
+// The input is always closed so decryption sees the end of the stream.
+func feedCiphertext(st storage.Storage, objects []manifest.Object, input io.WriteCloser, whole hash.Hash, workFolder string) feedResult {
+	defer input.Close()
+
+	var result feedResult
+	for i, object := range objects {
+		localPath := filepath.Join(workFolder, fmt.Sprintf("part-%04d", i))
+		err := st.Get(object.Key, localPath)
+		if err != nil {
+			result.err = fmt.Errorf("failed to download %s: %w", object.Key, err)
+			return result
+		}
+
+		file, err := os.Open(localPath)
+		if err != nil {
+			os.Remove(localPath)
+			result.err = err
+			return result
+		}
+		part := sha256.New()
+		_, err = io.Copy(io.MultiWriter(input, whole, part), file)
+		file.Close()
+		os.Remove(localPath)
+		if err != nil {
+			result.err = fmt.Errorf("failed to stream %s: %w", object.Key, err)
+			return result
+		}
+
+		sum := hex.EncodeToString(part.Sum(nil))
+		if object.SHA256 != "" && sum != object.SHA256 {
+			result.problems = append(result.problems, fmt.Sprintf("%s: sha256 %s, manifest %s", object.Key, sum, object.SHA256))
+		}
+	}
+	return result
+}
+
+func verifyArchive(input io.Reader, name string) (problems, details []string) {
+	_, _, codec, ok := script.ParseArchiveName(name)
+	if !ok {
+		return []string{fmt.Sprintf("cannot determine compression codec of %s", name)}, nil
+	}
+
+	reader, err := archive.Decompress(codec, input)
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+
+	tarStream := &countingReader{reader: reader}
+	entries, err := walkTar(tarStream)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("invalid tar after %d entries: %v", entries, err))
+	}
+	_, _ = io.Copy(io.Discard, tarStream)
+	if err == nil && tarStream.count == 0 {
+		problems = append(problems, "archive stream is empty")
+	}
+
+	err = reader.Close()
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	return problems, []string{fmt.Sprintf("%d tar entries, %s uncompressed", entries, FormatSize(tarStream.count))}
+}
+
+func walkTar(input io.Reader) (int, error) {
+	reader := tar.NewReader(input)
+	entries := 0
+	for {
+		_, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		_, err = io.Copy(io.Discard, reader)
+		if err != nil {
+			return entries, err
+		}
+		entries++
+	}
+}
+
+func itemKind(item BackupItem) string {
+	if item.Entry != nil {
+		return item.Entry.Kind
+	}
+	if strings.HasSuffix(item.Name, script.ArchiveMetadataSuffix) {
+		return manifest.KindMetadata
+	}
+	if strings.HasSuffix(item.Name, archive.IndexSuffix) {
+		return manifest.KindIndex
+	}
+	if _, _, _, ok := script.ParseArchiveName(item.Name); ok {
+		return manifest.KindArchive
+	}
+	return manifest.KindDeletedList
+}
+
+// The last line of stderr usually names the actual failure.
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return lines[len(lines)-1]
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.count += int64(n)
+	return n, err
+}
@@ -2,6 +2,7 @@ package script
 
 import (
 	"fmt"
+	"log/slog"
 	"os/exec"
 	"path/filepath"
 
@@ -18,7 +19,7 @@ func KeyGenerate(configuration config.Config) error {
 		return fmt.Errorf("error executing key-generate.sh: %w\nOutput: %s", err, string(output))
 	}
 
-	fmt.Println(string(output))
+	logOutput("key-generate.sh", output, slog.LevelInfo, configuration)
 	return nil
 }
 
@@ -35,7 +36,7 @@ func KeyEncrypt(inputFile, outputFile, publicKeyFile string, configuration confi
 		return fmt.Errorf("error executing key-encrypt.sh: %w\nOutput: %s", err, string(output))
 	}
 
-	fmt.Println(string(output))
+	logOutput("key-encrypt.sh", output, slog.LevelDebug, configuration)
 	return nil
 }
 
@@ -52,6 +53,6 @@ func KeyDecrypt(inputFile, outputFile, privateKeyFile string, configuration conf
 		return fmt.Errorf("error executing key-decrypt.sh: %w\nOutput: %s", err, string(output))
 	}
 
-	fmt.Println(string(output))
+	logOutput("key-decrypt.sh", output, slog.LevelDebug, configuration)
 	return nil
 }
@@ -0,0 +1,128 @@
+package script
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gos3/internal/config"
+)
+
+// Codec is a compression format supported by volume-backup.sh and
+// volume-restore.sh. Decompressor is empty for uncompressed archives.
+type Codec struct {
+	Name         string
+	Extension    string
+	Decompressor []string
+}
+
+const DefaultCodec = "gzip"
+
+// Codecs is ordered so that longer extensions are matched first.
+var Codecs = []Codec{
+	{Name: "gzip", Extension: ".tar.gz", Decompressor: []string{"gzip", "-dc"}},
+	{Name: "zstd", Extension: ".tar.zst", Decompressor: []string{"zstd", "-q", "-dc"}},
+	{Name: "xz", Extension: ".tar.xz", Decompressor: []string{"xz", "-dc"}},
+	{Name: "lz4", Extension: ".tar.lz4", Decompressor: []string{"lz4", "-q", "-dc"}},
+	{Name: "none", Extension: ".tar"},
+}
+
+func GetCodec(name string) (Codec, error) {
+	if name == "" {
+		name = DefaultCodec
+	}
+	for _, codec := range Codecs {
+		if codec.Name == name {
+			return codec, nil
+		}
+	}
+	return Codec{}, fmt.Errorf("unknown compression codec: %s", name)
+}
+
+// ParseArchiveName splits "<base>[.incr]<extension>" into its parts. ok is
+// false when the name does not end with a known archive extension.
+func ParseArchiveName(name string) (base string, incremental bool, codec Codec, ok bool) {
+	for _, c := range Codecs {
+		if strings.HasSuffix(name, c.Extension) {
+			base = strings.TrimSuffix(name, c.Extension)
+			base, incremental = strings.CutSuffix(base, IncrementalMarker)
+			return base, incremental, c, true
+		}
+	}
+	return "", false, Codec{}, false
+}
+
+// ArchiveMetadata is written next to every archive as "<archive>.meta" so a
+// restore can pick the right decompressor without relying on the file name.
+// BaseFolder is the date folder an incremental archive was diffed against.
+type ArchiveMetadata struct {
+	Codec        string    `json:"codec"`
+	Level        int       `json:"level,omitempty"`
+	Threads      int       `json:"threads,omitempty"`
+	Incremental  bool      `json:"incremental,omitempty"`
+	BaseFolder   string    `json:"baseFolder,omitempty"`
+	OriginalSize int64     `json:"originalSize"`
+	FinalSize    int64     `json:"finalSize"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+const ArchiveMetadataSuffix = ".meta"
+
+func WriteArchiveMetadata(archivePath string, metadata ArchiveMetadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode archive metadata: %w", err)
+	}
+	return os.WriteFile(archivePath+ArchiveMetadataSuffix, data, 0644)
+}
+
+// ReadArchiveMetadata returns nil when the archive has no metadata file.
+func ReadArchiveMetadata(archivePath string) (*ArchiveMetadata, error) {
+	data, err := os.ReadFile(archivePath + ArchiveMetadataSuffix)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive metadata: %w", err)
+	}
+
+	var metadata ArchiveMetadata
+	err = json.Unmarshal(data, &metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse archive metadata: %w", err)
+	}
+	return &metadata, nil
+}
+
+// archiveCodec prefers the metadata file of an archive over its extension.
+func archiveCodec(archivePath string) (Codec, error) {
+	metadata, err := ReadArchiveMetadata(archivePath)
+	if err != nil {
+		return Codec{}, err
+	}
+	if metadata != nil && metadata.Codec != "" {
+		return GetCodec(metadata.Codec)
+	}
+
+	_, _, codec, ok := ParseArchiveName(archivePath)
+	if !ok {
+		return Codec{}, fmt.Errorf("cannot determine compression codec of %s", archivePath)
+	}
+	return codec, nil
+}
+
+func compressionArgs(compression config.CompressionConfig) []string {
+	args := []string{"--codec", compression.Codec}
+	if compression.Codec == "" {
+		args[1] = DefaultCodec
+	}
+	if compression.Level > 0 {
+		args = append(args, "--level", fmt.Sprintf("%d", compression.Level))
+	}
+	if compression.Threads > 0 {
+		args = append(args, "--threads", fmt.Sprintf("%d", compression.Threads))
+	}
+	return args
+}
@@ -3,7 +3,7 @@ package script
 import (
 	"fmt"
 	"gos3/internal/config"
-	"log"
+	"log/slog"
 	"os/exec"
 	"path/filepath"
 )
@@ -31,6 +31,6 @@ func KeyDecrypt2(inputFile, outputFile, encryptedPrivateKeyFile string, privateK
 		return fmt.Errorf("error executing key-decrypt2.sh: %w\nOutput: %s", err, string(output))
 	}
 
-	log.Printf("KeyDecrypt2 output: %s", string(output))
+	logOutput("key-decrypt2.sh", output, slog.LevelDebug, configuration)
 	return nil
 }
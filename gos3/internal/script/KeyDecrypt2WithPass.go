@@ -2,7 +2,7 @@ package script
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os/exec"
 	"path/filepath"
 
@@ -23,6 +23,6 @@ func KeyDecrypt2WithPass(inputFile, outputFile, encryptedPrivateKeyFile, private
 		return fmt.Errorf("error executing key-decrypt2-withpass.sh: %w\nOutput: %s", err, string(output))
 	}
 
-	log.Printf("KeyDecrypt2WithPass output: %s", string(output))
+	logOutput("key-decrypt2-withpass.sh", output, slog.LevelDebug, configuration)
 	return nil
 }
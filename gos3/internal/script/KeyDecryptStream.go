@@ -0,0 +1,20 @@
+package script
+
+import (
+	"os/exec"
+	"path/filepath"
+
+	"gos3/internal/config"
+)
+
+// KeyDecryptStream prepares key-decrypt2-stream.sh, which decrypts stdin to
+// stdout. The caller starts and waits for the command.
+func KeyDecryptStream(passFile, encryptedPrivateKeyFile, privateKeyPassword string, configuration config.Config) *exec.Cmd {
+	scriptPath := filepath.Join(configuration.App.ScriptsFolder, "key-decrypt2-stream.sh")
+	cmd := exec.Command(scriptPath,
+		config.MustGetAbsPathRelativeToAppFolder(passFile, configuration),
+		config.MustGetAbsPathRelativeToAppFolder(encryptedPrivateKeyFile, configuration),
+		privateKeyPassword)
+	cmd.Dir = configuration.AppFolders.ScriptsFolder
+	return cmd
+}
@@ -2,6 +2,7 @@ package script
 
 import (
 	"fmt"
+	"log/slog"
 	"os/exec"
 	"path/filepath"
 
@@ -20,7 +21,7 @@ func Split(folderPath, splitSize string, configuration config.Config) error {
 		return fmt.Errorf("error executing split.sh: %w\nOutput: %s", err, string(output))
 	}
 
-	fmt.Println(string(output))
+	logOutput("split.sh", output, slog.LevelDebug, configuration)
 	return nil
 }
 
@@ -34,6 +35,6 @@ func Join(folderPath string, configuration config.Config) error {
 		return fmt.Errorf("error executing join.sh: %w\nOutput: %s", err, string(output))
 	}
 
-	fmt.Println(string(output))
+	logOutput("join.sh", output, slog.LevelDebug, configuration)
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"gos3/internal/config"
 )
@@ -18,14 +19,33 @@ type VolumeBackupResult struct {
 	TimeElapsed      float64
 }
 
-func VolumeBackup(volumeName, backupFileName string, compress bool, configuration config.Config) (*VolumeBackupResult, error) {
-	scriptPath := filepath.Join(configuration.App.ScriptsFolder, "volume-backup.sh")
+func VolumeBackup(volumeName, backupFileName string, compression config.CompressionConfig, configuration config.Config) (*VolumeBackupResult, error) {
+	backupFilePath := config.MustGetAbsPathRelativeToAppFolder(backupFileName, configuration)
+	args := append([]string{volumeName, backupFilePath}, compressionArgs(compression)...)
+
+	return runVolumeBackup(args, backupFilePath, compression, "", configuration)
+}
+
+// VolumeBackupFiles archives only the volume paths listed in fileListPath,
+// one per line and relative to the volume root, as an incremental backup on
+// top of the archive in baseFolder.
+func VolumeBackupFiles(volumeName, backupFileName, fileListPath, baseFolder string, compression config.CompressionConfig, configuration config.Config) (*VolumeBackupResult, error) {
+	backupFilePath := config.MustGetAbsPathRelativeToAppFolder(backupFileName, configuration)
+	args := append([]string{volumeName, backupFilePath,
+		"--files-from", config.MustGetAbsPathRelativeToAppFolder(fileListPath, configuration)},
+		compressionArgs(compression)...)
+
+	return runVolumeBackup(args, backupFilePath, compression, baseFolder, configuration)
+}
 
-	args := []string{volumeName, config.MustGetAbsPathRelativeToAppFolder(backupFileName, configuration)}
-	if !compress {
-		args = append(args, "--no-compression")
+func runVolumeBackup(args []string, backupFilePath string, compression config.CompressionConfig, baseFolder string, configuration config.Config) (*VolumeBackupResult, error) {
+	codec, err := GetCodec(compression.Codec)
+	if err != nil {
+		return nil, err
 	}
 
+	scriptPath := filepath.Join(configuration.App.ScriptsFolder, "volume-backup.sh")
+
 	cmd := exec.Command(scriptPath, args...)
 	cmd.Dir = configuration.AppFolders.ScriptsFolder
 
@@ -61,5 +81,19 @@ func VolumeBackup(volumeName, backupFileName string, compress bool, configuratio
 		return nil, fmt.Errorf("command finished with error: %w", err)
 	}
 
+	err = WriteArchiveMetadata(backupFilePath, ArchiveMetadata{
+		Codec:        codec.Name,
+		Level:        compression.Level,
+		Threads:      compression.Threads,
+		Incremental:  baseFolder != "",
+		BaseFolder:   baseFolder,
+		OriginalSize: result.OriginalSize,
+		FinalSize:    result.FinalSize,
+		CreatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
@@ -0,0 +1,110 @@
+package script
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gos3/internal/config"
+)
+
+// Incremental backups are stored next to full ones, sharing the base name
+// "<definition>-<volume>". Their archive is "<base>.incr<codec extension>"
+// and the paths removed since the previous run are listed in
+// "<base>.incr.deleted".
+const (
+	IncrementalMarker        = ".incr"
+	IncrementalDeletedSuffix = ".incr.deleted"
+)
+
+type VolumeIndexEntry struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	Inode   uint64 `json:"inode"`
+	Symlink bool   `json:"symlink,omitempty"`
+	Hash    string `json:"hash,omitempty"`
+}
+
+// VolumeIndex lists every regular file and symlink of a volume together with
+// its size, modification time and inode. Paths are relative to the volume root
+// and keep the "./" prefix used by tar.
+func VolumeIndex(volumeName string, configuration config.Config) ([]VolumeIndexEntry, error) {
+	scriptPath := filepath.Join(configuration.App.ScriptsFolder, "volume-index.sh")
+	cmd := exec.Command(scriptPath, "stat", volumeName)
+	cmd.Dir = configuration.AppFolders.ScriptsFolder
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error creating stdout pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting command: %w", err)
+	}
+
+	var entries []VolumeIndexEntry
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "|", 5)
+		if len(parts) != 5 {
+			continue
+		}
+		inode, _ := strconv.ParseUint(parts[0], 10, 64)
+		size, _ := strconv.ParseInt(parts[1], 10, 64)
+		modTime, _ := strconv.ParseInt(parts[2], 10, 64)
+		entries = append(entries, VolumeIndexEntry{
+			Path:    parts[4],
+			Size:    size,
+			ModTime: modTime,
+			Inode:   inode,
+			Symlink: parts[3] == "symbolic link",
+		})
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("error executing volume-index.sh: %w\nOutput: %s", err, stderr.String())
+	}
+
+	return entries, nil
+}
+
+// VolumeHash returns the sha256 of each listed regular file, keyed by path.
+func VolumeHash(volumeName string, paths []string, configuration config.Config) (map[string]string, error) {
+	hashes := make(map[string]string, len(paths))
+	if len(paths) == 0 {
+		return hashes, nil
+	}
+
+	scriptPath := filepath.Join(configuration.App.ScriptsFolder, "volume-index.sh")
+	cmd := exec.Command(scriptPath, "hash", volumeName)
+	cmd.Dir = configuration.AppFolders.ScriptsFolder
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\n") + "\n")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error executing volume-index.sh: %w\nOutput: %s", err, stderr.String())
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		hash, path, found := strings.Cut(scanner.Text(), "  ")
+		if found {
+			hashes[path] = hash
+		}
+	}
+
+	return hashes, nil
+}
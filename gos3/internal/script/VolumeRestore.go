@@ -10,9 +10,30 @@ import (
 )
 
 func VolumeRestore(volumeName, backupFileName string, configuration config.Config) error {
+	return runVolumeRestore(volumeName, backupFileName, nil, configuration)
+}
+
+// VolumeRestoreIncremental extracts an incremental backup on top of the volume
+// and removes the paths listed in deletedListFile.
+func VolumeRestoreIncremental(volumeName, backupFileName, deletedListFile string, configuration config.Config) error {
+	args := []string{"--incremental"}
+	if deletedListFile != "" {
+		args = append(args, config.MustGetAbsPathRelativeToAppFolder(deletedListFile, configuration))
+	}
+	return runVolumeRestore(volumeName, backupFileName, args, configuration)
+}
+
+func runVolumeRestore(volumeName, backupFileName string, options []string, configuration config.Config) error {
+	backupFilePath := config.MustGetAbsPathRelativeToAppFolder(backupFileName, configuration)
+	codec, err := archiveCodec(backupFilePath)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{volumeName, backupFilePath, "--codec", codec.Name}, options...)
 	scriptPath := filepath.Join(configuration.App.ScriptsFolder, "volume-restore.sh")
 
-	cmd := exec.Command(scriptPath, volumeName, config.MustGetAbsPathRelativeToAppFolder(backupFileName, configuration))
+	cmd := exec.Command(scriptPath, args...)
 	cmd.Dir = configuration.AppFolders.ScriptsFolder
 
 	stdout, err := cmd.StdoutPipe()
@@ -24,9 +45,10 @@ func VolumeRestore(volumeName, backupFileName string, configuration config.Confi
 		return fmt.Errorf("error starting command: %w", err)
 	}
 
+	logger := configuration.Logger().With("script", "volume-restore.sh", "volume", volumeName)
 	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
-		fmt.Println(scanner.Text())
+		logger.Debug(scanner.Text())
 	}
 
 	if err := cmd.Wait(); err != nil {
@@ -0,0 +1,20 @@
+package script
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"gos3/internal/config"
+)
+
+// Every line is its own record, so the output stays parseable in JSON logs.
+func logOutput(scriptName string, output []byte, level slog.Level, configuration config.Config) {
+	logger := configuration.Logger().With("script", scriptName)
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			logger.Log(context.Background(), level, line)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package serve
+
+import (
+	"fmt"
+	"gos3/internal/backupops"
+	"gos3/internal/compliance"
+	"gos3/internal/config"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Run schedules the configured jobs and blocks until SIGINT or SIGTERM. Jobs
+// never overlap, since they share the local backup and state folders.
+func Run(cfg config.Config) error {
+	scheduler := cron.New()
+	var running sync.Mutex
+	jobs := 0
+
+	schedule := func(name, spec string, job func()) error {
+		_, err := scheduler.AddFunc(spec, func() {
+			running.Lock()
+			defer running.Unlock()
+			cfg.Logger().Info("Running scheduled job", "job", name)
+			job()
+		})
+		if err != nil {
+			return fmt.Errorf("invalid schedule %q for %s: %w", spec, name, err)
+		}
+		cfg.Logger().Info("Scheduled job", "job", name, "schedule", spec)
+		jobs++
+		return nil
+	}
+
+	if cfg.Serve.BackupSchedule != "" {
+		err := schedule("backups", cfg.Serve.BackupSchedule, func() {
+			// A panic would end serve and every later run with it; report it
+			// to the heartbeat monitors and keep the schedule going.
+			defer func() {
+				if r := recover(); r != nil {
+					cfg.Logger().Error("Scheduled backup panicked", "error", r)
+					backupops.FailHeartbeats(cfg.BackupDefinitions, fmt.Sprintf("Backup run panicked: %v", r), cfg)
+				}
+			}()
+			err := backupops.PerformBackups(cfg)
+			if err != nil {
+				cfg.Logger().Error("Scheduled backup failed", "error", err)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, test := range cfg.RestoreTests {
+		if test.Schedule == "" {
+			continue
+		}
+		if !cfg.App.PrivateKeyPassword.IsSet() {
+			return fmt.Errorf("restore test %s is scheduled but app.privateKeyPassword is not configured", test.Name)
+		}
+
+		err := schedule("restore test "+test.Name, test.Schedule, func() {
+			password, err := cfg.App.PrivateKeyPassword.Resolve()
+			if err != nil {
+				cfg.Logger().Error("Restore test skipped, failed to read private key password", "restore_test", test.Name, "error", err)
+				return
+			}
+			backupops.RunRestoreTest(test, password, cfg)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.Compliance.Schedule != "" {
+		if cfg.Compliance.Email == "" && !cfg.Compliance.Upload {
+			return fmt.Errorf("compliance report is scheduled but neither compliance.email nor compliance.upload is set")
+		}
+
+		err := schedule("compliance report", cfg.Compliance.Schedule, func() {
+			err := compliance.Publish(cfg)
+			if err != nil {
+				cfg.Logger().Error("Scheduled compliance report failed", "error", err)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if jobs == 0 {
+		return fmt.Errorf("nothing to schedule: set serve.backupSchedule, a restore test schedule or compliance.schedule")
+	}
+
+	if cfg.Metrics.Listen != "" {
+		server, err := startMetricsServer(cfg)
+		if err != nil {
+			return err
+		}
+		defer server.Close()
+	}
+
+	if cfg.Report.URL != "" {
+		stop := make(chan struct{})
+		defer close(stop)
+		go flushReports(cfg, stop)
+	}
+
+	scheduler.Start()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+
+	cfg.Logger().Info("Waiting for running jobs to finish", "signal", sig.String())
+	<-scheduler.Stop().Done()
+	return nil
+}
@@ -0,0 +1,28 @@
+package serve
+
+import (
+	"gos3/internal/config"
+	"gos3/internal/report"
+	"time"
+)
+
+const reportFlushInterval = 5 * time.Minute
+
+// flushReports sends the reports queued while the hub was unreachable, on
+// start and then every reportFlushInterval until stop is closed.
+func flushReports(cfg config.Config, stop <-chan struct{}) {
+	ticker := time.NewTicker(reportFlushInterval)
+	defer ticker.Stop()
+	for {
+		err := report.Flush(cfg)
+		if err != nil {
+			cfg.Logger().Warn("Failed to send queued reports to hub", "error", err)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
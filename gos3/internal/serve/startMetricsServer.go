@@ -0,0 +1,42 @@
+package serve
+
+import (
+	"errors"
+	"fmt"
+	"gos3/internal/backupops"
+	"gos3/internal/config"
+	"gos3/internal/metrics"
+	"net"
+	"net/http"
+	"time"
+)
+
+// The listener is opened right away, so a bad address fails serve at startup.
+func startMetricsServer(cfg config.Config) (*http.Server, error) {
+	listener, err := net.Listen("tcp", cfg.Metrics.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for metrics: %w", err)
+	}
+
+	backupops.SeedMetrics(cfg)
+	go func() {
+		for _, target := range cfg.AllTargetNames() {
+			if targetCfg, err := cfg.ForTarget(target); err == nil {
+				backupops.RecordBucketSizes(targetCfg)
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			cfg.Logger().Error("Metrics server stopped", "error", err)
+		}
+	}()
+
+	cfg.Logger().Info("Serving metrics", "address", listener.Addr().String(), "path", "/metrics")
+	return server, nil
+}
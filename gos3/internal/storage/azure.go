@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gos3/internal/config"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+)
+
+const defaultAzureBlockSize = 8 * 1024 * 1024
+
+type azureStorage struct {
+	cfg       config.AzureConfig
+	client    *azblob.Client
+	container *container.Client
+	blockSize int64
+	limits    *targetLimiters
+}
+
+func NewAzure(cfg config.AzureConfig, bandwidth config.BandwidthConfig) (Storage, error) {
+	if cfg.Container == "" {
+		return nil, fmt.Errorf("azure storage requires a container")
+	}
+
+	var client *azblob.Client
+	var err error
+	if cfg.ConnectionString != "" {
+		client, err = azblob.NewClientFromConnectionString(cfg.ConnectionString, nil)
+	} else {
+		var cred *azblob.SharedKeyCredential
+		cred, err = azblob.NewSharedKeyCredential(cfg.AccountName, cfg.AccountKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create azure credentials: %w", err)
+		}
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://%s.blob.core.windows.net/", cfg.AccountName)
+		}
+		client, err = azblob.NewClientWithSharedKeyCredential(endpoint, cred, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create azure client: %w", err)
+	}
+
+	blockSize := config.ParseSize(cfg.BlockSize)
+	if blockSize <= 0 {
+		blockSize = defaultAzureBlockSize
+	}
+
+	return &azureStorage{
+		cfg:       cfg,
+		client:    client,
+		container: client.ServiceClient().NewContainerClient(cfg.Container),
+		blockSize: blockSize,
+		limits:    limitersFor(client.URL()+cfg.Container, bandwidth),
+	}, nil
+}
+
+func (a *azureStorage) Name() string {
+	return strings.TrimSuffix(a.client.URL(), "/") + "/" + a.cfg.Container
+}
+
+// Put uploads a file as a block blob. Blob storage has no folders, so folder
+// keys ending in "/" are ignored.
+func (a *azureStorage) Put(localPath, key string) error {
+	if strings.HasSuffix(key, "/") {
+		return nil
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", localPath, err)
+	}
+	defer file.Close()
+
+	concurrency := a.cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	// UploadFile reads the file itself, so throttled uploads stream it.
+	if a.limits != nil {
+		_, err = a.client.UploadStream(context.Background(), a.cfg.Container, key, a.limits.uploadReader(file), &azblob.UploadStreamOptions{
+			BlockSize:   a.blockSize,
+			Concurrency: concurrency,
+		})
+	} else {
+		_, err = a.client.UploadFile(context.Background(), a.cfg.Container, key, file, &azblob.UploadFileOptions{
+			BlockSize:   a.blockSize,
+			Concurrency: uint16(concurrency),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", localPath, err)
+	}
+	return nil
+}
+
+func (a *azureStorage) Get(key, localPath string) error {
+	err := os.MkdirAll(filepath.Dir(localPath), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	if a.limits != nil {
+		err = a.downloadStream(key, file)
+	} else {
+		_, err = a.client.DownloadFile(context.Background(), a.cfg.Container, key, file, nil)
+	}
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return nil
+}
+
+func (a *azureStorage) downloadStream(key string, file *os.File) error {
+	resp, err := a.client.DownloadStream(context.Background(), a.cfg.Container, key, nil)
+	if err != nil {
+		return err
+	}
+	body := resp.NewRetryReader(context.Background(), nil)
+	defer body.Close()
+
+	_, err = io.Copy(file, a.limits.downloadReader(body))
+	return err
+}
+
+func (a *azureStorage) List(options ListOptions) (ListPage, error) {
+	var marker, prefix *string
+	var maxResults *int32
+	if options.ContinuationToken != "" {
+		marker = to.Ptr(options.ContinuationToken)
+	}
+	if options.Prefix != "" {
+		prefix = to.Ptr(options.Prefix)
+	}
+	if options.MaxKeys > 0 {
+		maxResults = to.Ptr(int32(options.MaxKeys))
+	}
+
+	var page ListPage
+	if options.Delimiter != "" {
+		pager := a.container.NewListBlobsHierarchyPager(options.Delimiter, &container.ListBlobsHierarchyOptions{
+			Prefix:     prefix,
+			Marker:     marker,
+			MaxResults: maxResults,
+		})
+		resp, err := pager.NextPage(context.Background())
+		if err != nil {
+			return ListPage{}, fmt.Errorf("failed to list %s: %w", a.Name(), err)
+		}
+		for _, blobPrefix := range resp.Segment.BlobPrefixes {
+			page.Prefixes = append(page.Prefixes, *blobPrefix.Name)
+		}
+		page.Objects = azureObjects(resp.Segment.BlobItems)
+		page.ContinuationToken = deref(resp.NextMarker)
+	} else {
+		pager := a.container.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{
+			Prefix:     prefix,
+			Marker:     marker,
+			MaxResults: maxResults,
+		})
+		resp, err := pager.NextPage(context.Background())
+		if err != nil {
+			return ListPage{}, fmt.Errorf("failed to list %s: %w", a.Name(), err)
+		}
+		page.Objects = azureObjects(resp.Segment.BlobItems)
+		page.ContinuationToken = deref(resp.NextMarker)
+	}
+
+	page.IsTruncated = page.ContinuationToken != ""
+	return page, nil
+}
+
+func azureObjects(items []*container.BlobItem) []Object {
+	objects := make([]Object, 0, len(items))
+	for _, item := range items {
+		object := Object{Key: *item.Name}
+		if item.Properties != nil {
+			object.Size = deref(item.Properties.ContentLength)
+			object.LastModified = deref(item.Properties.LastModified)
+			if item.Properties.ETag != nil {
+				object.ETag = strings.Trim(string(*item.Properties.ETag), "\"")
+			}
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+func deref[T any](value *T) T {
+	var zero T
+	if value == nil {
+		return zero
+	}
+	return *value
+}
+
+func (a *azureStorage) Delete(key string) error {
+	_, err := a.client.DeleteBlob(context.Background(), a.cfg.Container, key, nil)
+	if err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (a *azureStorage) Stat(key string) (Object, error) {
+	props, err := a.container.NewBlobClient(key).GetProperties(context.Background(), nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return Object{}, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to stat %s: %w", key, err)
+	}
+
+	object := Object{
+		Key:          key,
+		Size:         deref(props.ContentLength),
+		LastModified: deref(props.LastModified),
+	}
+	if props.ETag != nil {
+		object.ETag = strings.Trim(string(*props.ETag), "\"")
+	}
+	return object, nil
+}
+
+func (a *azureStorage) Close() error {
+	return nil
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"gos3/internal/config"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+)
+
+// azuriteConnectionString uses the well-known development account of Azurite.
+const azuriteConnectionString = "DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;" +
+	"AccountKey=Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==;" +
+	"BlobEndpoint=http://%s/devstoreaccount1;"
+
+// startAzure creates a new container in the Azurite instance at
+// AZURITE_BLOB_ADDRESS (127.0.0.1:10000 by default) and skips the test when
+// Azurite is not running.
+func startAzure(t *testing.T) config.AzureConfig {
+	t.Helper()
+	address := os.Getenv("AZURITE_BLOB_ADDRESS")
+	if address == "" {
+		address = "127.0.0.1:10000"
+	}
+	conn, err := net.DialTimeout("tcp", address, time.Second)
+	if err != nil {
+		t.Skipf("Azurite is not running on %s", address)
+	}
+	conn.Close()
+
+	cfg := config.AzureConfig{
+		ConnectionString: fmt.Sprintf(azuriteConnectionString, address),
+		Container:        fmt.Sprintf("gos3-test-%d", time.Now().UnixNano()),
+		BlockSize:        "1K",
+		Concurrency:      4,
+	}
+	client, err := azblob.NewClientFromConnectionString(cfg.ConnectionString, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.CreateContainer(context.Background(), cfg.Container, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.DeleteContainer(context.Background(), cfg.Container, nil)
+	})
+	return cfg
+}
+
+func TestAzurePutGetListDelete(t *testing.T) {
+	cfg := startAzure(t)
+	st, err := NewAzure(cfg, config.BandwidthConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	// Larger than BlockSize, so the upload is staged in blocks.
+	content := bytes.Repeat([]byte("0123456789"), 500)
+	large := filepath.Join(t.TempDir(), "large")
+	err = os.WriteFile(large, content, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.Put(large, "backups/2026-01-01/large.cpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{
+		"backups/2026-01-01/a.cpt",
+		"backups/2026-01-01/c.cpt-split_parts/c.cpt.part-0000",
+		"backups/2026-01-02/a.cpt",
+	} {
+		err = st.Put(writeFile(t, key), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = st.Put(large, "backups/2026-01-03/")
+	if err != nil {
+		t.Errorf("Put of a folder key = %v, want nil", err)
+	}
+
+	object, err := st.Stat("backups/2026-01-01/large.cpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if object.Size != int64(len(content)) {
+		t.Errorf("Stat size = %d, want %d", object.Size, len(content))
+	}
+	_, err = st.Stat("backups/2026-01-01/missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat of missing key = %v, want ErrNotFound", err)
+	}
+
+	localPath := filepath.Join(t.TempDir(), "download")
+	err = st.Get("backups/2026-01-01/large.cpt", localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(localPath)
+	if !bytes.Equal(data, content) {
+		t.Errorf("Get returned %d bytes, want %d", len(data), len(content))
+	}
+	err = st.Get("backups/2026-01-01/missing", localPath)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of missing key = %v, want ErrNotFound", err)
+	}
+
+	page, err := st.List(ListOptions{Prefix: "backups/", Delimiter: "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"backups/2026-01-01/", "backups/2026-01-02/"}; !slices.Equal(page.Prefixes, want) {
+		t.Errorf("Prefixes = %v, want %v", page.Prefixes, want)
+	}
+
+	all, err := ListAll(st, ListOptions{Prefix: "backups/2026-01-01/", MaxKeys: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"backups/2026-01-01/a.cpt",
+		"backups/2026-01-01/c.cpt-split_parts/c.cpt.part-0000",
+		"backups/2026-01-01/large.cpt",
+	}
+	if !slices.Equal(keys(all.Objects), want) {
+		t.Errorf("paged objects = %v, want %v", keys(all.Objects), want)
+	}
+
+	err = st.Delete("backups/2026-01-02/a.cpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.Delete("backups/2026-01-02/a.cpt")
+	if err != nil {
+		t.Errorf("Delete of missing key = %v, want nil", err)
+	}
+	page, err = st.List(ListOptions{Prefix: "backups/2026-01-02/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Objects) != 0 {
+		t.Errorf("Objects after Delete = %v", keys(page.Objects))
+	}
+}
@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"gos3/internal/config"
+)
+
+// bandwidthLimiter is a token bucket. The rate is looked up on each wait so
+// time-of-day schedules take effect during long transfers; 0 means unlimited.
+type bandwidthLimiter struct {
+	mu     sync.Mutex
+	rate   func(time.Time) int64
+	tokens float64
+	last   time.Time
+}
+
+const bandwidthChunkSize = 32 * 1024
+
+type targetLimiters struct {
+	upload   *bandwidthLimiter
+	download *bandwidthLimiter
+}
+
+// limiters are keyed on target and bandwidth settings, so transfers to one
+// target share its limits.
+var limiters = struct {
+	mu      sync.Mutex
+	targets map[string]*targetLimiters
+}{targets: make(map[string]*targetLimiters)}
+
+// limitersFor returns nil when bandwidth sets no limits.
+func limitersFor(target string, bandwidth config.BandwidthConfig) *targetLimiters {
+	if !bandwidthLimited(bandwidth) {
+		return nil
+	}
+	key := fmt.Sprintf("%s\x00%v", target, bandwidth)
+
+	limiters.mu.Lock()
+	defer limiters.mu.Unlock()
+	if limits, ok := limiters.targets[key]; ok {
+		return limits
+	}
+
+	limits := &targetLimiters{
+		upload: &bandwidthLimiter{rate: func(now time.Time) int64 {
+			limit := bandwidth.Upload
+			if schedule := activeBandwidthSchedule(bandwidth.Schedules, now); schedule != nil {
+				limit = schedule.Upload
+			}
+			return config.ParseSize(limit)
+		}},
+		download: &bandwidthLimiter{rate: func(now time.Time) int64 {
+			limit := bandwidth.Download
+			if schedule := activeBandwidthSchedule(bandwidth.Schedules, now); schedule != nil {
+				limit = schedule.Download
+			}
+			return config.ParseSize(limit)
+		}},
+	}
+	limiters.targets[key] = limits
+	return limits
+}
+
+// uploadReader throttles reader to the upload limit; l may be nil.
+func (l *targetLimiters) uploadReader(reader io.Reader) io.Reader {
+	if l == nil {
+		return reader
+	}
+	return &limitedReader{reader: reader, limiter: l.upload}
+}
+
+// downloadReader throttles reader to the download limit; l may be nil.
+func (l *targetLimiters) downloadReader(reader io.Reader) io.Reader {
+	if l == nil {
+		return reader
+	}
+	return &limitedReader{reader: reader, limiter: l.download}
+}
+
+// downloadWriter throttles writer to the download limit; l may be nil.
+func (l *targetLimiters) downloadWriter(writer io.Writer) io.Writer {
+	if l == nil {
+		return writer
+	}
+	return &limitedWriter{writer: writer, limiter: l.download}
+}
+
+func bandwidthLimited(cfg config.BandwidthConfig) bool {
+	return config.ParseSize(cfg.Upload) > 0 || config.ParseSize(cfg.Download) > 0 || len(cfg.Schedules) > 0
+}
+
+func (l *bandwidthLimiter) wait(n int) {
+	for n > 0 {
+		l.mu.Lock()
+		now := time.Now()
+		rate := l.rate(now)
+		if rate <= 0 {
+			l.tokens = 0
+			l.last = now
+			l.mu.Unlock()
+			return
+		}
+
+		if !l.last.IsZero() {
+			l.tokens += now.Sub(l.last).Seconds() * float64(rate)
+		}
+		l.last = now
+		if l.tokens > float64(rate) {
+			l.tokens = float64(rate)
+		}
+
+		take := n
+		if int64(take) > rate {
+			take = int(rate)
+		}
+
+		if l.tokens >= float64(take) {
+			l.tokens -= float64(take)
+			n -= take
+			l.mu.Unlock()
+			continue
+		}
+
+		missing := float64(take) - l.tokens
+		l.mu.Unlock()
+		time.Sleep(time.Duration(missing / float64(rate) * float64(time.Second)))
+	}
+}
+
+func activeBandwidthSchedule(schedules []config.BandwidthSchedule, now time.Time) *config.BandwidthSchedule {
+	minutes := now.Hour()*60 + now.Minute()
+	for i, schedule := range schedules {
+		start, err := parseClock(schedule.Start)
+		if err != nil {
+			continue
+		}
+		end, err := parseClock(schedule.End)
+		if err != nil {
+			continue
+		}
+
+		if start <= end && minutes >= start && minutes < end {
+			return &schedules[i]
+		}
+		if start > end && (minutes >= start || minutes < end) {
+			return &schedules[i]
+		}
+	}
+	return nil
+}
+
+func parseClock(value string) (int, error) {
+	var hour, minute int
+	_, err := fmt.Sscanf(value, "%d:%d", &hour, &minute)
+	if err != nil || hour < 0 || hour > 24 || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid time of day %q", value)
+	}
+	return hour*60 + minute, nil
+}
+
+type limitedReader struct {
+	reader  io.Reader
+	limiter *bandwidthLimiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > bandwidthChunkSize {
+		p = p[:bandwidthChunkSize]
+	}
+	n, err := r.reader.Read(p)
+	r.limiter.wait(n)
+	return n, err
+}
+
+type limitedWriterAt struct {
+	writer  io.WriterAt
+	limiter *bandwidthLimiter
+}
+
+func (w *limitedWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	w.limiter.wait(len(p))
+	return w.writer.WriteAt(p, off)
+}
+
+type limitedWriter struct {
+	writer  io.Writer
+	limiter *bandwidthLimiter
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), bandwidthChunkSize)]
+		w.limiter.wait(len(chunk))
+		n, err := w.writer.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gos3/internal/config"
+)
+
+func TestLocalStorageBandwidthLimits(t *testing.T) {
+	bandwidth := config.BandwidthConfig{Upload: "64K", Download: "64K"}
+	st, err := NewLocal(t.TempDir(), bandwidth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := writeFile(t, string(make([]byte, 32*1024)))
+	started := time.Now()
+	err = st.Put(file, "backups/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed < 400*time.Millisecond {
+		t.Errorf("upload of 32K at 64K/s took %v", elapsed)
+	}
+
+	started = time.Now()
+	err = st.Get("backups/file", filepath.Join(t.TempDir(), "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed < 400*time.Millisecond {
+		t.Errorf("download of 32K at 64K/s took %v", elapsed)
+	}
+}
@@ -1,4 +1,4 @@
-package s3
+package storage
 
 import (
 	"gos3/internal/config"
@@ -0,0 +1,56 @@
+package storage
+
+// Iterator walks every page of a listing, following continuation tokens.
+type Iterator struct {
+	st      Storage
+	options ListOptions
+	page    ListPage
+	done    bool
+	err     error
+}
+
+// NewIterator starts at options.ContinuationToken; options.MaxKeys is the
+// page size.
+func NewIterator(st Storage, options ListOptions) *Iterator {
+	return &Iterator{st: st, options: options}
+}
+
+// Next fetches the next page and reports whether there was one.
+func (it *Iterator) Next() bool {
+	if it.done || it.err != nil {
+		return false
+	}
+
+	page, err := it.st.List(it.options)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.page = page
+	if !page.IsTruncated || page.ContinuationToken == "" || page.ContinuationToken == it.options.ContinuationToken {
+		it.done = true
+	}
+	it.options.ContinuationToken = page.ContinuationToken
+	return true
+}
+
+func (it *Iterator) Page() ListPage {
+	return it.page
+}
+
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// ListAll collects every page of a listing into one.
+func ListAll(st Storage, options ListOptions) (ListPage, error) {
+	var all ListPage
+	it := NewIterator(st, options)
+	for it.Next() {
+		page := it.Page()
+		all.Objects = append(all.Objects, page.Objects...)
+		all.Prefixes = append(all.Prefixes, page.Prefixes...)
+	}
+	return all, it.Err()
+}
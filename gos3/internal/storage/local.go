@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gos3/internal/config"
+)
+
+type localStorage struct {
+	root   string
+	limits *targetLimiters
+}
+
+func NewLocal(root string, bandwidth config.BandwidthConfig) (Storage, error) {
+	if root == "" {
+		return nil, fmt.Errorf("local storage requires a path")
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for %s: %w", root, err)
+	}
+	err = os.MkdirAll(absRoot, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage folder %s: %w", absRoot, err)
+	}
+	return &localStorage{root: absRoot, limits: limitersFor("file://"+absRoot, bandwidth)}, nil
+}
+
+func (l *localStorage) Name() string {
+	return "file://" + l.root
+}
+
+func (l *localStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + filepath.FromSlash(key))
+	if clean == string(filepath.Separator) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(l.root, clean), nil
+}
+
+func (l *localStorage) Put(localPath, key string) error {
+	if strings.HasSuffix(key, "/") {
+		path, err := l.path(strings.TrimSuffix(key, "/"))
+		if err != nil {
+			return err
+		}
+		return os.MkdirAll(path, 0755)
+	}
+
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create folder for %s: %w", key, err)
+	}
+
+	tmpPath := target + ".partial"
+	err = copyFile(localPath, tmpPath, l.limits.uploadReader)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, target)
+}
+
+func (l *localStorage) Get(key, localPath string) error {
+	source, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(source); os.IsNotExist(err) {
+		return fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	return copyFile(source, localPath, l.limits.downloadReader)
+}
+
+func (l *localStorage) List(options ListOptions) (ListPage, error) {
+	dir, err := l.path(prefixFolder(options.Prefix))
+	if err != nil {
+		dir = l.root
+	}
+
+	var objects []Object
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, ".partial") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, options.Prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return ListPage{}, fmt.Errorf("failed to list %s: %w", l.root, err)
+	}
+
+	return paginate(objects, options), nil
+}
+
+// Delete removes the file and any folders left empty by it.
+func (l *localStorage) Delete(key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+
+	for dir := filepath.Dir(path); dir != l.root && strings.HasPrefix(dir, l.root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (l *localStorage) Stat(key string) (Object, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return Object{}, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to stat %s: %w", key, err)
+	}
+	return Object{Key: key, Size: info.Size(), LastModified: info.ModTime()}, nil
+}
+
+func (l *localStorage) Close() error {
+	return nil
+}
+
+// copyFile reads source through throttle.
+func copyFile(source, target string, throttle func(io.Reader) io.Reader) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", source, err)
+	}
+	defer in.Close()
+
+	err = os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	out, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", target, err)
+	}
+
+	_, err = io.Copy(out, throttle(in))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy %s to %s: %w", source, target, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+)
+
+const defaultPageSize = 1000
+
+func prefixFolder(prefix string) string {
+	i := strings.LastIndex(prefix, "/")
+	if i < 0 {
+		return ""
+	}
+	return prefix[:i]
+}
+
+// paginate emulates ListObjectsV2 for backends that walk a file tree. The
+// continuation token is the last key or prefix returned.
+func paginate(objects []Object, options ListOptions) ListPage {
+	maxKeys := options.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = defaultPageSize
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+
+	var page ListPage
+	seenPrefixes := make(map[string]bool)
+	count := 0
+	for _, object := range objects {
+		if !strings.HasPrefix(object.Key, options.Prefix) {
+			continue
+		}
+
+		entry := object.Key
+		isPrefix := false
+		if options.Delimiter != "" {
+			rest := strings.TrimPrefix(object.Key, options.Prefix)
+			if i := strings.Index(rest, options.Delimiter); i >= 0 {
+				entry = options.Prefix + rest[:i+len(options.Delimiter)]
+				isPrefix = true
+			}
+		}
+
+		if options.ContinuationToken != "" && entry <= options.ContinuationToken {
+			continue
+		}
+		if isPrefix && seenPrefixes[entry] {
+			continue
+		}
+
+		if count == maxKeys {
+			page.IsTruncated = true
+			break
+		}
+		count++
+		page.ContinuationToken = entry
+
+		if isPrefix {
+			seenPrefixes[entry] = true
+			page.Prefixes = append(page.Prefixes, entry)
+			continue
+		}
+		page.Objects = append(page.Objects, object)
+	}
+
+	if !page.IsTruncated {
+		page.ContinuationToken = ""
+	}
+	return page
+}
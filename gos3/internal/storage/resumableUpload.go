@@ -0,0 +1,373 @@
+package storage
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+const defaultAbandonedUploadMaxAge = 48 * time.Hour
+
+// multipartState is keyed on the remote key. It is resumed when the local
+// file has the recorded content, so a retry uploading the backup kept by a
+// failed run continues it. ModTime and Size validate the cached SHA256.
+type multipartState struct {
+	Bucket    string           `json:"bucket"`
+	Key       string           `json:"key"`
+	LocalPath string           `json:"localPath"`
+	Size      int64            `json:"size"`
+	ModTime   time.Time        `json:"modTime"`
+	SHA256    string           `json:"sha256"`
+	PartSize  int64            `json:"partSize"`
+	UploadID  string           `json:"uploadId"`
+	Parts     map[int64]string `json:"parts"`
+	CreatedAt time.Time        `json:"createdAt"`
+
+	mu   sync.Mutex
+	path string
+}
+
+func (s *s3Storage) uploadMultipartResumable(file *os.File, info os.FileInfo, remotePath string) error {
+	svc := s.uploader.S3
+	localPath := file.Name()
+
+	state, err := s.loadMultipartState(file, info, remotePath)
+	if err != nil {
+		return err
+	}
+
+	if state.UploadID != "" {
+		parts, err := listUploadedParts(svc, state)
+		if err != nil {
+			s.logger.Warn("Cannot resume upload, starting over", "file", localPath, "error", err)
+			state.UploadID = ""
+			state.Parts = make(map[int64]string)
+		} else {
+			state.Parts = parts
+			s.logger.Info("Resuming upload", "file", localPath, "parts", len(parts))
+		}
+	}
+
+	if state.UploadID == "" {
+		output, err := svc.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+			Bucket: aws.String(state.Bucket),
+			Key:    aws.String(state.Key),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create multipart upload: %w", err)
+		}
+		state.UploadID = *output.UploadId
+		state.CreatedAt = time.Now().UTC()
+		if err := state.save(); err != nil {
+			return err
+		}
+	}
+
+	// Collect the missing parts before the workers start writing state.Parts.
+	partCount := (state.Size + state.PartSize - 1) / state.PartSize
+	var missingParts []int64
+	for partNumber := int64(1); partNumber <= partCount; partNumber++ {
+		if _, done := state.Parts[partNumber]; !done {
+			missingParts = append(missingParts, partNumber)
+		}
+	}
+	missing := make(chan int64)
+	errChan := make(chan error, len(missingParts))
+
+	workers := s.uploader.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for partNumber := range missing {
+				if err := s.uploadPart(file, state, partNumber); err != nil {
+					errChan <- err
+				}
+			}
+		}()
+	}
+
+	for _, partNumber := range missingParts {
+		missing <- partNumber
+	}
+	close(missing)
+	wg.Wait()
+	close(errChan)
+
+	for err := range errChan {
+		if err != nil {
+			return err
+		}
+	}
+
+	completed := make([]*s3.CompletedPart, 0, len(state.Parts))
+	for partNumber, etag := range state.Parts {
+		completed = append(completed, &s3.CompletedPart{
+			PartNumber: aws.Int64(partNumber),
+			ETag:       aws.String(etag),
+		})
+	}
+	sort.Slice(completed, func(i, j int) bool {
+		return *completed[i].PartNumber < *completed[j].PartNumber
+	})
+
+	_, err = svc.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(state.Bucket),
+		Key:             aws.String(state.Key),
+		UploadId:        aws.String(state.UploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+
+	err = os.Remove(state.path)
+	if err != nil && !os.IsNotExist(err) {
+		s.logger.Warn("Failed to remove upload state", "file", state.path, "error", err)
+	}
+	return nil
+}
+
+func (s *s3Storage) uploadPart(file *os.File, state *multipartState, partNumber int64) error {
+	offset := (partNumber - 1) * state.PartSize
+	size := state.PartSize
+	if offset+size > state.Size {
+		size = state.Size - offset
+	}
+
+	var body io.ReadSeeker = io.NewSectionReader(file, offset, size)
+	if s.limits != nil {
+		body = &limitedReadSeeker{ReadSeeker: body, limiter: s.limits.upload}
+	}
+
+	output, err := s.uploader.S3.UploadPart(&s3.UploadPartInput{
+		Bucket:        aws.String(state.Bucket),
+		Key:           aws.String(state.Key),
+		UploadId:      aws.String(state.UploadID),
+		PartNumber:    aws.Int64(partNumber),
+		ContentLength: aws.Int64(size),
+		Body:          body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d of %s: %w", partNumber, state.LocalPath, err)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.Parts[partNumber] = *output.ETag
+	return state.saveLocked()
+}
+
+func listUploadedParts(svc s3iface.S3API, state *multipartState) (map[int64]string, error) {
+	parts := make(map[int64]string)
+	err := svc.ListPartsPages(&s3.ListPartsInput{
+		Bucket:   aws.String(state.Bucket),
+		Key:      aws.String(state.Key),
+		UploadId: aws.String(state.UploadID),
+	}, func(page *s3.ListPartsOutput, lastPage bool) bool {
+		for _, part := range page.Parts {
+			expected := state.PartSize
+			if *part.PartNumber*state.PartSize > state.Size {
+				expected = state.Size - (*part.PartNumber-1)*state.PartSize
+			}
+			if *part.Size == expected {
+				parts[*part.PartNumber] = *part.ETag
+			}
+		}
+		return true
+	})
+	return parts, err
+}
+
+// abortAbandonedUploads also removes the local resume state of the uploads it
+// aborts.
+func (s *s3Storage) abortAbandonedUploads() error {
+	svc := s.uploader.S3
+	maxAge := defaultAbandonedUploadMaxAge
+	if s.cfg.AbandonedUploadMaxAge != "" {
+		parsed, err := time.ParseDuration(s.cfg.AbandonedUploadMaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid abandonedUploadMaxAge: %w", err)
+		}
+		maxAge = parsed
+	}
+	cutoff := time.Now().Add(-maxAge)
+
+	var stale []*s3.MultipartUpload
+	err := svc.ListMultipartUploadsPages(&s3.ListMultipartUploadsInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Prefix: aws.String(s.cfg.BackupFolder),
+	}, func(page *s3.ListMultipartUploadsOutput, lastPage bool) bool {
+		for _, upload := range page.Uploads {
+			if upload.Initiated != nil && upload.Initiated.Before(cutoff) {
+				stale = append(stale, upload)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list multipart uploads: %w", err)
+	}
+
+	staleIDs := make(map[string]bool, len(stale))
+	for _, upload := range stale {
+		_, err := svc.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.cfg.Bucket),
+			Key:      upload.Key,
+			UploadId: upload.UploadId,
+		})
+		if err != nil {
+			var awsErr awserr.Error
+			if !errors.As(err, &awsErr) || awsErr.Code() != s3.ErrCodeNoSuchUpload {
+				return fmt.Errorf("failed to abort multipart upload of %s: %w", *upload.Key, err)
+			}
+		}
+		staleIDs[*upload.UploadId] = true
+		s.logger.Info("Aborted abandoned multipart upload", "key", *upload.Key, "initiated", upload.Initiated.Format(time.RFC3339))
+	}
+
+	s.removeMultipartStates(staleIDs)
+	return nil
+}
+
+func (s *s3Storage) removeMultipartStates(uploadIDs map[string]bool) {
+	if len(uploadIDs) == 0 {
+		return
+	}
+	paths, _ := filepath.Glob(filepath.Join(s.stateFolder, "uploads", "*.json"))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var state multipartState
+		if json.Unmarshal(data, &state) == nil && uploadIDs[state.UploadID] {
+			os.Remove(path)
+		}
+	}
+}
+
+func (s *s3Storage) loadMultipartState(file *os.File, info os.FileInfo, remotePath string) (*multipartState, error) {
+	sum := sha1.Sum([]byte(s.cfg.Bucket + "\x00" + remotePath))
+	path := filepath.Join(s.stateFolder, "uploads", hex.EncodeToString(sum[:])+".json")
+
+	fresh := &multipartState{
+		Bucket:    s.cfg.Bucket,
+		Key:       remotePath,
+		LocalPath: file.Name(),
+		Size:      info.Size(),
+		ModTime:   info.ModTime().UTC(),
+		PartSize:  s.uploader.PartSize,
+		Parts:     make(map[int64]string),
+		path:      path,
+	}
+
+	var state multipartState
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read upload state: %w", err)
+	}
+	if err == nil && json.Unmarshal(data, &state) == nil && state.Size == fresh.Size && state.PartSize == fresh.PartSize && state.ModTime.Equal(fresh.ModTime) {
+		return state.resumeWith(fresh), nil
+	}
+
+	fresh.SHA256, err = hashFile(file, fresh.Size)
+	if err != nil {
+		return nil, err
+	}
+	if state.UploadID == "" {
+		return fresh, nil
+	}
+	if state.Size == fresh.Size && state.PartSize == fresh.PartSize && state.SHA256 == fresh.SHA256 {
+		return state.resumeWith(fresh), nil
+	}
+
+	// The parts of other content are useless.
+	_, err = s.uploader.S3.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(state.Bucket),
+		Key:      aws.String(state.Key),
+		UploadId: aws.String(state.UploadID),
+	})
+	if err != nil {
+		s.logger.Warn("Failed to abort outdated multipart upload", "key", state.Key, "error", err)
+	}
+	return fresh, nil
+}
+
+func (m *multipartState) resumeWith(fresh *multipartState) *multipartState {
+	m.path = fresh.path
+	m.LocalPath = fresh.LocalPath
+	m.ModTime = fresh.ModTime
+	if m.SHA256 == "" {
+		m.SHA256 = fresh.SHA256
+	}
+	if m.Parts == nil {
+		m.Parts = make(map[int64]string)
+	}
+	return m
+}
+
+func hashFile(file *os.File, size int64) (string, error) {
+	hash := sha256.New()
+	_, err := io.Copy(hash, io.NewSectionReader(file, 0, size))
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", file.Name(), err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (m *multipartState) save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.saveLocked()
+}
+
+func (m *multipartState) saveLocked() error {
+	err := os.MkdirAll(filepath.Dir(m.path), 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create upload state folder: %w", err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode upload state: %w", err)
+	}
+	tmpPath := m.path + ".tmp"
+	err = os.WriteFile(tmpPath, data, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write upload state: %w", err)
+	}
+	return os.Rename(tmpPath, m.path)
+}
+
+type limitedReadSeeker struct {
+	io.ReadSeeker
+	limiter *bandwidthLimiter
+}
+
+func (r *limitedReadSeeker) Read(p []byte) (int, error) {
+	if len(p) > bandwidthChunkSize {
+		p = p[:bandwidthChunkSize]
+	}
+	n, err := r.ReadSeeker.Read(p)
+	r.limiter.wait(n)
+	return n, err
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"gos3/internal/config"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+type fakeMultipartS3 struct {
+	s3iface.S3API
+	uploads  int
+	failPart int64
+	uploaded []int64
+	parts    map[string][]*s3.Part
+	aborted  []string
+	complete []string
+}
+
+func (f *fakeMultipartS3) CreateMultipartUpload(input *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error) {
+	f.uploads++
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String(fmt.Sprintf("upload-%d", f.uploads))}, nil
+}
+
+func (f *fakeMultipartS3) UploadPart(input *s3.UploadPartInput) (*s3.UploadPartOutput, error) {
+	if *input.PartNumber == f.failPart {
+		return nil, fmt.Errorf("connection reset")
+	}
+	data, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.uploaded = append(f.uploaded, *input.PartNumber)
+	etag := fmt.Sprintf("%s-%d", *input.UploadId, *input.PartNumber)
+	f.parts[*input.UploadId] = append(f.parts[*input.UploadId], &s3.Part{PartNumber: input.PartNumber, Size: aws.Int64(int64(len(data))), ETag: aws.String(etag)})
+	return &s3.UploadPartOutput{ETag: aws.String(etag)}, nil
+}
+
+func (f *fakeMultipartS3) ListPartsPages(input *s3.ListPartsInput, fn func(*s3.ListPartsOutput, bool) bool) error {
+	fn(&s3.ListPartsOutput{Parts: f.parts[*input.UploadId]}, true)
+	return nil
+}
+
+func (f *fakeMultipartS3) CompleteMultipartUpload(input *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error) {
+	f.complete = append(f.complete, *input.UploadId)
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (f *fakeMultipartS3) AbortMultipartUpload(input *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error) {
+	f.aborted = append(f.aborted, *input.UploadId)
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func uploadResumable(t *testing.T, s *s3Storage, localPath string) error {
+	t.Helper()
+	file, err := os.Open(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.uploadMultipartResumable(file, info, "backups/2026-01-01/def-vol.tar.gz.cpt")
+}
+
+func TestUploadMultipartResumable(t *testing.T) {
+	fake := &fakeMultipartS3{failPart: 2, parts: make(map[string][]*s3.Part)}
+	s := &s3Storage{
+		cfg:         config.S3Config{Bucket: "bucket"},
+		stateFolder: t.TempDir(),
+		uploader:    &s3manager.Uploader{S3: fake, PartSize: 5, Concurrency: 1},
+		logger:      slog.Default(),
+	}
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	err := os.WriteFile(first, []byte("0123456789ab"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := uploadResumable(t, s, first); err == nil {
+		t.Fatal("upload with a failing part succeeded")
+	}
+
+	// A failed run keeps its encrypted files elsewhere; the retry resumes.
+	kept := filepath.Join(dir, "kept")
+	err = os.Rename(first, kept)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.failPart = 0
+	err = uploadResumable(t, s, kept)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{1, 3, 2}; !slices.Equal(fake.uploaded, want) {
+		t.Errorf("uploaded parts %v, want %v", fake.uploaded, want)
+	}
+	if !slices.Equal(fake.complete, []string{"upload-1"}) {
+		t.Errorf("completed uploads %v, want [upload-1]", fake.complete)
+	}
+	states, _ := filepath.Glob(filepath.Join(s.stateFolder, "uploads", "*"))
+	if len(states) != 0 {
+		t.Errorf("upload state left behind: %v", states)
+	}
+
+	// A new encryption of the same object starts over.
+	fake.failPart = 3
+	fake.uploaded = nil
+	if err := uploadResumable(t, s, kept); err == nil {
+		t.Fatal("upload with a failing part succeeded")
+	}
+	err = os.WriteFile(kept, []byte("ba9876543210"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chtimes(kept, time.Time{}, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.failPart = 0
+	err = uploadResumable(t, s, kept)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(fake.aborted, []string{"upload-2"}) {
+		t.Errorf("aborted uploads %v, want [upload-2]", fake.aborted)
+	}
+	if want := []int64{1, 2, 1, 2, 3}; !slices.Equal(fake.uploaded, want) {
+		t.Errorf("uploaded parts %v, want %v", fake.uploaded, want)
+	}
+}
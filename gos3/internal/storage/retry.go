@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"gos3/internal/metrics"
+)
+
+// uploadRetryDelay is multiplied by the square of the attempt number.
+var uploadRetryDelay = 5 * time.Second
+
+// retryUpload calls put up to retries extra times. Bodies rejected as too
+// large fail again, so they are not retried.
+func retryUpload(logger *slog.Logger, storage, localPath string, retries int, put func() error) error {
+	retries = max(retries, 0)
+
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			delay := time.Duration(attempt*attempt) * uploadRetryDelay
+			logger.Warn("Retrying upload", "file", localPath, "delay", delay, "attempt", attempt+1, "attempts", retries+1, "error", err)
+			metrics.RecordUploadRetry(storage)
+			time.Sleep(delay)
+		}
+		err = put()
+		if err == nil || errors.Is(err, errTooLarge) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gos3/internal/config"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+type s3Storage struct {
+	cfg         config.S3Config
+	stateFolder string
+	svc         *s3.S3
+	uploader    *s3manager.Uploader
+	downloader  *s3manager.Downloader
+	limits      *targetLimiters
+	logger      *slog.Logger
+	cleanup     sync.Once
+}
+
+// NewS3 creates the S3 backend. stateFolder keeps the resume state of
+// multipart uploads.
+func NewS3(cfg config.S3Config, stateFolder string, logger *slog.Logger) (Storage, error) {
+	sess, err := createS3Session(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 session: %w", err)
+	}
+
+	return &s3Storage{
+		cfg:         cfg,
+		stateFolder: stateFolder,
+		svc:         s3.New(sess),
+		uploader: s3manager.NewUploader(sess, func(u *s3manager.Uploader) {
+			if partSize := config.ParseSize(cfg.UploadPartSize); partSize >= s3manager.MinUploadPartSize {
+				u.PartSize = partSize
+			}
+			if cfg.UploadConcurrency > 0 {
+				u.Concurrency = cfg.UploadConcurrency
+			}
+		}),
+		downloader: s3manager.NewDownloader(sess),
+		limits:     limitersFor(cfg.Endpoint+"/"+cfg.Bucket, cfg.Bandwidth),
+		logger:     logger,
+	}, nil
+}
+
+func (s *s3Storage) Name() string {
+	return "s3://" + s.cfg.Bucket
+}
+
+// Put retries up to UploadRetries times. The first upload of the process also
+// aborts abandoned multipart uploads.
+func (s *s3Storage) Put(localPath, key string) error {
+	s.cleanup.Do(func() {
+		if err := s.abortAbandonedUploads(); err != nil {
+			s.logger.Warn("Failed to clean up abandoned multipart uploads", "bucket", s.cfg.Bucket, "error", err)
+		}
+	})
+
+	return retryUpload(s.logger, s.Name(), localPath, s.cfg.UploadRetries, func() error {
+		return s.put(localPath, key)
+	})
+}
+
+func (s *s3Storage) put(localPath, key string) error {
+	s.logger.Debug("Starting upload", "file", localPath, "key", key)
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", localPath, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	if info.Size() > s.uploader.PartSize {
+		err = s.uploadMultipartResumable(file, info, key)
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	_, err = s.uploader.Upload(&s3manager.UploadInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(key),
+		Body:   s.limits.uploadReader(file),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload file to S3: %w", err)
+	}
+
+	return nil
+}
+
+func (s *s3Storage) Get(key, localPath string) error {
+	err := os.MkdirAll(filepath.Dir(localPath), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	var target io.WriterAt = file
+	if s.limits != nil {
+		target = &limitedWriterAt{writer: file, limiter: s.limits.download}
+	}
+
+	_, err = s.downloader.Download(target, &s3.GetObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(key),
+	})
+	if isNotFound(err) {
+		return fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	return nil
+}
+
+func (s *s3Storage) List(options ListOptions) (ListPage, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket:    aws.String(s.cfg.Bucket),
+		Prefix:    aws.String(options.Prefix),
+		Delimiter: aws.String(options.Delimiter),
+	}
+	if options.MaxKeys > 0 {
+		input.MaxKeys = aws.Int64(int64(options.MaxKeys))
+	}
+	if options.ContinuationToken != "" {
+		input.ContinuationToken = aws.String(options.ContinuationToken)
+	}
+
+	result, err := s.svc.ListObjectsV2(input)
+	if err != nil {
+		return ListPage{}, fmt.Errorf("unable to list items in bucket %q, %v", s.cfg.Bucket, err)
+	}
+
+	var page ListPage
+	for _, prefix := range result.CommonPrefixes {
+		page.Prefixes = append(page.Prefixes, *prefix.Prefix)
+	}
+	for _, item := range result.Contents {
+		page.Objects = append(page.Objects, Object{
+			Key:          *item.Key,
+			Size:         aws.Int64Value(item.Size),
+			LastModified: aws.TimeValue(item.LastModified),
+			ETag:         strings.Trim(aws.StringValue(item.ETag), "\""),
+		})
+	}
+	page.IsTruncated = aws.BoolValue(result.IsTruncated)
+	page.ContinuationToken = aws.StringValue(result.NextContinuationToken)
+	return page, nil
+}
+
+func (s *s3Storage) Delete(key string) error {
+	_, err := s.svc.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *s3Storage) Stat(key string) (Object, error) {
+	result, err := s.svc.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(key),
+	})
+	if isNotFound(err) {
+		return Object{}, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to stat %s: %w", key, err)
+	}
+	return Object{
+		Key:          key,
+		Size:         aws.Int64Value(result.ContentLength),
+		LastModified: aws.TimeValue(result.LastModified),
+		ETag:         strings.Trim(aws.StringValue(result.ETag), "\""),
+	}, nil
+}
+
+func (s *s3Storage) Close() error {
+	return nil
+}
+
+func isNotFound(err error) bool {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return false
+	}
+	switch awsErr.Code() {
+	case s3.ErrCodeNoSuchKey, "NotFound":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,291 @@
+package storage
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gos3/internal/config"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+type sftpStorage struct {
+	name   string
+	root   string
+	conn   *ssh.Client
+	client *sftp.Client
+	limits *targetLimiters
+}
+
+// NewSFTP connects to the configured server. The server host key must be
+// listed in the known hosts file.
+func NewSFTP(cfg config.SFTPConfig, bandwidth config.BandwidthConfig) (Storage, error) {
+	if cfg.Host == "" || cfg.User == "" {
+		return nil, fmt.Errorf("sftp storage requires host and user")
+	}
+
+	auth, err := sftpAuthMethods(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	knownHostsFile := cfg.KnownHostsFile
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known hosts file %s: %w", knownHostsFile, err)
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = 22
+	}
+	address := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+
+	conn, err := ssh.Dial("tcp", address, &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start sftp session on %s: %w", address, err)
+	}
+
+	root := path.Clean(cfg.Root)
+
+	name := fmt.Sprintf("sftp://%s@%s/%s", cfg.User, address, strings.TrimPrefix(root, "/"))
+	return &sftpStorage{
+		name:   name,
+		root:   root,
+		conn:   conn,
+		client: client,
+		limits: limitersFor(name, bandwidth),
+	}, nil
+}
+
+func sftpAuthMethods(cfg config.SFTPConfig) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+
+	if cfg.PrivateKeyFile != "" {
+		key, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sftp private key: %w", err)
+		}
+
+		var signer ssh.Signer
+		if cfg.PrivateKeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(cfg.PrivateKeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sftp private key: %w", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if cfg.Password != "" {
+		methods = append(methods, ssh.Password(cfg.Password))
+	}
+
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("sftp storage requires a password or a private key file")
+	}
+	return methods, nil
+}
+
+func (s *sftpStorage) Name() string {
+	return s.name
+}
+
+func (s *sftpStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return path.Join(s.root, clean), nil
+}
+
+func (s *sftpStorage) key(remotePath string) string {
+	if s.root == "." {
+		return remotePath
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(remotePath, s.root), "/")
+}
+
+// Put writes to a ".partial" file and renames it when complete, so an
+// interrupted upload never looks like a finished backup.
+func (s *sftpStorage) Put(localPath, key string) error {
+	if strings.HasSuffix(key, "/") {
+		dir, err := s.path(strings.TrimSuffix(key, "/"))
+		if err != nil {
+			return err
+		}
+		return s.client.MkdirAll(dir)
+	}
+
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = s.client.MkdirAll(path.Dir(target))
+	if err != nil {
+		return fmt.Errorf("failed to create folder for %s: %w", key, err)
+	}
+
+	in, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", localPath, err)
+	}
+	defer in.Close()
+
+	tmpPath := target + ".partial"
+	out, err := s.client.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", tmpPath, err)
+	}
+
+	_, err = out.ReadFrom(s.limits.uploadReader(in))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		s.client.Remove(tmpPath)
+		return fmt.Errorf("failed to upload %s: %w", localPath, err)
+	}
+
+	s.client.Remove(target)
+	err = s.client.Rename(tmpPath, target)
+	if err != nil {
+		return fmt.Errorf("failed to rename %s: %w", tmpPath, err)
+	}
+	return nil
+}
+
+func (s *sftpStorage) Get(key, localPath string) error {
+	source, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	in, err := s.client.Open(source)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", key, err)
+	}
+	defer in.Close()
+
+	err = os.MkdirAll(filepath.Dir(localPath), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	out, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	_, err = in.WriteTo(s.limits.downloadWriter(out))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *sftpStorage) List(options ListOptions) (ListPage, error) {
+	dir := s.root
+	if folder := prefixFolder(options.Prefix); folder != "" {
+		var err error
+		dir, err = s.path(folder)
+		if err != nil {
+			return ListPage{}, err
+		}
+	}
+
+	var objects []Object
+	walker := s.client.Walk(dir)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			if os.IsNotExist(err) && walker.Path() == dir {
+				break
+			}
+			return ListPage{}, fmt.Errorf("failed to list %s: %w", s.name, err)
+		}
+
+		info := walker.Stat()
+		if info.IsDir() || strings.HasSuffix(walker.Path(), ".partial") {
+			continue
+		}
+
+		rel := s.key(walker.Path())
+		if !strings.HasPrefix(rel, options.Prefix) {
+			continue
+		}
+		objects = append(objects, Object{Key: rel, Size: info.Size(), LastModified: info.ModTime()})
+	}
+
+	return paginate(objects, options), nil
+}
+
+// Delete removes the file and any folders left empty by it.
+func (s *sftpStorage) Delete(key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = s.client.Remove(target)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+
+	for dir := path.Dir(target); dir != s.root && dir != "/" && dir != "."; dir = path.Dir(dir) {
+		if s.client.RemoveDirectory(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *sftpStorage) Stat(key string) (Object, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+	info, err := s.client.Stat(target)
+	if os.IsNotExist(err) {
+		return Object{}, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to stat %s: %w", key, err)
+	}
+	return Object{Key: key, Size: info.Size(), LastModified: info.ModTime()}, nil
+}
+
+func (s *sftpStorage) Close() error {
+	s.client.Close()
+	return s.conn.Close()
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gos3/internal/config"
+	"gos3/internal/storage/storagetest"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file")
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func keys(objects []Object) []string {
+	var keys []string
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+	}
+	return keys
+}
+
+func TestSFTPPutGetStat(t *testing.T) {
+	cfg := storagetest.StartSFTPServer(t)
+	st, err := NewSFTP(cfg, config.BandwidthConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	err = st.Put(writeFile(t, "first"), "backups/2026-01-01/def-vol1.tar.gz.cpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Overwriting replaces the file through the ".partial" rename.
+	err = st.Put(writeFile(t, "second"), "backups/2026-01-01/def-vol1.tar.gz.cpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	object, err := st.Stat("backups/2026-01-01/def-vol1.tar.gz.cpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if object.Size != int64(len("second")) {
+		t.Errorf("Stat size = %d, want %d", object.Size, len("second"))
+	}
+	_, err = os.Stat(filepath.Join(cfg.Root, "backups/2026-01-01/def-vol1.tar.gz.cpt.partial"))
+	if !os.IsNotExist(err) {
+		t.Errorf("partial file was left behind: %v", err)
+	}
+
+	localPath := filepath.Join(t.TempDir(), "nested", "download")
+	err = st.Get("backups/2026-01-01/def-vol1.tar.gz.cpt", localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "second" {
+		t.Errorf("Get content = %q, want %q", data, "second")
+	}
+
+	err = st.Get("backups/2026-01-01/missing", localPath)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of missing key = %v, want ErrNotFound", err)
+	}
+	_, err = st.Stat("backups/2026-01-01/missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat of missing key = %v, want ErrNotFound", err)
+	}
+
+	err = st.Put(writeFile(t, "escape"), "../escape")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(filepath.Join(cfg.Root, "escape"))
+	if err != nil {
+		t.Errorf("key ../escape was not stored below the root: %v", err)
+	}
+}
+
+func TestSFTPListAndDelete(t *testing.T) {
+	cfg := storagetest.StartSFTPServer(t)
+	st, err := NewSFTP(cfg, config.BandwidthConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	for _, key := range []string{
+		"backups/2026-01-01/a.cpt",
+		"backups/2026-01-01/b.cpt",
+		"backups/2026-01-01/c.cpt-split_parts/c.cpt.part-0000",
+		"backups/2026-01-02/a.cpt",
+		"other/x",
+	} {
+		err = st.Put(writeFile(t, key), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = os.WriteFile(filepath.Join(cfg.Root, "backups/2026-01-02/b.cpt.partial"), nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := st.List(ListOptions{Prefix: "backups/", Delimiter: "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"backups/2026-01-01/", "backups/2026-01-02/"}; !slices.Equal(page.Prefixes, want) {
+		t.Errorf("Prefixes = %v, want %v", page.Prefixes, want)
+	}
+	if len(page.Objects) != 0 {
+		t.Errorf("Objects = %v, want none", keys(page.Objects))
+	}
+
+	page, err = st.List(ListOptions{Prefix: "backups/2026-01-01/", Delimiter: "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"backups/2026-01-01/a.cpt", "backups/2026-01-01/b.cpt"}; !slices.Equal(keys(page.Objects), want) {
+		t.Errorf("Objects = %v, want %v", keys(page.Objects), want)
+	}
+	if want := []string{"backups/2026-01-01/c.cpt-split_parts/"}; !slices.Equal(page.Prefixes, want) {
+		t.Errorf("Prefixes = %v, want %v", page.Prefixes, want)
+	}
+
+	all, err := ListAll(st, ListOptions{Prefix: "backups/", MaxKeys: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"backups/2026-01-01/a.cpt",
+		"backups/2026-01-01/b.cpt",
+		"backups/2026-01-01/c.cpt-split_parts/c.cpt.part-0000",
+		"backups/2026-01-02/a.cpt",
+	}
+	if !slices.Equal(keys(all.Objects), want) {
+		t.Errorf("paged objects = %v, want %v", keys(all.Objects), want)
+	}
+
+	page, err = st.List(ListOptions{Prefix: "missing/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Objects) != 0 {
+		t.Errorf("Objects below a missing folder = %v", keys(page.Objects))
+	}
+
+	err = st.Delete("backups/2026-01-01/c.cpt-split_parts/c.cpt.part-0000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(filepath.Join(cfg.Root, "backups/2026-01-01/c.cpt-split_parts"))
+	if !os.IsNotExist(err) {
+		t.Errorf("empty parts folder was not removed: %v", err)
+	}
+	_, err = os.Stat(filepath.Join(cfg.Root, "backups/2026-01-01"))
+	if err != nil {
+		t.Errorf("non-empty date folder was removed: %v", err)
+	}
+
+	err = st.Delete("backups/2026-01-01/missing")
+	if err != nil {
+		t.Errorf("Delete of missing key = %v, want nil", err)
+	}
+}
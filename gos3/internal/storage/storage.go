@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gos3/internal/config"
+)
+
+// ErrNotFound is returned by Get and Stat when the key does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Object describes a stored file. Keys always use "/" as separator.
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	ETag         string
+}
+
+// ListOptions follow S3 ListObjectsV2 semantics: with Delimiter "/" keys below
+// the next "/" after Prefix are grouped into Prefixes. MaxKeys 0 lets the
+// backend pick its page size; ContinuationToken resumes a previous page.
+type ListOptions struct {
+	Prefix            string
+	Delimiter         string
+	MaxKeys           int
+	ContinuationToken string
+}
+
+type ListPage struct {
+	Objects           []Object
+	Prefixes          []string
+	IsTruncated       bool
+	ContinuationToken string
+}
+
+// Storage is a backup target. Put and Get transfer whole local files so each
+// backend can stream, split or resume them as it sees fit.
+type Storage interface {
+	// Name identifies the target in logs, e.g. "s3://bucket".
+	Name() string
+	Put(localPath, key string) error
+	Get(key, localPath string) error
+	List(options ListOptions) (ListPage, error)
+	Delete(key string) error
+	Stat(key string) (Object, error)
+	// Close releases connections held by the backend.
+	Close() error
+}
+
+// Open returns the backend selected by the storage block of the
+// configuration. S3 is used when no type is set.
+func Open(cfg config.Config) (Storage, error) {
+	switch cfg.Storage.Type {
+	case "", "s3":
+		return NewS3(cfg.S3, cfg.App.StateFolder, cfg.Logger())
+	case "local":
+		return NewLocal(cfg.Storage.Path, cfg.S3.Bandwidth)
+	case "sftp":
+		return NewSFTP(cfg.Storage.SFTP, cfg.S3.Bandwidth)
+	case "webdav":
+		return NewWebDAV(cfg.Storage.WebDAV, cfg.S3.Bandwidth, cfg.S3.UploadRetries, cfg.Logger())
+	case "azure":
+		return NewAzure(cfg.Storage.Azure, cfg.S3.Bandwidth)
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", cfg.Storage.Type)
+	}
+}
@@ -0,0 +1,122 @@
+package storagetest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"gos3/internal/config"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	sftpUser     = "backup"
+	sftpPassword = "secret"
+)
+
+// StartSFTPServer serves the local filesystem over SFTP on a random port
+// until the test ends. The returned configuration logs in with a password,
+// trusts the server host key and stores below a new temporary folder.
+func StartSFTPServer(t testing.TB) config.SFTPConfig {
+	t.Helper()
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if meta.User() == sftpUser && string(password) == sftpPassword {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSH(conn, serverConfig)
+		}
+	}()
+
+	address := listener.Addr().String()
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(address)}, signer.PublicKey())
+	err = os.WriteFile(knownHostsFile, []byte(line+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host, port, _ := net.SplitHostPort(address)
+	portNumber, _ := strconv.Atoi(port)
+	return config.SFTPConfig{
+		Host:           host,
+		Port:           portNumber,
+		User:           sftpUser,
+		Password:       sftpPassword,
+		KnownHostsFile: knownHostsFile,
+		Root:           t.TempDir(),
+	}
+}
+
+func serveSSH(conn net.Conn, serverConfig *ssh.ServerConfig) {
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer serverConn.Close()
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			for request := range channelRequests {
+				// The payload is the subsystem name as an SSH string.
+				ok := request.Type == "subsystem" && len(request.Payload) > 4 && string(request.Payload[4:]) == "sftp"
+				request.Reply(ok, nil)
+				if !ok {
+					continue
+				}
+				go func() {
+					defer channel.Close()
+					server, err := sftp.NewServer(channel)
+					if err != nil {
+						return
+					}
+					server.Serve()
+				}()
+			}
+		}()
+	}
+}
@@ -0,0 +1,419 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gos3/internal/config"
+)
+
+const defaultWebDAVChunkSize = 10 * 1024 * 1024
+
+var errTooLarge = errors.New("request entity too large")
+
+type webdavStorage struct {
+	cfg        config.WebDAVConfig
+	base       *url.URL
+	uploadsURL string
+	chunkSize  int64
+	client     *http.Client
+	limits     *targetLimiters
+	retries    int
+	logger     *slog.Logger
+}
+
+// NewWebDAV creates the webdav backend. Single-request uploads are retried
+// uploadRetries times.
+func NewWebDAV(cfg config.WebDAVConfig, bandwidth config.BandwidthConfig, uploadRetries int, logger *slog.Logger) (Storage, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("webdav storage requires a url")
+	}
+	base, err := url.Parse(strings.TrimSuffix(cfg.URL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid webdav url %s: %w", cfg.URL, err)
+	}
+
+	chunkSize := config.ParseSize(cfg.ChunkSize)
+	if chunkSize <= 0 {
+		chunkSize = defaultWebDAVChunkSize
+	}
+
+	return &webdavStorage{
+		cfg:        cfg,
+		base:       base,
+		uploadsURL: strings.TrimSuffix(cfg.UploadsURL, "/"),
+		chunkSize:  chunkSize,
+		client:     &http.Client{},
+		limits:     limitersFor(base.String(), bandwidth),
+		retries:    uploadRetries,
+		logger:     logger,
+	}, nil
+}
+
+func (w *webdavStorage) Name() string {
+	return w.base.String()
+}
+
+func (w *webdavStorage) url(key string) string {
+	u := *w.base
+	u.Path = path.Join(w.base.Path, path.Clean("/"+key))
+	return u.String()
+}
+
+// do sends body through the upload limiter.
+func (w *webdavStorage) do(method, target string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = w.limits.uploadReader(body)
+	}
+	req, err := http.NewRequest(method, target, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if w.cfg.User != "" {
+		req.SetBasicAuth(w.cfg.User, w.cfg.Password)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	switch body := body.(type) {
+	case *os.File:
+		if info, err := body.Stat(); err == nil {
+			req.ContentLength = info.Size()
+		}
+	case *io.SectionReader:
+		req.ContentLength = body.Size()
+	case *strings.Reader:
+		req.ContentLength = int64(body.Len())
+	}
+	return w.client.Do(req)
+}
+
+func (w *webdavStorage) expect(method, target string, body io.Reader, headers map[string]string, accepted ...int) error {
+	resp, err := w.do(method, target, body, headers)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, target, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	for _, code := range accepted {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%s %s: %w", method, target, ErrNotFound)
+	case http.StatusRequestEntityTooLarge:
+		return fmt.Errorf("%s %s: %w", method, target, errTooLarge)
+	}
+	return fmt.Errorf("%s %s failed: %s", method, target, resp.Status)
+}
+
+// Existing collections answer MKCOL with 405.
+func (w *webdavStorage) mkdirAll(folder string) error {
+	current := ""
+	for _, part := range strings.Split(strings.Trim(folder, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		current = path.Join(current, part)
+		err := w.expect("MKCOL", w.url(current), nil, nil, http.StatusCreated, http.StatusMethodNotAllowed)
+		if err != nil {
+			return fmt.Errorf("failed to create folder %s: %w", current, err)
+		}
+	}
+	return nil
+}
+
+func (w *webdavStorage) Put(localPath, key string) error {
+	if strings.HasSuffix(key, "/") {
+		return w.mkdirAll(key)
+	}
+
+	err := w.mkdirAll(path.Dir(key))
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", localPath, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	if w.uploadsURL != "" && info.Size() > w.chunkSize {
+		return w.putChunked(file, info.Size(), key)
+	}
+
+	// A single PUT cannot resume, so every attempt sends the whole file.
+	err = retryUpload(w.logger, w.Name(), localPath, w.retries, func() error {
+		body := io.NewSectionReader(file, 0, info.Size())
+		return w.expect(http.MethodPut, w.url(key), body, nil, http.StatusCreated, http.StatusNoContent, http.StatusOK)
+	})
+	if errors.Is(err, errTooLarge) {
+		return fmt.Errorf("server rejected %s (%d bytes) as too large; set s3.maxFileSize to split it, or storage.webdav.uploadsUrl for Nextcloud chunked upload", localPath, info.Size())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", localPath, err)
+	}
+	return nil
+}
+
+// putChunked uses Nextcloud chunked upload v2.
+func (w *webdavStorage) putChunked(file *os.File, size int64, key string) error {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("failed to generate upload id: %w", err)
+	}
+	uploadFolder := w.uploadsURL + "/gos3-" + hex.EncodeToString(id)
+	headers := map[string]string{
+		"Destination":     w.url(key),
+		"OC-Total-Length": strconv.FormatInt(size, 10),
+	}
+
+	err := w.expect("MKCOL", uploadFolder, nil, headers, http.StatusCreated)
+	if err != nil {
+		return fmt.Errorf("failed to start chunked upload: %w", err)
+	}
+
+	for index, offset := 1, int64(0); offset < size; index, offset = index+1, offset+w.chunkSize {
+		length := min(w.chunkSize, size-offset)
+		chunk := io.NewSectionReader(file, offset, length)
+		chunkHeaders := map[string]string{
+			"Destination":     w.url(key),
+			"OC-Total-Length": strconv.FormatInt(size, 10),
+		}
+		err = w.expect(http.MethodPut, fmt.Sprintf("%s/%05d", uploadFolder, index), chunk, chunkHeaders, http.StatusCreated, http.StatusNoContent)
+		if err != nil {
+			w.expect(http.MethodDelete, uploadFolder, nil, nil, http.StatusNoContent)
+			return fmt.Errorf("failed to upload chunk %d of %s: %w", index, key, err)
+		}
+	}
+
+	err = w.expect("MOVE", uploadFolder+"/.file", nil, headers, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		w.expect(http.MethodDelete, uploadFolder, nil, nil, http.StatusNoContent)
+		return fmt.Errorf("failed to assemble chunked upload of %s: %w", key, err)
+	}
+	return nil
+}
+
+func (w *webdavStorage) Get(key, localPath string) error {
+	resp, err := w.do(http.MethodGet, w.url(key), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", key, resp.Status)
+	}
+
+	err = os.MkdirAll(filepath.Dir(localPath), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	out, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	_, err = io.Copy(out, w.limits.downloadReader(resp.Body))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return nil
+}
+
+type davMultistatus struct {
+	Responses []davResponse `xml:"response"`
+}
+
+type davResponse struct {
+	Href     string `xml:"href"`
+	Propstat []struct {
+		Prop struct {
+			ContentLength string    `xml:"getcontentlength"`
+			LastModified  string    `xml:"getlastmodified"`
+			ETag          string    `xml:"getetag"`
+			Collection    *struct{} `xml:"resourcetype>collection"`
+		} `xml:"prop"`
+		Status string `xml:"status"`
+	} `xml:"propstat"`
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/><d:getlastmodified/><d:getetag/></d:prop></d:propfind>`
+
+type davEntry struct {
+	object       Object
+	isCollection bool
+}
+
+func (w *webdavStorage) propfind(key, depth string) ([]davEntry, error) {
+	resp, err := w.do("PROPFIND", w.url(key), strings.NewReader(propfindBody), map[string]string{
+		"Depth":        depth,
+		"Content-Type": "application/xml",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("failed to list %s: %s", key, resp.Status)
+	}
+
+	var result davMultistatus
+	err = xml.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse listing of %s: %w", key, err)
+	}
+
+	self := strings.Trim(path.Clean("/"+key), "/")
+	var entries []davEntry
+	for _, response := range result.Responses {
+		href, err := url.PathUnescape(response.Href)
+		if err != nil {
+			continue
+		}
+		if u, err := url.Parse(href); err == nil && u.IsAbs() {
+			href = u.Path
+		}
+		rel := strings.Trim(strings.TrimPrefix(href, w.base.Path), "/")
+		if depth != "0" && rel == self {
+			continue
+		}
+
+		entry := davEntry{object: Object{Key: rel}}
+		for _, propstat := range response.Propstat {
+			if !strings.Contains(propstat.Status, " 200 ") {
+				continue
+			}
+			prop := propstat.Prop
+			entry.isCollection = entry.isCollection || prop.Collection != nil
+			if prop.ContentLength != "" {
+				entry.object.Size, _ = strconv.ParseInt(prop.ContentLength, 10, 64)
+			}
+			if prop.LastModified != "" {
+				entry.object.LastModified, _ = time.Parse(time.RFC1123, prop.LastModified)
+			}
+			if prop.ETag != "" {
+				entry.object.ETag = strings.Trim(prop.ETag, "\"")
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// With delimiter "/" List reports the collections below Prefix without
+// descending into them, so listing date folders stays a single request.
+func (w *webdavStorage) List(options ListOptions) (ListPage, error) {
+	var objects []Object
+	var walk func(folder string) error
+	walk = func(folder string) error {
+		entries, err := w.propfind(folder, "1")
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			key := entry.object.Key
+			if !entry.isCollection {
+				objects = append(objects, entry.object)
+				continue
+			}
+
+			folderKey := key + "/"
+			switch {
+			case strings.HasPrefix(folderKey, options.Prefix):
+				if options.Delimiter == "/" {
+					objects = append(objects, Object{Key: folderKey})
+					continue
+				}
+				if err := walk(key); err != nil {
+					return err
+				}
+			case strings.HasPrefix(options.Prefix, folderKey):
+				if err := walk(key); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	err := walk(prefixFolder(options.Prefix))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return ListPage{}, err
+	}
+
+	return paginate(objects, options), nil
+}
+
+// Delete removes the file and any collections left empty by it.
+func (w *webdavStorage) Delete(key string) error {
+	err := w.expect(http.MethodDelete, w.url(key), nil, nil, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+
+	for folder := path.Dir(strings.TrimSuffix(key, "/")); folder != "." && folder != "/"; folder = path.Dir(folder) {
+		entries, err := w.propfind(folder, "1")
+		if err != nil || len(entries) > 0 {
+			break
+		}
+		if w.expect(http.MethodDelete, w.url(folder), nil, nil, http.StatusNoContent, http.StatusOK) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (w *webdavStorage) Stat(key string) (Object, error) {
+	entries, err := w.propfind(key, "0")
+	if err != nil {
+		return Object{}, err
+	}
+	if len(entries) == 0 {
+		return Object{}, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	object := entries[0].object
+	object.Key = key
+	return object, nil
+}
+
+func (w *webdavStorage) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,265 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gos3/internal/config"
+
+	"golang.org/x/net/webdav"
+)
+
+// startWebDAV serves a temporary folder below /dav with basic auth. Requests
+// to /uploads follow Nextcloud chunked upload v2 and assemble into the same
+// folder.
+func startWebDAV(t *testing.T, maxBody int64) (config.WebDAVConfig, string) {
+	t.Helper()
+	root := t.TempDir()
+	dav := &webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: webdav.Dir(root),
+		LockSystem: webdav.NewMemLS(),
+	}
+
+	var mu sync.Mutex
+	uploads := make(map[string]map[string][]byte)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "backup" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if maxBody > 0 && r.ContentLength > maxBody {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, "/uploads/") {
+			dav.ServeHTTP(w, r)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		folder, chunk, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/uploads/"), "/")
+		switch {
+		case r.Method == "MKCOL" && chunk == "":
+			uploads[folder] = make(map[string][]byte)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && uploads[folder] != nil:
+			data, _ := io.ReadAll(r.Body)
+			uploads[folder][chunk] = data
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "MOVE" && chunk == ".file" && uploads[folder] != nil:
+			var names []string
+			for name := range uploads[folder] {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			var assembled []byte
+			for _, name := range names {
+				assembled = append(assembled, uploads[folder][name]...)
+			}
+			delete(uploads, folder)
+			destination := strings.TrimPrefix(r.Header.Get("Destination"), "http://"+r.Host+"/dav")
+			err := os.WriteFile(filepath.Join(root, filepath.FromSlash(destination)), assembled, 0644)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodDelete:
+			delete(uploads, folder)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return config.WebDAVConfig{
+		URL:      server.URL + "/dav/",
+		User:     "backup",
+		Password: "secret",
+	}, root
+}
+
+func TestWebDAVPutGetListDelete(t *testing.T) {
+	cfg, root := startWebDAV(t, 0)
+	st, err := NewWebDAV(cfg, config.BandwidthConfig{}, 0, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	for _, key := range []string{
+		"backups/2026-01-01/a.cpt",
+		"backups/2026-01-01/b.cpt",
+		"backups/2026-01-01/c.cpt-split_parts/c.cpt.part-0000",
+		"backups/2026-01-02/a.cpt",
+	} {
+		err = st.Put(writeFile(t, key), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	object, err := st.Stat("backups/2026-01-01/a.cpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if object.Size != int64(len("backups/2026-01-01/a.cpt")) {
+		t.Errorf("Stat size = %d", object.Size)
+	}
+	_, err = st.Stat("backups/2026-01-01/missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat of missing key = %v, want ErrNotFound", err)
+	}
+
+	localPath := filepath.Join(t.TempDir(), "download")
+	err = st.Get("backups/2026-01-02/a.cpt", localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(localPath)
+	if string(data) != "backups/2026-01-02/a.cpt" {
+		t.Errorf("Get content = %q", data)
+	}
+	err = st.Get("backups/2026-01-02/missing", localPath)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of missing key = %v, want ErrNotFound", err)
+	}
+
+	page, err := st.List(ListOptions{Prefix: "backups/", Delimiter: "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"backups/2026-01-01/", "backups/2026-01-02/"}; !slices.Equal(page.Prefixes, want) {
+		t.Errorf("Prefixes = %v, want %v", page.Prefixes, want)
+	}
+
+	all, err := ListAll(st, ListOptions{Prefix: "backups/2026-01-01/", MaxKeys: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"backups/2026-01-01/a.cpt",
+		"backups/2026-01-01/b.cpt",
+		"backups/2026-01-01/c.cpt-split_parts/c.cpt.part-0000",
+	}
+	if !slices.Equal(keys(all.Objects), want) {
+		t.Errorf("paged objects = %v, want %v", keys(all.Objects), want)
+	}
+
+	page, err = st.List(ListOptions{Prefix: "missing/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Objects) != 0 {
+		t.Errorf("Objects below a missing folder = %v", keys(page.Objects))
+	}
+
+	err = st.Delete("backups/2026-01-02/a.cpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(filepath.Join(root, "backups", "2026-01-02"))
+	if !os.IsNotExist(err) {
+		t.Errorf("empty date folder was not removed: %v", err)
+	}
+	_, err = os.Stat(filepath.Join(root, "backups"))
+	if err != nil {
+		t.Errorf("non-empty backup folder was removed: %v", err)
+	}
+}
+
+func TestWebDAVLargeUploads(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	localPath := filepath.Join(t.TempDir(), "large")
+	err := os.WriteFile(localPath, content, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _ := startWebDAV(t, 256)
+	st, err := NewWebDAV(cfg, config.BandwidthConfig{}, 0, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.Put(localPath, "backups/large.cpt")
+	if err == nil || !strings.Contains(err.Error(), "too large") || !strings.Contains(err.Error(), "maxFileSize") {
+		t.Errorf("Put above the server limit = %v, want a too large error", err)
+	}
+	st.Close()
+
+	cfg, root := startWebDAV(t, 256)
+	cfg.UploadsURL = strings.Replace(cfg.URL, "/dav/", "/uploads", 1)
+	cfg.ChunkSize = "200"
+	st, err = NewWebDAV(cfg, config.BandwidthConfig{}, 0, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	err = st.Put(localPath, "backups/large.cpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "backups", "large.cpt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Errorf("chunked upload assembled %d bytes, want %d", len(data), len(content))
+	}
+}
+
+func TestWebDAVRetriesSinglePut(t *testing.T) {
+	uploadRetryDelay = 0
+	t.Cleanup(func() { uploadRetryDelay = 5 * time.Second })
+
+	var attempts int
+	var stored []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "MKCOL":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case http.MethodPut:
+			attempts++
+			data, _ := io.ReadAll(r.Body)
+			if attempts == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			stored = data
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	st, err := NewWebDAV(config.WebDAVConfig{URL: server.URL}, config.BandwidthConfig{}, 1, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	err = st.Put(writeFile(t, "backup"), "backups/a.cpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 || string(stored) != "backup" {
+		t.Errorf("attempts = %d, stored %q, want 2 attempts storing %q", attempts, stored, "backup")
+	}
+}
@@ -39,7 +39,7 @@ for SPLIT_FOLDER in $SPLIT_FOLDERS; do
         continue
     fi
 
-    OUTPUT_FILE="$ORIGINAL_FILE"
+    OUTPUT_FILE="$(dirname "$SPLIT_FOLDER")/$ORIGINAL_FILE"
     
     echo "Joining parts to create: $OUTPUT_FILE"
 
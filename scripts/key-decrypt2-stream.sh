@@ -0,0 +1,61 @@
+#!/bin/bash
+
+# Decrypts stdin to stdout with the encrypted private key, so callers can
+# inspect a backup without writing its plaintext to disk. Diagnostics go to
+# stderr.
+
+set -e  # Exit immediately if a command exits with a non-zero status.
+
+if [ "$#" -ne 3 ]; then
+    echo "Usage: $0 <encrypted_password_file> <encrypted_private_key_file> <private_key_password> < input > output" >&2
+    exit 1
+fi
+
+PASS_FILE=$1
+ENCRYPTED_PRIVATE_KEY_FILE=$2
+PRIVATE_KEY_PASSWORD=$3
+
+# Check if required files exist
+for file in "$PASS_FILE" "$ENCRYPTED_PRIVATE_KEY_FILE"; do
+    if [ ! -f "$file" ]; then
+        echo "Error: File not found: $file" >&2
+        exit 1
+    fi
+done
+
+PRIVATE_KEY_METADATA_FILE="${ENCRYPTED_PRIVATE_KEY_FILE}.metadata"
+
+if [ ! -f "$PRIVATE_KEY_METADATA_FILE" ]; then
+    echo "Metadata file not found: $PRIVATE_KEY_METADATA_FILE" >&2
+    exit 1
+fi
+
+# Read private key metadata
+PRIVATE_KEY_SALT=$(grep "Salt:" "$PRIVATE_KEY_METADATA_FILE" | cut -d' ' -f2)
+PRIVATE_KEY_ITERATIONS=$(grep "Iterations:" "$PRIVATE_KEY_METADATA_FILE" | cut -d' ' -f2)
+PRIVATE_KEY_IV=$(grep "IV:" "$PRIVATE_KEY_METADATA_FILE" | cut -d' ' -f2)
+
+# Derive key for private key decryption
+PRIVATE_KEY_INFO=$(./derive-key.sh "$PRIVATE_KEY_PASSWORD" "$PRIVATE_KEY_SALT" "$PRIVATE_KEY_ITERATIONS")
+PRIVATE_KEY_ENCRYPTION_KEY=$(echo "$PRIVATE_KEY_INFO" | grep "Key:" | cut -d' ' -f2)
+
+# Decrypt the private key (in memory)
+DECRYPTED_PRIVATE_KEY=$(openssl enc -d -aes-256-cbc -in "$ENCRYPTED_PRIVATE_KEY_FILE" -K "$PRIVATE_KEY_ENCRYPTION_KEY" -iv "$PRIVATE_KEY_IV" 2>/dev/null | tr -d '\0')
+
+# A wrong password usually yields garbage rather than an openssl error
+if ! echo "$DECRYPTED_PRIVATE_KEY" | grep -q "PRIVATE KEY"; then
+    echo "Error: Failed to decrypt the private key, check the password." >&2
+    exit 1
+fi
+
+# Now use the decrypted private key to decrypt the file password
+RANDOM_PASS=$(echo "$DECRYPTED_PRIVATE_KEY" | openssl pkeyutl -decrypt -inkey /dev/stdin -in "$PASS_FILE")
+unset DECRYPTED_PRIVATE_KEY
+
+if [ -z "$RANDOM_PASS" ]; then
+    echo "Error: Failed to decrypt the file password." >&2
+    exit 1
+fi
+
+# Finally, decrypt the stream using the decrypted password
+exec openssl enc -d -aes-256-cbc -pbkdf2 -iter 100000 -k "$RANDOM_PASS"
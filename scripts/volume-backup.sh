@@ -1,9 +1,15 @@
 #!/bin/bash
 
+set -o pipefail
+
 usage() {
     echo "Usage: $0 <volume_name> <backup_file_name> [options]"
     echo "Options:"
-    echo "  -n, --no-compression     Create backup without compression"
+    echo "  -n, --no-compression     Create backup without compression (same as --codec none)"
+    echo "  -c, --codec <codec>      Compression codec: none, gzip (default), zstd, xz or lz4"
+    echo "  -l, --level <level>      Compression level (codec default when omitted)"
+    echo "  -t, --threads <threads>  Compression threads for codecs that support it (gzip needs pigz)"
+    echo "  -T, --files-from <file>  Only archive the paths listed in <file> (relative to the volume root)"
     echo "  -h, --help               Display this help message"
 }
 
@@ -16,30 +22,99 @@ VOLUME_NAME=$1
 BACKUP_FILE=$2
 shift 2
 
-COMPRESS=true
+CODEC=gzip
+LEVEL=""
+THREADS=""
+FILES_FROM=""
 
 while [ "$#" -gt 0 ]; do
     case "$1" in
-        -n|--no-compression) COMPRESS=false ;;
+        -n|--no-compression) CODEC=none ;;
+        -c|--codec) CODEC=$2; shift ;;
+        -l|--level) LEVEL=$2; shift ;;
+        -t|--threads) THREADS=$2; shift ;;
+        -T|--files-from) FILES_FROM=$2; shift ;;
         -h|--help) usage; exit 0 ;;
         *) echo "Unknown option: $1"; usage; exit 1 ;;
     esac
     shift
 done
 
+# Build the compressor command for the selected codec. Compression runs on the
+# host, reading the tar stream produced inside the container.
+case "$CODEC" in
+    none)
+        COMPRESSOR="cat"
+        ;;
+    gzip)
+        if [ -n "$THREADS" ] && [ "$THREADS" != "1" ] && command -v pigz > /dev/null; then
+            COMPRESSOR="pigz -c -p $THREADS ${LEVEL:+-$LEVEL}"
+        else
+            COMPRESSOR="gzip -c ${LEVEL:+-$LEVEL}"
+        fi
+        ;;
+    zstd)
+        ULTRA=""
+        if [ -n "$LEVEL" ] && [ "$LEVEL" -gt 19 ]; then
+            ULTRA="--ultra"
+        fi
+        COMPRESSOR="zstd -q -c $ULTRA ${LEVEL:+-$LEVEL} -T${THREADS:-0}"
+        ;;
+    xz)
+        COMPRESSOR="xz -c ${LEVEL:+-$LEVEL} -T${THREADS:-0}"
+        ;;
+    lz4)
+        COMPRESSOR="lz4 -q -c ${LEVEL:+-$LEVEL}"
+        ;;
+    *)
+        echo "Unknown codec: $CODEC"
+        usage
+        exit 1
+        ;;
+esac
+
+COMPRESSOR_BIN=${COMPRESSOR%% *}
+if ! command -v "$COMPRESSOR_BIN" > /dev/null; then
+    echo "Error: $COMPRESSOR_BIN is required for the $CODEC codec but was not found."
+    exit 1
+fi
+
 start_time=$(date +%s.%N)
 
-if [ "$COMPRESS" = true ]; then
-    # Compressed backup with size reporting
+TAR_SOURCE="."
+LIST_MOUNT=""
+if [ -n "$FILES_FROM" ]; then
+    if [ ! -f "$FILES_FROM" ]; then
+        echo "Error: File list $FILES_FROM does not exist."
+        exit 1
+    fi
+    TAR_SOURCE="-T /filelist"
+    LIST_MOUNT="-v $FILES_FROM:/filelist:ro"
+fi
+
+if [ -n "$FILES_FROM" ]; then
+    orig_size=$(docker run --rm -v $VOLUME_NAME:/volume $LIST_MOUNT alpine sh -c 'cd /volume && cat /filelist | while IFS= read -r f; do du -sb "$f"; done' | awk '{s+=$1} END {print s+0}')
+else
     orig_size=$(docker run --rm -v $VOLUME_NAME:/volume alpine du -sb /volume | cut -f1)
-    docker run --rm -v $VOLUME_NAME:/volume -v $(dirname $BACKUP_FILE):/backup alpine tar -czpf /backup/$(basename $BACKUP_FILE) -C /volume .
-    final_size=$(stat -c%s "$BACKUP_FILE")
+fi
+
+if [ -n "$FILES_FROM" ] && [ ! -s "$FILES_FROM" ]; then
+    # Nothing changed: busybox tar refuses to create an empty archive
+    tar -cf - -T /dev/null | $COMPRESSOR > "$BACKUP_FILE"
+else
+    docker run --rm -v $VOLUME_NAME:/volume $LIST_MOUNT alpine tar -cpf - -C /volume $TAR_SOURCE | $COMPRESSOR > "$BACKUP_FILE"
+fi
+
+if [ $? -ne 0 ]; then
+    echo "Error: Failed to create backup of volume $VOLUME_NAME"
+    rm -f "$BACKUP_FILE"
+    exit 1
+fi
+
+final_size=$(stat -c%s "$BACKUP_FILE")
+if [ "$CODEC" != "none" ] && [ "$orig_size" -gt 0 ]; then
     compression_ratio=$(echo "scale=2; $final_size / $orig_size" | bc)
 else
-    # Uncompressed backup
-    docker run --rm -v $VOLUME_NAME:/volume -v $(dirname $BACKUP_FILE):/backup alpine tar -cpf /backup/$(basename $BACKUP_FILE) -C /volume .
-    final_size=$(stat -c%s "$BACKUP_FILE")
-    orig_size=$final_size
     compression_ratio=1
 fi
 
@@ -47,7 +122,8 @@ end_time=$(date +%s.%N)
 elapsed=$(echo "$end_time - $start_time" | bc)
 
 echo "Backup of volume $VOLUME_NAME created as $BACKUP_FILE"
+echo "Codec: $CODEC"
 echo "Original size: $orig_size bytes"
 echo "Final size: $final_size bytes"
 echo "Compression ratio: $compression_ratio"
-echo "Time elapsed: $elapsed seconds"
\ No newline at end of file
+echo "Time elapsed: $elapsed seconds"
@@ -0,0 +1,33 @@
+#!/bin/bash
+
+usage() {
+    echo "Usage: $0 stat <volume_name>"
+    echo "       $0 hash <volume_name> < file_list"
+    echo "Modes:"
+    echo "  stat    Print inode|size|mtime|type|path for every file and symlink in the volume"
+    echo "  hash    Print the sha256 of every path read from stdin (one per line)"
+}
+
+if [ "$#" -ne 2 ]; then
+    usage
+    exit 1
+fi
+
+MODE=$1
+VOLUME_NAME=$2
+
+case "$MODE" in
+    stat)
+        docker run --rm -v $VOLUME_NAME:/volume:ro alpine sh -c \
+            'cd /volume && find . \( -type f -o -type l \) -exec stat -c "%i|%s|%Y|%F|%n" {} +'
+        ;;
+    hash)
+        docker run --rm -i -v $VOLUME_NAME:/volume:ro alpine sh -c \
+            'cd /volume && while IFS= read -r f; do [ -f "$f" ] && sha256sum "$f"; done; true'
+        ;;
+    *)
+        echo "Unknown mode: $MODE"
+        usage
+        exit 1
+        ;;
+esac
@@ -1,20 +1,96 @@
 #!/bin/bash
 
-if [ "$#" -ne 2 ]; then
-    echo "Usage: $0 <volume_name> <backup_file_name>"
+set -o pipefail
+
+usage() {
+    echo "Usage: $0 <volume_name> <backup_file_name> [options]"
+    echo "Options:"
+    echo "  -c, --codec <codec>               Compression codec of the backup: none, gzip, zstd, xz or lz4"
+    echo "                                    (detected from the file extension when omitted)"
+    echo "  -i, --incremental [deleted_list]  Apply the backup on top of the volume contents instead of"
+    echo "                                    replacing them, then remove the paths listed in deleted_list"
+}
+
+if [ "$#" -lt 2 ]; then
+    usage
     exit 1
 fi
 
 VOLUME_NAME=$1
 BACKUP_FILE=$2
+shift 2
+
+INCREMENTAL=false
+DELETED_LIST=""
+CODEC=""
+
+while [ "$#" -gt 0 ]; do
+    case "$1" in
+        -c|--codec) CODEC=$2; shift ;;
+        -i|--incremental)
+            INCREMENTAL=true
+            if [ -n "$2" ] && [ "${2:0:1}" != "-" ]; then
+                DELETED_LIST=$2
+                shift
+            fi
+            ;;
+        -h|--help) usage; exit 0 ;;
+        *) echo "Unknown option: $1"; usage; exit 1 ;;
+    esac
+    shift
+done
 
 if [ ! -f "$BACKUP_FILE" ]; then
     echo "Error: Backup file $BACKUP_FILE does not exist."
     exit 1
 fi
 
+if [ -z "$CODEC" ]; then
+    case "$BACKUP_FILE" in
+        *.tar.gz|*.tgz) CODEC=gzip ;;
+        *.tar.zst) CODEC=zstd ;;
+        *.tar.xz) CODEC=xz ;;
+        *.tar.lz4) CODEC=lz4 ;;
+        *) CODEC=none ;;
+    esac
+fi
+
+case "$CODEC" in
+    none) DECOMPRESSOR="cat" ;;
+    gzip) DECOMPRESSOR="gzip -dc" ;;
+    zstd) DECOMPRESSOR="zstd -q -dc" ;;
+    xz) DECOMPRESSOR="xz -dc" ;;
+    lz4) DECOMPRESSOR="lz4 -q -dc" ;;
+    *) echo "Unknown codec: $CODEC"; usage; exit 1 ;;
+esac
+
+DECOMPRESSOR_BIN=${DECOMPRESSOR%% *}
+if ! command -v "$DECOMPRESSOR_BIN" > /dev/null; then
+    echo "Error: $DECOMPRESSOR_BIN is required for the $CODEC codec but was not found."
+    exit 1
+fi
+
 docker volume inspect $VOLUME_NAME > /dev/null 2>&1 || docker volume create $VOLUME_NAME
 
-docker run --rm -v $VOLUME_NAME:/volume -v $BACKUP_FILE:/backup.tar.gz alpine sh -c "rm -rf /volume/* /volume/..?* /volume/.[!.]* ; tar -xzpf /backup.tar.gz -C /volume"
+if [ "$INCREMENTAL" = true ]; then
+    DELETED_MOUNT=""
+    DELETE_CMD="true"
+    if [ -n "$DELETED_LIST" ]; then
+        if [ ! -f "$DELETED_LIST" ]; then
+            echo "Error: Deleted list $DELETED_LIST does not exist."
+            exit 1
+        fi
+        DELETED_MOUNT="-v $DELETED_LIST:/deleted.txt:ro"
+        DELETE_CMD='while IFS= read -r p; do [ -n "$p" ] && rm -rf "/volume/$p"; done < /deleted.txt'
+    fi
+    $DECOMPRESSOR "$BACKUP_FILE" | docker run --rm -i -v $VOLUME_NAME:/volume $DELETED_MOUNT alpine sh -c "tar -xpf - -C /volume && $DELETE_CMD"
+else
+    $DECOMPRESSOR "$BACKUP_FILE" | docker run --rm -i -v $VOLUME_NAME:/volume alpine sh -c "rm -rf /volume/* /volume/..?* /volume/.[!.]* ; tar -xpf - -C /volume"
+fi
+
+if [ $? -ne 0 ]; then
+    echo "Error: Failed to restore $BACKUP_FILE to volume $VOLUME_NAME"
+    exit 1
+fi
 
 echo "Restore of $BACKUP_FILE to volume $VOLUME_NAME completed"